@@ -12,7 +12,9 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -179,3 +181,551 @@ url = "`+srv.URL+`"
 	require.NoError(t, err, "should exit 0 even with oversized fields")
 	assert.Contains(t, stderr.String(), "invalid notification")
 }
+
+func TestEndToEndFilters(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	var mu sync.Mutex
+	var topics []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		topics = append(topics, r.URL.Path)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`[[notifiers.ntfy]]
+url = "`+srv.URL+`/phone"
+on = ["permission_prompt"]
+
+[[notifiers.ntfy]]
+url = "`+srv.URL+`/desktop"
+on = ["idle_prompt"]
+
+[[notifiers.ntfy]]
+url = "`+srv.URL+`/work"
+projects = ["api-*"]
+exclude_projects = ["api-legacy"]
+`), 0644))
+
+	send := func(notifType, cwd string) []string {
+		t.Helper()
+		mu.Lock()
+		topics = nil
+		mu.Unlock()
+		input, err := json.Marshal(map[string]string{"message": "hi", "cwd": cwd, "notification_type": notifType})
+		require.NoError(t, err)
+
+		cmd := exec.CommandContext(context.Background(), testBinary, "--config", configPath)
+		cmd.Stdin = bytes.NewReader(input)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		require.NoError(t, cmd.Run(), "stderr: %s", stderr.String())
+		assert.NotContains(t, stderr.String(), "ERROR")
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		return topics
+	}
+
+	assert.ElementsMatch(t, []string{"/phone", "/work"}, send("permission_prompt", "/src/api-users"))
+	assert.ElementsMatch(t, []string{"/desktop"}, send("idle_prompt", "/src/api-legacy"))
+	assert.Empty(t, send("auth_success", "/src/web"))
+}
+
+func TestEndToEndInvalidFilter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`[[notifiers.ntfy]]
+url = "http://127.0.0.1:1/topic"
+cwd_match = "("
+`), 0644))
+
+	cmd := exec.CommandContext(context.Background(), testBinary, "--config", configPath)
+	cmd.Stdin = strings.NewReader(`{"message":"hi"}`)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	require.NoError(t, cmd.Run(), "should exit 0 even with an invalid filter")
+	assert.Contains(t, stderr.String(), "cwd_match")
+}
+
+const routesConfig = `[global]
+default_route = ["desktop"]
+
+[[routes]]
+name = "permission prompts"
+notifiers = ["phone", "archive"]
+continue = true
+[routes.match]
+types = ["permission_prompt"]
+
+[[routes]]
+name = "work projects"
+notifiers = ["work"]
+[routes.match]
+projects = ["api-*"]
+
+[[notifiers.ntfy]]
+id = "phone"
+url = "URL/phone"
+
+[[notifiers.ntfy]]
+id = "desktop"
+url = "URL/desktop"
+
+[[notifiers.ntfy]]
+id = "work"
+url = "URL/work"
+
+[[notifiers.ntfy]]
+id = "archive"
+url = "URL/archive"
+exclude_projects = ["secret"]
+`
+
+func TestEndToEndRoutes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	var mu sync.Mutex
+	var topics []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		topics = append(topics, r.URL.Path)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte(strings.ReplaceAll(routesConfig, "URL", srv.URL)), 0644))
+
+	send := func(notifType, cwd string) []string {
+		t.Helper()
+		mu.Lock()
+		topics = nil
+		mu.Unlock()
+		input, err := json.Marshal(map[string]string{"message": "hi", "cwd": cwd, "notification_type": notifType})
+		require.NoError(t, err)
+
+		cmd := exec.CommandContext(context.Background(), testBinary, "--config", configPath)
+		cmd.Stdin = bytes.NewReader(input)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		require.NoError(t, cmd.Run(), "stderr: %s", stderr.String())
+		assert.NotContains(t, stderr.String(), "ERROR")
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		return topics
+	}
+
+	assert.ElementsMatch(t, []string{"/phone", "/archive", "/work"}, send("permission_prompt", "/src/api-users"))
+	assert.ElementsMatch(t, []string{"/phone"}, send("permission_prompt", "/src/secret"))
+	assert.ElementsMatch(t, []string{"/work"}, send("idle_prompt", "/src/api-users"))
+	assert.ElementsMatch(t, []string{"/desktop"}, send("idle_prompt", "/src/web"))
+}
+
+func TestEndToEndRouteExplain(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte(strings.ReplaceAll(routesConfig, "URL", "http://127.0.0.1:1")), 0644))
+
+	cmd := exec.CommandContext(context.Background(), testBinary, "--config", configPath, "route", "--explain")
+	cmd.Stdin = strings.NewReader(`{"message":"hi","cwd":"/src/secret","notification_type":"permission_prompt"}`)
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	assert.Equal(t, `route #1 "permission prompts": matched
+route #2 "work projects": no match (project "secret" does not match [api-*])
+route targets: phone, archive
+archive: filtered out
+notifiers: phone
+`, string(output))
+}
+
+func TestEndToEndRouteUnknownTarget(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`[[routes]]
+notifiers = ["pager"]
+
+[[notifiers.ntfy]]
+url = "http://127.0.0.1:1/topic"
+`), 0644))
+
+	cmd := exec.CommandContext(context.Background(), testBinary, "--config", configPath, "route")
+	cmd.Stdin = strings.NewReader(`{"message":"hi"}`)
+	output, err := cmd.CombinedOutput()
+	require.Error(t, err)
+	assert.Contains(t, string(output), `route target \"pager\" matches no notifier id or plugin`)
+}
+
+func TestEndToEndInstanceSelection(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	var mu sync.Mutex
+	var topics []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		topics = append(topics, r.URL.Path)
+		mu.Unlock()
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`[[notifiers.ntfy]]
+id = "phone"
+url = "`+srv.URL+`/phone"
+
+[[notifiers.ntfy]]
+url = "`+srv.URL+`/broken"
+
+[[notifiers.ntfy]]
+id = "old"
+enabled = false
+url = "`+srv.URL+`/old"
+`), 0644))
+
+	run := func(args ...string) ([]string, string) {
+		t.Helper()
+		mu.Lock()
+		topics = nil
+		mu.Unlock()
+
+		cmd := exec.CommandContext(context.Background(), testBinary, append([]string{"--config", configPath}, args...)...)
+		cmd.Stdin = strings.NewReader(`{"message":"hi"}`)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		require.NoError(t, cmd.Run(), "stderr: %s", stderr.String())
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		return topics, stderr.String()
+	}
+
+	got, stderr := run()
+	assert.ElementsMatch(t, []string{"/phone", "/broken"}, got)
+	assert.Contains(t, stderr, "ntfy#2: server returned 500")
+
+	got, _ = run("--only", "phone")
+	assert.Equal(t, []string{"/phone"}, got)
+
+	got, _ = run("--except", "phone")
+	assert.Equal(t, []string{"/broken"}, got)
+
+	got, _ = run("--only", "ntfy", "--except", "ntfy#2")
+	assert.Equal(t, []string{"/phone"}, got)
+
+	got, stderr = run("--only", "pager")
+	assert.Empty(t, got)
+	assert.Contains(t, stderr, `no notifier with id or plugin \"pager\"`)
+}
+
+func TestEndToEndTestCommandOnly(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`[[notifiers.ntfy]]
+id = "phone"
+url = "http://127.0.0.1:1/phone"
+`), 0644))
+
+	cmd := exec.CommandContext(context.Background(), testBinary, "--config", configPath, "--only", "phone", "test")
+	output, err := cmd.CombinedOutput()
+	require.Error(t, err)
+	assert.Contains(t, string(output), "error: phone: sending request")
+
+	cmd = exec.CommandContext(context.Background(), testBinary, "--config", configPath, "--except", "phone", "test")
+	output, err = cmd.CombinedOutput()
+	require.Error(t, err)
+	assert.Contains(t, string(output), "no notifiers enabled or selected")
+}
+
+func TestEndToEndDuplicateID(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`[[notifiers.ntfy]]
+id = "phone"
+url = "http://127.0.0.1:1/a"
+
+[[notifiers.ntfy]]
+id = "phone"
+url = "http://127.0.0.1:1/b"
+`), 0644))
+
+	cmd := exec.CommandContext(context.Background(), testBinary, "--config", configPath, "test")
+	output, err := cmd.CombinedOutput()
+	require.Error(t, err)
+	assert.Contains(t, string(output), `duplicate notifier id \"phone\"`)
+}
+
+func TestEndToEndSeverity(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	var gotPriority, gotTitle string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPriority = r.Header.Get("Priority")
+		gotTitle = r.Header.Get("Title")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`[global.severity]
+idle_prompt = "low"
+
+[[notifiers.ntfy]]
+url = "`+srv.URL+`/topic"
+title = "{{.Severity}}: {{.Project}}"
+severity_priority = { low = "min", high = "high" }
+`), 0644))
+
+	send := func(notifType string) {
+		t.Helper()
+		cmd := exec.CommandContext(context.Background(), testBinary, "--config", configPath)
+		cmd.Stdin = strings.NewReader(`{"message":"hi","cwd":"/src/app","notification_type":"` + notifType + `"}`)
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err)
+		assert.Empty(t, string(output))
+	}
+
+	send("idle_prompt")
+	assert.Equal(t, "min", gotPriority)
+	assert.Equal(t, "low: app", gotTitle)
+
+	send("permission_prompt")
+	assert.Equal(t, "high", gotPriority)
+	assert.Equal(t, "high: app", gotTitle)
+}
+
+func TestEndToEndSchedule(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	var mu sync.Mutex
+	var topics []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		topics = append(topics, r.URL.Path)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	// A window that starts hours from now, so the test never runs inside it.
+	now := time.Now().UTC()
+	quiet := now.Add(6*time.Hour).Format("15:04") + "-" + now.Add(12*time.Hour).Format("15:04")
+
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`[global]
+active_hours = ["`+quiet+`"]
+timezone = "UTC"
+bypass_on = ["permission_prompt"]
+
+[[notifiers.ntfy]]
+id = "always"
+url = "`+srv.URL+`/always"
+
+[[notifiers.ntfy]]
+id = "scheduled"
+url = "`+srv.URL+`/scheduled"
+active_hours = ["`+quiet+`"]
+timezone = "UTC"
+`), 0644))
+
+	send := func(notifType string) []string {
+		t.Helper()
+		mu.Lock()
+		topics = nil
+		mu.Unlock()
+
+		cmd := exec.CommandContext(context.Background(), testBinary, "--config", configPath)
+		cmd.Stdin = strings.NewReader(`{"message":"hi","notification_type":"` + notifType + `"}`)
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err)
+		assert.Empty(t, string(output))
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		return topics
+	}
+
+	assert.Empty(t, send("idle_prompt"))
+	assert.Equal(t, []string{"/always"}, send("permission_prompt"))
+
+	cmd := exec.CommandContext(context.Background(), testBinary, "--config", configPath, "route", "--explain")
+	cmd.Stdin = strings.NewReader(`{"message":"hi","notification_type":"permission_prompt"}`)
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, "output: %s", output)
+	assert.Contains(t, string(output), "scheduled: outside schedule (")
+	assert.Contains(t, string(output), "notifiers: always\n")
+}
+
+func TestEndToEndInvalidSchedule(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`[[notifiers.ntfy]]
+url = "https://ntfy.example.com/topic"
+weekdays = ["someday"]
+`), 0644))
+
+	cmd := exec.CommandContext(context.Background(), testBinary, "--config", configPath, "test")
+	output, err := cmd.CombinedOutput()
+	require.Error(t, err)
+	assert.Contains(t, string(output), "schedule for ntfy: weekdays: unknown day")
+}
+
+func TestEndToEndDedupAndRateLimit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	var mu sync.Mutex
+	var topics []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		topics = append(topics, r.URL.Path)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`[global]
+dedup_window = "5m"
+
+[[notifiers.ntfy]]
+id = "limited"
+url = "`+srv.URL+`/limited"
+max_per_minute = 1
+
+[[notifiers.ntfy]]
+id = "free"
+url = "`+srv.URL+`/free"
+`), 0644))
+
+	send := func(message string) []string {
+		t.Helper()
+		mu.Lock()
+		topics = nil
+		mu.Unlock()
+
+		cmd := exec.CommandContext(context.Background(), testBinary, "--config", configPath)
+		cmd.Env = append(os.Environ(), "XDG_STATE_HOME="+filepath.Join(dir, "state"))
+		cmd.Stdin = strings.NewReader(`{"message":"` + message + `","session_id":"s1","notification_type":"idle_prompt"}`)
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err)
+		assert.Empty(t, string(output))
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		return topics
+	}
+
+	assert.ElementsMatch(t, []string{"/limited", "/free"}, send("waiting for input"))
+	assert.Empty(t, send("waiting for input"), "duplicate within dedup_window")
+	assert.Equal(t, []string{"/free"}, send("still waiting"), "limited is out of tokens")
+	assert.FileExists(t, filepath.Join(dir, "state", "claude-notifier", "state.json"))
+}
+
+func TestEndToEndFailedSendIsNotRecorded(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	var mu sync.Mutex
+	var topics []string
+	failing := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		topics = append(topics, r.URL.Path)
+		if failing && r.URL.Path == "/flaky" {
+			w.WriteHeader(http.StatusBadGateway)
+
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`[global]
+dedup_window = "5m"
+
+[[notifiers.ntfy]]
+id = "flaky"
+url = "`+srv.URL+`/flaky"
+max_per_minute = 1
+
+[[notifiers.ntfy]]
+id = "steady"
+url = "`+srv.URL+`/steady"
+`), 0644))
+
+	send := func() []string {
+		t.Helper()
+		mu.Lock()
+		topics = nil
+		mu.Unlock()
+
+		cmd := exec.CommandContext(context.Background(), testBinary, "--config", configPath)
+		cmd.Env = append(os.Environ(), "XDG_STATE_HOME="+filepath.Join(dir, "state"))
+		cmd.Stdin = strings.NewReader(`{"message":"waiting","session_id":"s1","notification_type":"idle_prompt"}`)
+		require.NoError(t, cmd.Run())
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		return topics
+	}
+
+	assert.ElementsMatch(t, []string{"/flaky", "/steady"}, send())
+
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	assert.Equal(t, []string{"/flaky"}, send(), "the failed send is retried and keeps its token")
+	assert.Empty(t, send(), "both have now sent it")
+}
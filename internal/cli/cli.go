@@ -9,10 +9,14 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/felipeelias/claude-notifier/internal/config"
 	"github.com/felipeelias/claude-notifier/internal/dispatch"
+	"github.com/felipeelias/claude-notifier/internal/filter"
 	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/route"
+	"github.com/felipeelias/claude-notifier/internal/schedule"
 	ucli "github.com/urfave/cli/v2"
 )
 
@@ -21,13 +25,18 @@ const (
 	configFilePerms = 0600
 )
 
+// Commander is implemented by notifiers that contribute their own subcommands.
+type Commander interface {
+	Command() *ucli.Command
+}
+
 // New creates the CLI application.
 func New(version string, reg *notifier.Registry) *ucli.App {
 	return &ucli.App{
 		Name:    "claude-notifier",
 		Usage:   "Notification dispatcher for Claude Code",
 		Version: version,
-		Flags: []ucli.Flag{
+		Flags: append([]ucli.Flag{
 			&ucli.StringFlag{
 				Name:    "config",
 				Aliases: []string{"c"},
@@ -35,42 +44,116 @@ func New(version string, reg *notifier.Registry) *ucli.App {
 				Value:   config.DefaultPath(),
 				EnvVars: []string{"CLAUDE_NOTIFIER_CONFIG"},
 			},
-		},
+		}, selectionFlags()...),
 		Action: func(cmd *ucli.Context) error {
 			return sendAction(cmd, reg)
 		},
-		Commands: []*ucli.Command{
+		Commands: append([]*ucli.Command{
 			initCommand(reg),
 			testCommand(reg),
-		},
+			routeCommand(reg),
+		}, pluginCommands(reg)...),
+	}
+}
+
+// pluginCommands collects subcommands from plugins implementing Commander.
+func pluginCommands(reg *notifier.Registry) []*ucli.Command {
+	all := reg.All()
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+
+	var cmds []*ucli.Command
+	for _, name := range names {
+		if c, ok := all[name]().(Commander); ok {
+			cmds = append(cmds, c.Command())
+		}
+	}
+
+	return cmds
 }
 
-func loadNotifiers(configPath string, reg *notifier.Registry) ([]notifier.Notifier, *config.Config, error) {
+func loadNotifiers(configPath string, reg *notifier.Registry) ([]instance, *config.Config, error) {
 	cfg, err := config.Load(configPath)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	var notifiers []notifier.Notifier
-	for name, primitives := range cfg.Notifiers {
+	names := make([]string, 0, len(cfg.Notifiers))
+	for name := range cfg.Notifiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var instances []instance
+	ids := map[string]bool{}
+	for _, name := range names {
+		primitives := cfg.Notifiers[name]
 		factory, ok := reg.All()[name]
 		if !ok {
 			slog.Warn("unknown notifier plugin, skipping", "name", name)
 
 			continue
 		}
-		for _, prim := range primitives {
-			n := factory()
-			err := cfg.Decode(prim, n)
+		for i, prim := range primitives {
+			var keys instanceKeys
+			err := cfg.Decode(prim, &keys)
 			if err != nil {
 				return nil, nil, fmt.Errorf("decoding config for %s: %w", name, err)
 			}
-			notifiers = append(notifiers, n)
+			id := keys.ID
+			if id == "" {
+				id = defaultID(name, i, len(primitives))
+			}
+			if ids[id] {
+				return nil, nil, fmt.Errorf("duplicate notifier id %q", id)
+			}
+			ids[id] = true
+			if keys.MaxPerMinute < 0 {
+				return nil, nil, fmt.Errorf("max_per_minute for %s must not be negative", id)
+			}
+
+			n := factory()
+			err = cfg.Decode(prim, n)
+			if err != nil {
+				return nil, nil, fmt.Errorf("decoding config for %s: %w", id, err)
+			}
+
+			var f filter.Filter
+			err = cfg.Decode(prim, &f)
+			if err != nil {
+				return nil, nil, fmt.Errorf("decoding filter for %s: %w", id, err)
+			}
+			err = f.Compile()
+			if err != nil {
+				return nil, nil, fmt.Errorf("filter for %s: %w", id, err)
+			}
+
+			var sched schedule.Schedule
+			err = cfg.Decode(prim, &sched)
+			if err != nil {
+				return nil, nil, fmt.Errorf("decoding schedule for %s: %w", id, err)
+			}
+			err = sched.Compile()
+			if err != nil {
+				return nil, nil, fmt.Errorf("schedule for %s: %w", id, err)
+			}
+
+			instances = append(instances, instance{
+				plugin:       name,
+				id:           id,
+				disabled:     keys.Enabled != nil && !*keys.Enabled,
+				notifier:     n,
+				filter:       f,
+				schedule:     sched,
+				maxPerMinute: keys.MaxPerMinute,
+			})
 		}
 	}
 
-	return notifiers, cfg, nil
+	return instances, cfg, nil
 }
 
 func sendAction(cmd *ucli.Context, reg *notifier.Registry) error {
@@ -92,12 +175,13 @@ func sendAction(cmd *ucli.Context, reg *notifier.Registry) error {
 	}
 
 	configPath := cmd.String("config")
-	notifiers, cfg, err := loadNotifiers(configPath, reg)
+	instances, cfg, err := loadNotifiers(configPath, reg)
 	if err != nil {
 		slog.Error("loading config", "error", err)
 
 		return nil // don't fail the hook
 	}
+	notif.Severity = cfg.Severity(notif.NotificationType)
 
 	ctx := cmd.Context
 	if cfg.Global.Timeout > 0 {
@@ -106,12 +190,49 @@ func sendAction(cmd *ucli.Context, reg *notifier.Registry) error {
 		defer cancel()
 	}
 
-	if errs := dispatch.Send(ctx, notifiers, notif); len(errs) > 0 {
-		for _, err := range errs {
-			slog.Error("sending notification", "error", err)
+	router, err := newRouter(cfg, instances)
+	if err != nil {
+		slog.Error("loading config", "error", err)
+
+		return nil // don't fail the hook
+	}
+
+	instances, err = selectInstances(cmd, instances)
+	if err != nil {
+		slog.Error("selecting notifiers", "error", err)
+
+		return nil // don't fail the hook
+	}
+
+	if notif.Resumed() {
+		// The user is back; clear what was shown instead of notifying.
+		for _, err := range dispatch.Clear(ctx, allNotifiers(instances), notif) {
+			slog.Error("clearing notifications", "error", err)
 		}
+
+		return nil
+	}
+
+	env := route.CurrentEnv()
+	if ok, reason := cfg.Global.Allows(notif, env.Now); !ok {
+		slog.Debug("outside global schedule, not sending", "reason", reason)
+
+		return nil
+	}
+
+	if router != nil {
+		instances = routed(instances, router.Route(notif, env).Targets)
 	}
 
+	limits := newLimiter(cfg, notif, env.Now)
+	instances = limits.allow(matchingInstances(instances, notif, env.Now))
+
+	failed, errs := send(ctx, instances, notif)
+	for _, err := range errs {
+		slog.Error("sending notification", "error", err)
+	}
+	limits.refund(failed)
+
 	return nil // always succeed
 }
 
@@ -151,19 +272,28 @@ func testCommand(reg *notifier.Registry) *ucli.Command {
 		Usage: "Send a test notification to all configured notifiers",
 		Action: func(cmd *ucli.Context) error {
 			configPath := cmd.String("config")
-			notifiers, cfg, err := loadNotifiers(configPath, reg)
+			instances, cfg, err := loadNotifiers(configPath, reg)
 			if err != nil {
 				return fmt.Errorf("loading config: %w", err)
 			}
 
-			if len(notifiers) == 0 {
+			if len(instances) == 0 {
 				return fmt.Errorf("no notifiers configured in %s", configPath)
 			}
 
+			instances, err = selectInstances(cmd, instances)
+			if err != nil {
+				return err
+			}
+			if len(instances) == 0 {
+				return errors.New("no notifiers enabled or selected")
+			}
+
 			notif := notifier.Notification{
 				Message: "This is a test notification from claude-notifier",
 				Title:   "claude-notifier test",
 			}
+			notif.Severity = cfg.Severity(notif.NotificationType)
 
 			ctx := cmd.Context
 			if cfg.Global.Timeout > 0 {
@@ -172,7 +302,8 @@ func testCommand(reg *notifier.Registry) *ucli.Command {
 				defer cancel()
 			}
 
-			if errs := dispatch.Send(ctx, notifiers, notif); len(errs) > 0 {
+			// Filters are skipped so every channel can be tested.
+			if errs := dispatch.Send(ctx, allNotifiers(instances), notif); len(errs) > 0 {
 				for _, err := range errs {
 					_, _ = fmt.Fprintf(cmd.App.ErrWriter, "error: %s\n", err)
 				}
@@ -2,6 +2,7 @@ package cli_test
 
 import (
 	"bytes"
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -10,6 +11,7 @@ import (
 	"github.com/felipeelias/claude-notifier/internal/notifier"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	ucli "github.com/urfave/cli/v2"
 )
 
 func TestInitCommand(t *testing.T) {
@@ -47,3 +49,33 @@ func TestVersionFlag(t *testing.T) {
 	require.NoError(t, err)
 	assert.Contains(t, buf.String(), "1.2.3")
 }
+
+type commandNotifier struct{ ran *bool }
+
+func (c *commandNotifier) Name() string { return "cmd" }
+
+func (c *commandNotifier) Send(context.Context, notifier.Notification) error { return nil }
+
+func (c *commandNotifier) Command() *ucli.Command {
+	return &ucli.Command{
+		Name: "cmd",
+		Action: func(*ucli.Context) error {
+			*c.ran = true
+
+			return nil
+		},
+	}
+}
+
+func TestPluginCommands(t *testing.T) {
+	var ran bool
+	reg := notifier.NewRegistry()
+	require.NoError(t, reg.Register("cmd", func() notifier.Notifier {
+		return &commandNotifier{ran: &ran}
+	}))
+
+	app := appcli.New("test", reg)
+	err := app.Run([]string{"claude-notifier", "cmd"})
+	require.NoError(t, err)
+	assert.True(t, ran)
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/filter"
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/schedule"
+	ucli "github.com/urfave/cli/v2"
+)
+
+// instance is one [[notifiers.*]] block: the decoded notifier and the keys
+// every block accepts.
+type instance struct {
+	plugin   string
+	id       string
+	disabled bool
+	notifier notifier.Notifier
+	filter   filter.Filter
+	schedule schedule.Schedule
+	// maxPerMinute limits how often the instance sends; 0 is unlimited.
+	maxPerMinute int
+}
+
+// instanceKeys are the keys every [[notifiers.*]] block accepts besides
+// the plugin's own, the filter keys and the schedule keys.
+type instanceKeys struct {
+	ID           string `toml:"id"`
+	Enabled      *bool  `toml:"enabled"`
+	MaxPerMinute int    `toml:"max_per_minute"`
+}
+
+// defaultID names a block without an id: the plugin name, numbered from 1
+// when the plugin has several blocks.
+func defaultID(plugin string, index, count int) string {
+	if count == 1 {
+		return plugin
+	}
+
+	return fmt.Sprintf("%s#%d", plugin, index+1)
+}
+
+// targetedBy reports whether a route target or --only/--except value names
+// the instance: by its id, or by its plugin name, which names all of the
+// plugin's instances.
+func (inst instance) targetedBy(target string) bool {
+	return target == inst.id || target == inst.plugin
+}
+
+// named reports errors under the instance id rather than the plugin name.
+type named struct {
+	notifier.Notifier
+	id string
+}
+
+func (n named) Name() string { return n.id }
+
+// Clear forwards to the plugin when it can clear notifications.
+func (n named) Clear(ctx context.Context, notif notifier.Notification) error {
+	if clearer, ok := n.Notifier.(notifier.Clearer); ok {
+		return clearer.Clear(ctx, notif)
+	}
+
+	return nil
+}
+
+// selectionFlags are the global flags that limit which instances run.
+func selectionFlags() []ucli.Flag {
+	return []ucli.Flag{
+		&ucli.StringSliceFlag{
+			Name:  "only",
+			Usage: "Only use these notifier ids or plugin names",
+		},
+		&ucli.StringSliceFlag{
+			Name:  "except",
+			Usage: "Skip these notifier ids or plugin names",
+		},
+	}
+}
+
+// selectInstances drops disabled instances and applies --only and --except.
+func selectInstances(cmd *ucli.Context, instances []instance) ([]instance, error) {
+	only, except := cmd.StringSlice("only"), cmd.StringSlice("except")
+	for _, target := range slices.Concat(only, except) {
+		if !slices.ContainsFunc(instances, func(inst instance) bool { return inst.targetedBy(target) }) {
+			return nil, fmt.Errorf("no notifier with id or plugin %q", target)
+		}
+	}
+
+	var selected []instance
+	for _, inst := range instances {
+		switch {
+		case inst.disabled:
+			slog.Debug("notifier disabled", "id", inst.id)
+		case len(only) > 0 && !slices.ContainsFunc(only, inst.targetedBy):
+			slog.Debug("notifier not in --only", "id", inst.id)
+		case slices.ContainsFunc(except, inst.targetedBy):
+			slog.Debug("notifier in --except", "id", inst.id)
+		default:
+			selected = append(selected, inst)
+		}
+	}
+
+	return selected, nil
+}
+
+// allNotifiers returns every instance's notifier, ignoring filters.
+func allNotifiers(instances []instance) []notifier.Notifier {
+	notifiers := make([]notifier.Notifier, 0, len(instances))
+	for _, inst := range instances {
+		notifiers = append(notifiers, named{Notifier: inst.notifier, id: inst.id})
+	}
+
+	return notifiers
+}
+
+// matchingInstances returns the instances whose filters pass notif and
+// whose schedules allow sending it at now.
+func matchingInstances(instances []instance, notif notifier.Notification, now time.Time) []instance {
+	var matching []instance
+	for _, inst := range instances {
+		if !inst.filter.Match(notif) {
+			slog.Debug("notifier filtered out", "id", inst.id)
+
+			continue
+		}
+		if ok, reason := inst.schedule.Allows(notif, now); !ok {
+			slog.Debug("notifier outside schedule", "id", inst.id, "reason", reason)
+
+			continue
+		}
+		matching = append(matching, inst)
+	}
+
+	return matching
+}
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/config"
+	"github.com/felipeelias/claude-notifier/internal/dispatch"
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/state"
+)
+
+// limiter applies [global] dedup_window and each instance's max_per_minute.
+// Notifications are recorded per instance when they are let through, so
+// concurrent hooks see each other, and refunded for instances whose send
+// failed, so a retry isn't suppressed. Records are keyed by instance id, so
+// a block without an explicit id takes over another block's counts when
+// blocks of its plugin are added or reordered.
+type limiter struct {
+	path   string
+	window time.Duration
+	key    string
+	now    time.Time
+}
+
+func newLimiter(cfg *config.Config, notif notifier.Notification, now time.Time) limiter {
+	return limiter{
+		path:   filepath.Join(state.Dir(), "state.json"),
+		window: cfg.Global.DedupWindow,
+		key:    dedupKey(notif),
+		now:    now,
+	}
+}
+
+// active reports whether any limit applies to instances; the state file is
+// only touched when one does.
+func (l limiter) active(instances []instance) bool {
+	return len(instances) > 0 && (l.window > 0 ||
+		slices.ContainsFunc(instances, func(inst instance) bool { return inst.maxPerMinute > 0 }))
+}
+
+// allow returns the instances notif may still be sent to. Errors reading or
+// writing the state file let the notification through.
+func (l limiter) allow(instances []instance) []instance {
+	if !l.active(instances) {
+		return instances
+	}
+
+	var allowed []instance
+	err := state.Update(l.path, l.now, func(s *state.State) {
+		for _, inst := range instances {
+			key := l.key + "/" + inst.id
+			if l.window > 0 && s.Duplicate(key, l.now, l.window) {
+				slog.Debug("duplicate notification, not sending", "id", inst.id, "window", l.window)
+
+				continue
+			}
+			if inst.maxPerMinute > 0 && !s.Take(inst.id, inst.maxPerMinute, l.now) {
+				slog.Debug("notifier rate limited", "id", inst.id, "max_per_minute", inst.maxPerMinute)
+				s.Forget(key)
+
+				continue
+			}
+			allowed = append(allowed, inst)
+		}
+	})
+	if err != nil {
+		slog.Warn("skipping de-duplication and rate limits", "error", err)
+
+		return instances
+	}
+
+	return allowed
+}
+
+// refund gives back what allow recorded for instances whose send failed.
+func (l limiter) refund(failed []instance) {
+	if !l.active(failed) {
+		return
+	}
+
+	err := state.Update(l.path, l.now, func(s *state.State) {
+		for _, inst := range failed {
+			s.Forget(l.key + "/" + inst.id)
+			if inst.maxPerMinute > 0 {
+				s.Refund(inst.id, inst.maxPerMinute, l.now)
+			}
+		}
+	})
+	if err != nil {
+		slog.Warn("refunding de-duplication and rate limits", "error", err)
+	}
+}
+
+// dedupKey identifies identical notifications: same session, type and
+// message.
+func dedupKey(notif notifier.Notification) string {
+	sum := sha256.Sum256([]byte(notif.SessionID + "\x00" + notif.NotificationType + "\x00" + notif.Message))
+
+	return hex.EncodeToString(sum[:16])
+}
+
+// tracked records whether its notifier's send failed.
+type tracked struct {
+	named
+	failed atomic.Bool
+}
+
+func (t *tracked) Send(ctx context.Context, notif notifier.Notification) error {
+	err := t.named.Send(ctx, notif)
+	if err != nil {
+		t.failed.Store(true)
+	}
+
+	return err
+}
+
+// send dispatches notif to instances, returning the instances that failed
+// along with their errors.
+func send(ctx context.Context, instances []instance, notif notifier.Notification) ([]instance, []error) {
+	dests := make([]notifier.Notifier, 0, len(instances))
+	trackers := make([]*tracked, 0, len(instances))
+	for _, inst := range instances {
+		t := &tracked{named: named{Notifier: inst.notifier, id: inst.id}}
+		dests = append(dests, t)
+		trackers = append(trackers, t)
+	}
+
+	errs := dispatch.Send(ctx, dests, notif)
+
+	var failed []instance
+	for i, t := range trackers {
+		if t.failed.Load() {
+			failed = append(failed, instances[i])
+		}
+	}
+
+	return failed, errs
+}
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/felipeelias/claude-notifier/internal/config"
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/route"
+	ucli "github.com/urfave/cli/v2"
+)
+
+// newRouter builds the router from the [[routes]] rules, or returns nil when
+// none are configured and every instance receives every notification.
+func newRouter(cfg *config.Config, instances []instance) (*route.Router, error) {
+	if len(cfg.Routes) == 0 && len(cfg.Global.DefaultRoute) == 0 {
+		return nil, nil
+	}
+	router, err := route.New(cfg.Routes, cfg.Global.DefaultRoute)
+	if err != nil {
+		return nil, err
+	}
+	for _, target := range router.Targets() {
+		if !slices.ContainsFunc(instances, func(inst instance) bool { return inst.targetedBy(target) }) {
+			return nil, fmt.Errorf("route target %q matches no notifier id or plugin", target)
+		}
+	}
+
+	return router, nil
+}
+
+// routed returns the instances named by targets.
+func routed(instances []instance, targets []string) []instance {
+	var selected []instance
+	for _, inst := range instances {
+		if slices.ContainsFunc(targets, inst.targetedBy) {
+			selected = append(selected, inst)
+		}
+	}
+
+	return selected
+}
+
+func routeCommand(reg *notifier.Registry) *ucli.Command {
+	return &ucli.Command{
+		Name:  "route",
+		Usage: "Show which notifiers a notification read from stdin would go to",
+		Flags: []ucli.Flag{
+			&ucli.BoolFlag{
+				Name:  "explain",
+				Usage: "Show how each route rule was evaluated",
+			},
+		},
+		Action: func(cmd *ucli.Context) error {
+			const maxInputSize = 1 << 20 // 1 MiB
+			var notif notifier.Notification
+			err := json.NewDecoder(io.LimitReader(os.Stdin, maxInputSize)).Decode(&notif)
+			if err != nil {
+				return fmt.Errorf("reading notification from stdin: %w", err)
+			}
+
+			configPath := cmd.String("config")
+			instances, cfg, err := loadNotifiers(configPath, reg)
+			if err != nil {
+				return fmt.Errorf("loading config: %w", err)
+			}
+			notif.Severity = cfg.Severity(notif.NotificationType)
+			router, err := newRouter(cfg, instances)
+			if err != nil {
+				return fmt.Errorf("loading config: %w", err)
+			}
+			instances, err = selectInstances(cmd, instances)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.App.Writer
+			env := route.CurrentEnv()
+			if ok, reason := cfg.Global.Allows(notif, env.Now); !ok {
+				if cmd.Bool("explain") {
+					_, _ = fmt.Fprintf(out, "global schedule: %s\n", reason)
+				}
+				_, _ = fmt.Fprintln(out, "notifiers: none")
+
+				return nil
+			}
+
+			if router == nil {
+				if cmd.Bool("explain") {
+					_, _ = fmt.Fprintln(out, "no routes configured")
+				}
+			} else {
+				res := router.Route(notif, env)
+				if cmd.Bool("explain") {
+					explain(out, res)
+				}
+				instances = routed(instances, res.Targets)
+			}
+
+			var names []string
+			for _, inst := range instances {
+				if !inst.filter.Match(notif) {
+					if cmd.Bool("explain") {
+						_, _ = fmt.Fprintf(out, "%s: filtered out\n", inst.id)
+					}
+
+					continue
+				}
+				if ok, reason := inst.schedule.Allows(notif, env.Now); !ok {
+					if cmd.Bool("explain") {
+						_, _ = fmt.Fprintf(out, "%s: outside schedule (%s)\n", inst.id, reason)
+					}
+
+					continue
+				}
+				names = append(names, inst.id)
+			}
+			if len(names) == 0 {
+				_, _ = fmt.Fprintln(out, "notifiers: none")
+
+				return nil
+			}
+			_, _ = fmt.Fprintln(out, "notifiers: "+strings.Join(names, ", "))
+
+			return nil
+		},
+	}
+}
+
+func explain(w io.Writer, res route.Result) {
+	for _, step := range res.Steps {
+		switch {
+		case !step.Evaluated:
+			_, _ = fmt.Fprintf(w, "route %s: not evaluated\n", step.Rule)
+		case step.Matched:
+			_, _ = fmt.Fprintf(w, "route %s: matched\n", step.Rule)
+		default:
+			_, _ = fmt.Fprintf(w, "route %s: no match (%s)\n", step.Rule, step.Reason)
+		}
+	}
+	if res.Default {
+		_, _ = fmt.Fprintln(w, "no route matched, using default_route")
+	}
+	if len(res.Targets) == 0 {
+		_, _ = fmt.Fprintln(w, "route targets: none")
+
+		return
+	}
+	_, _ = fmt.Fprintln(w, "route targets: "+strings.Join(res.Targets, ", "))
+}
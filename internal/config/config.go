@@ -3,12 +3,17 @@ package config
 import (
 	"fmt"
 	"os"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/felipeelias/claude-notifier/internal/fsutil"
 	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/route"
+	"github.com/felipeelias/claude-notifier/internal/schedule"
 )
 
 const defaultTimeout = 10 * time.Second
@@ -16,11 +21,32 @@ const defaultTimeout = 10 * time.Second
 // Global holds top-level configuration.
 type Global struct {
 	Timeout time.Duration `toml:"timeout"`
+	// DefaultRoute lists the notifier IDs that receive notifications no
+	// route matches.
+	DefaultRoute []string `toml:"default_route"`
+	// Severity maps notification types to severity levels, on top of
+	// defaultSeverities; the "default" key covers unlisted types.
+	Severity map[string]string `toml:"severity"`
+	// DedupWindow suppresses a notification identical to one sent less
+	// than this long ago; 0 disables it.
+	DedupWindow time.Duration `toml:"dedup_window"`
+	// Schedule sets quiet hours for all notifiers, with the same keys as a
+	// notifier block's schedule.
+	schedule.Schedule
+}
+
+// defaultSeverities are the levels of the known notification types.
+var defaultSeverities = map[string]string{
+	"permission_prompt":  notifier.SeverityHigh,
+	"elicitation_dialog": notifier.SeverityHigh,
+	"idle_prompt":        notifier.SeverityNormal,
+	"auth_success":       notifier.SeverityLow,
 }
 
 // Config is the top-level configuration file structure.
 type Config struct {
 	Global    Global                      `toml:"global"`
+	Routes    []route.Rule                `toml:"routes"`
 	Notifiers map[string][]toml.Primitive `toml:"notifiers"`
 	meta      toml.MetaData
 }
@@ -45,9 +71,39 @@ func Load(path string) (*Config, error) {
 	}
 	cfg.meta = meta
 
+	err = cfg.Global.Schedule.Compile()
+	if err != nil {
+		return nil, fmt.Errorf("global: %w", err)
+	}
+
+	if cfg.Global.DedupWindow < 0 {
+		return nil, fmt.Errorf("global.dedup_window: %s is negative", cfg.Global.DedupWindow)
+	}
+
+	for notifType, severity := range cfg.Global.Severity {
+		if !notifier.ValidSeverity(severity) {
+			return nil, fmt.Errorf("global.severity: %s: unknown severity %q (want low, normal, high or critical)", notifType, severity)
+		}
+	}
+
 	return cfg, nil
 }
 
+// Severity returns the severity level of a notification type.
+func (c *Config) Severity(notifType string) string {
+	if severity, ok := c.Global.Severity[notifType]; ok {
+		return severity
+	}
+	if severity, ok := defaultSeverities[notifType]; ok {
+		return severity
+	}
+	if severity, ok := c.Global.Severity["default"]; ok {
+		return severity
+	}
+
+	return notifier.SeverityNormal
+}
+
 // Decode unmarshals a plugin's TOML primitive into the given struct.
 func (c *Config) Decode(p toml.Primitive, v any) error {
 	return c.meta.PrimitiveDecode(p, v)
@@ -68,11 +124,92 @@ type Configurable interface {
 	SampleConfig() string
 }
 
+// scheduleSample documents the schedule keys of [global], which notifier
+// blocks accept too.
+const scheduleSample = `## Quiet hours: only send during active_hours on the listed weekdays
+## Windows may wrap past midnight ("22:00-02:00" belongs to the day it
+## starts); weekdays are days or ranges such as "mon-fri"
+## bypass_on lists notification types or severity levels sent regardless
+## Every [[notifiers.*]] block accepts the same keys for its own schedule
+# active_hours = ["09:00-18:00"]
+# weekdays = ["mon-fri"]
+# timezone = "Europe/Berlin"
+# bypass_on = ["permission_prompt", "critical"]
+
+`
+
+// dedupSample documents [global] dedup_window.
+const dedupSample = `## Skip a notification identical to one sent within this window (same
+## session, type and message), e.g. repeated idle prompts; 0 disables
+# dedup_window = "5m"
+
+`
+
+// severitySample documents the [global.severity] map.
+const severitySample = `## Severity of each notification type: low, normal, high or critical
+## Available in templates as {{.Severity}}; plugins can map it to their own
+## priorities and sounds (e.g. ntfy severity_priority)
+## "default" applies to types not listed here or built in
+# [global.severity]
+# permission_prompt = "high"
+# elicitation_dialog = "high"
+# idle_prompt = "normal"
+# auth_success = "low"
+# default = "normal"
+
+`
+
+// filterSample documents the keys shared by all notifier blocks.
+const filterSample = `## Every [[notifiers.*]] block also accepts:
+##   id = "phone"       name used in routes, logs, errors and --only/--except
+##                      (default: the plugin name, "ntfy#2" for later blocks)
+##   enabled = false    keep the block but don't send to it
+##   max_per_minute = 5 send at most this many notifications a minute
+##                      (0: no limit); counts are kept per id, so give
+##                      the block an id if the plugin has several blocks
+## and filter keys; all keys that are set must match for the notification to
+## be sent to that block:
+##   on = ["permission_prompt"]            notification types
+##   projects = ["api-*"]                  project name globs
+##   exclude_projects = ["scratch"]        project name globs to skip
+##   cwd_match = "^/home/me/work/"         regular expression on the working directory
+##   message_match = "(?i)permission"      regular expression on the message
+
+`
+
+// routesSample documents the [[routes]] rules.
+const routesSample = `## Routes send each notification to a subset of notifiers, named by their
+## "id" key (or plugin name, for all of its instances). Rules are checked in
+## order; the first match wins unless it sets continue = true. When no rule
+## matches, [global] default_route is used; without it nothing is sent.
+## Without any routes, every notifier receives every notification.
+## Conditions under [routes.match] that are set must all match:
+##   types = ["permission_prompt"]         notification types
+##   projects = ["api-*"]                  project name globs
+##   hostnames = ["work-*"]                hostname globs
+##   time = "09:00-18:00"                  local time window
+##   ssh = true                            in an SSH session (false: not in one)
+##   env = { TERM_PROGRAM = "iTerm*" }     environment variable globs
+## Check a payload with: claude-notifier route --explain < payload.json
+# [[routes]]
+# name = "permission prompts to the phone"
+# notifiers = ["phone"]
+# continue = false
+# [routes.match]
+# types = ["permission_prompt"]
+
+`
+
 // SampleConfig generates a sample config from all registered plugins.
 func SampleConfig(reg *notifier.Registry) string {
 	var buf strings.Builder
 	buf.WriteString("# claude-notifier configuration\n\n")
 	buf.WriteString("[global]\ntimeout = \"10s\"\n\n")
+	buf.WriteString(scheduleSample)
+	buf.WriteString(dedupSample)
+	buf.WriteString(severitySample)
+	buf.WriteString(filterSample)
+	buf.WriteString(routesSample)
 
 	all := reg.All()
 	names := make([]string, 0, len(all))
@@ -93,3 +230,100 @@ func SampleConfig(reg *notifier.Registry) string {
 
 	return buf.String()
 }
+
+// SetNotifierValues writes string values into the first [[notifiers.<name>]]
+// block of the config file at path, replacing existing (or commented-out)
+// assignments of the same keys. The block is appended if it does not exist.
+// Other content, including comments, is left untouched.
+func SetNotifierValues(path, name string, values map[string]string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	header := "[[notifiers." + name + "]]"
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+
+	start := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) == header {
+			start = i
+
+			break
+		}
+	}
+	if start < 0 {
+		lines = append(lines, "", header)
+		start = len(lines) - 1
+	}
+
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), "[") {
+			end = i
+
+			break
+		}
+	}
+
+	var missing []string
+	for _, key := range keys {
+		assignment := key + " = " + strconv.Quote(values[key])
+		if !replaceAssignment(lines[start+1:end], key, assignment) {
+			missing = append(missing, assignment)
+		}
+	}
+	lines = append(lines[:start+1], append(missing, lines[start+1:]...)...)
+
+	err = fsutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), info.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+
+	return nil
+}
+
+// replaceAssignment sets key in a block's lines. The first live assignment
+// is replaced and any later ones commented out, so the key stays unique;
+// without one, the first commented-out assignment is uncommented. It
+// reports false when the block has neither.
+func replaceAssignment(lines []string, key, assignment string) bool {
+	live := regexp.MustCompile(`^\s*` + regexp.QuoteMeta(key) + `\s*=`)
+	commented := regexp.MustCompile(`^\s*#\s*` + regexp.QuoteMeta(key) + `\s*=`)
+
+	found := false
+	for i, line := range lines {
+		if !live.MatchString(line) {
+			continue
+		}
+		if found {
+			lines[i] = "# " + line
+		} else {
+			lines[i] = assignment
+			found = true
+		}
+	}
+	if found {
+		return true
+	}
+
+	for i, line := range lines {
+		if commented.MatchString(line) {
+			lines[i] = assignment
+
+			return true
+		}
+	}
+
+	return false
+}
@@ -55,3 +55,189 @@ func TestLoadConfigMissing(t *testing.T) {
 	_, err := config.Load("/nonexistent/config.toml")
 	assert.Error(t, err)
 }
+
+func TestSetNotifierValuesReplacesExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`[global]
+timeout = "5s"
+
+[[notifiers.webpush]]
+## Public key
+# public_key = ""
+private_key = "old"
+
+[[notifiers.ntfy]]
+# public_key = "untouched"
+`), 0600))
+
+	err := config.SetNotifierValues(path, "webpush", map[string]string{
+		"public_key":  "pub",
+		"private_key": "priv",
+	})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `[global]
+timeout = "5s"
+
+[[notifiers.webpush]]
+## Public key
+public_key = "pub"
+private_key = "priv"
+
+[[notifiers.ntfy]]
+# public_key = "untouched"
+`, string(content))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestSetNotifierValuesAppendsBlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte("[global]\ntimeout = \"5s\"\n"), 0600))
+
+	err := config.SetNotifierValues(path, "webpush", map[string]string{"b": "2", "a": "1"})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "[global]\ntimeout = \"5s\"\n\n[[notifiers.webpush]]\na = \"1\"\nb = \"2\"\n", string(content))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	assert.Len(t, cfg.Notifiers["webpush"], 1)
+}
+
+func TestSetNotifierValuesMissingFile(t *testing.T) {
+	err := config.SetNotifierValues("/nonexistent/config.toml", "webpush", map[string]string{"a": "1"})
+	assert.Error(t, err)
+}
+
+func TestSeverity(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+[global.severity]
+idle_prompt = "low"
+default = "high"
+`), 0644))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "low", cfg.Severity("idle_prompt"))
+	assert.Equal(t, "high", cfg.Severity("permission_prompt"))
+	assert.Equal(t, "low", cfg.Severity("auth_success"))
+	assert.Equal(t, "high", cfg.Severity("something_new"))
+}
+
+func TestSeverityDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, nil, 0644))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "high", cfg.Severity("permission_prompt"))
+	assert.Equal(t, "high", cfg.Severity("elicitation_dialog"))
+	assert.Equal(t, "normal", cfg.Severity("idle_prompt"))
+	assert.Equal(t, "low", cfg.Severity("auth_success"))
+	assert.Equal(t, "normal", cfg.Severity(""))
+}
+
+func TestSeverityInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+[global.severity]
+idle_prompt = "urgent"
+`), 0644))
+
+	_, err := config.Load(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `idle_prompt: unknown severity "urgent"`)
+}
+
+func TestGlobalSchedule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+[global]
+active_hours = ["08:00-22:00"]
+weekdays = ["mon-fri"]
+timezone = "Europe/Berlin"
+bypass_on = ["permission_prompt"]
+`), 0644))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"08:00-22:00"}, cfg.Global.ActiveHours)
+	assert.Equal(t, []string{"mon-fri"}, cfg.Global.Weekdays)
+	assert.Equal(t, "Europe/Berlin", cfg.Global.Timezone)
+	assert.Equal(t, []string{"permission_prompt"}, cfg.Global.BypassOn)
+}
+
+func TestGlobalScheduleInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+[global]
+timezone = "Mars/Olympus"
+`), 0644))
+
+	_, err := config.Load(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "global: timezone")
+}
+
+func TestDedupWindow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+[global]
+dedup_window = "5m"
+`), 0644))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, cfg.Global.DedupWindow)
+}
+
+func TestDedupWindowNegative(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+[global]
+dedup_window = "-1m"
+`), 0644))
+
+	_, err := config.Load(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "global.dedup_window")
+}
+
+func TestSetNotifierValuesPrefersLiveAssignment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`[[notifiers.webpush]]
+# private_key = "example"
+private_key = "old"
+private_key = "older"
+`), 0600))
+
+	err := config.SetNotifierValues(path, "webpush", map[string]string{"private_key": "new"})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `[[notifiers.webpush]]
+# private_key = "example"
+private_key = "new"
+# private_key = "older"
+`, string(content))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err, "the result has a single assignment and parses")
+	assert.Len(t, cfg.Notifiers["webpush"], 1)
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "the file is replaced, not written beside")
+}
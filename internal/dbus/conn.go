@@ -0,0 +1,259 @@
+// Package dbus is a minimal D-Bus client: enough to authenticate to the
+// session bus and make method calls, without pulling in a dependency.
+package dbus
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const dialTimeout = 5 * time.Second
+
+// Error is a D-Bus error reply.
+type Error struct {
+	Name    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return e.Name
+	}
+
+	return e.Name + ": " + e.Message
+}
+
+// Conn is a connection to a message bus.
+type Conn struct {
+	conn net.Conn
+	r    *bufio.Reader
+
+	mu     sync.Mutex
+	serial uint32
+}
+
+// SessionBusAddress returns the session bus address from
+// DBUS_SESSION_BUS_ADDRESS, falling back to $XDG_RUNTIME_DIR/bus.
+func SessionBusAddress() (string, error) {
+	if addr := os.Getenv("DBUS_SESSION_BUS_ADDRESS"); addr != "" {
+		return addr, nil
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		path := dir + "/bus"
+		if _, err := os.Stat(path); err == nil {
+			return "unix:path=" + path, nil
+		}
+	}
+
+	return "", errors.New("no session bus: DBUS_SESSION_BUS_ADDRESS is not set")
+}
+
+// SessionBus connects to the user's session bus.
+func SessionBus(ctx context.Context) (*Conn, error) {
+	addr, err := SessionBusAddress()
+	if err != nil {
+		return nil, err
+	}
+
+	return Dial(ctx, addr)
+}
+
+// Dial connects to the bus at address, authenticates with EXTERNAL and
+// registers with Hello. Only unix: addresses are supported; the first usable
+// entry of a semicolon-separated list is used.
+func Dial(ctx context.Context, address string) (*Conn, error) {
+	var lastErr error
+	for _, entry := range strings.Split(address, ";") {
+		socket, err := parseUnixAddress(entry)
+		if err != nil {
+			lastErr = err
+
+			continue
+		}
+
+		dialer := &net.Dialer{Timeout: dialTimeout}
+		nc, err := dialer.DialContext(ctx, "unix", socket)
+		if err != nil {
+			lastErr = fmt.Errorf("connecting to %s: %w", socket, err)
+
+			continue
+		}
+
+		c := &Conn{conn: nc, r: bufio.NewReader(nc)}
+		err = c.handshake(ctx)
+		if err != nil {
+			_ = nc.Close()
+
+			return nil, err
+		}
+
+		return c, nil
+	}
+	if lastErr == nil {
+		lastErr = errors.New("empty bus address")
+	}
+
+	return nil, lastErr
+}
+
+func parseUnixAddress(entry string) (string, error) {
+	transport, params, ok := strings.Cut(entry, ":")
+	if !ok || transport != "unix" {
+		return "", fmt.Errorf("unsupported bus address %q", entry)
+	}
+	for _, kv := range strings.Split(params, ",") {
+		key, value, _ := strings.Cut(kv, "=")
+		value = unescapeAddress(value)
+		switch key {
+		case "path":
+			return value, nil
+		case "abstract":
+			return "@" + value, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported bus address %q", entry)
+}
+
+// unescapeAddress decodes %xx escapes in an address value.
+func unescapeAddress(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 2
+
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
+
+func (c *Conn) handshake(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, func() { _ = c.conn.Close() })
+	defer stop()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = c.conn.SetDeadline(deadline)
+	}
+
+	uid := hex.EncodeToString([]byte(strconv.Itoa(os.Getuid())))
+	_, err := c.conn.Write([]byte("\x00AUTH EXTERNAL " + uid + "\r\n"))
+	if err != nil {
+		return fmt.Errorf("authenticating: %w", err)
+	}
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("authenticating: %w", err)
+	}
+	if !strings.HasPrefix(line, "OK ") {
+		return fmt.Errorf("authentication rejected: %s", strings.TrimSpace(line))
+	}
+	_, err = c.conn.Write([]byte("BEGIN\r\n"))
+	if err != nil {
+		return fmt.Errorf("authenticating: %w", err)
+	}
+
+	_, err = c.Call(ctx, "org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "Hello")
+	if err != nil {
+		return fmt.Errorf("registering with bus: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// Call invokes a method and waits for its reply, returning the reply body.
+// Signals and unrelated replies received in the meantime are discarded.
+func (c *Conn) Call(ctx context.Context, dest string, path ObjectPath, iface, method string, args ...any) ([]any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stop := context.AfterFunc(ctx, func() { _ = c.conn.SetDeadline(time.Now()) })
+	defer stop()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = c.conn.SetDeadline(deadline)
+	} else {
+		_ = c.conn.SetDeadline(time.Time{})
+	}
+
+	c.serial++
+	msg := &Message{
+		Type:        TypeMethodCall,
+		Serial:      c.serial,
+		Path:        path,
+		Interface:   iface,
+		Member:      method,
+		Destination: dest,
+		Body:        args,
+	}
+	data, err := msg.Encode()
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s.%s: %w", iface, method, err)
+	}
+
+	_, err = c.conn.Write(data)
+	if err != nil {
+		return nil, c.wrap(ctx, fmt.Errorf("calling %s.%s: %w", iface, method, err))
+	}
+
+	for {
+		reply, err := ReadMessage(c.r)
+		if err != nil {
+			return nil, c.wrap(ctx, fmt.Errorf("reading reply to %s.%s: %w", iface, method, err))
+		}
+		if reply.ReplySerial != msg.Serial {
+			continue
+		}
+		switch reply.Type {
+		case TypeMethodReturn:
+			return reply.Body, nil
+		case TypeError:
+			e := &Error{Name: reply.ErrorName}
+			if len(reply.Body) > 0 {
+				e.Message, _ = reply.Body[0].(string)
+			}
+
+			return nil, e
+		}
+	}
+}
+
+func (c *Conn) wrap(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("%w: %w", ctx.Err(), err)
+	}
+
+	return err
+}
+
+// NameHasOwner reports whether a bus name currently has an owner.
+func (c *Conn) NameHasOwner(ctx context.Context, name string) (bool, error) {
+	body, err := c.Call(ctx, "org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "NameHasOwner", name)
+	if err != nil {
+		return false, err
+	}
+	if len(body) == 0 {
+		return false, errors.New("empty NameHasOwner reply")
+	}
+	var owned bool
+	err = Decode(body[0], &owned)
+
+	return owned, err
+}
@@ -0,0 +1,153 @@
+package dbus_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/felipeelias/claude-notifier/internal/dbus"
+	"github.com/felipeelias/claude-notifier/internal/dbus/dbustest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	msg := &dbus.Message{
+		Type:        dbus.TypeMethodCall,
+		Serial:      7,
+		Path:        "/org/example/Object",
+		Interface:   "org.example.Iface",
+		Member:      "Method",
+		Destination: "org.example",
+		Body: []any{
+			"hello",
+			true,
+			uint32(42),
+			int32(-3),
+			byte(9),
+			int64(-1 << 40),
+			float64(1.5),
+			dbus.ObjectPath("/a/b"),
+			[]string{"x", "yy", ""},
+			map[string]string{"b": "2", "a": "1"},
+			map[string]dbus.Variant{"urgency": {Value: byte(2)}, "label": {Value: "hi"}},
+			[]byte{1, 2, 3},
+			[]any{"s", uint32(1)},
+			[]string{},
+		},
+	}
+	data, err := msg.Encode()
+	require.NoError(t, err)
+
+	got, err := dbus.ReadMessage(bufio.NewReader(bytes.NewReader(data)))
+	require.NoError(t, err)
+	assert.Equal(t, msg.Type, got.Type)
+	assert.Equal(t, msg.Serial, got.Serial)
+	assert.Equal(t, msg.Path, got.Path)
+	assert.Equal(t, msg.Interface, got.Interface)
+	assert.Equal(t, msg.Member, got.Member)
+	assert.Equal(t, msg.Destination, got.Destination)
+	require.Len(t, got.Body, len(msg.Body))
+
+	assert.Equal(t, "hello", got.Body[0])
+	assert.Equal(t, true, got.Body[1])
+	assert.Equal(t, uint32(42), got.Body[2])
+	assert.Equal(t, int32(-3), got.Body[3])
+	assert.Equal(t, byte(9), got.Body[4])
+	assert.Equal(t, int64(-1<<40), got.Body[5])
+	assert.InDelta(t, 1.5, got.Body[6], 0)
+	assert.Equal(t, dbus.ObjectPath("/a/b"), got.Body[7])
+
+	var strs []string
+	require.NoError(t, dbus.Decode(got.Body[8], &strs))
+	assert.Equal(t, []string{"x", "yy", ""}, strs)
+
+	var dict map[string]string
+	require.NoError(t, dbus.Decode(got.Body[9], &dict))
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, dict)
+
+	variants := got.Body[10].(map[any]any)
+	assert.Equal(t, dbus.Variant{Value: byte(2)}, variants["urgency"])
+	assert.Equal(t, dbus.Variant{Value: "hi"}, variants["label"])
+	assert.Equal(t, []any{byte(1), byte(2), byte(3)}, got.Body[11])
+	assert.Equal(t, []any{"s", uint32(1)}, got.Body[12])
+	assert.Equal(t, []any{}, got.Body[13])
+}
+
+func TestReadMessageTruncated(t *testing.T) {
+	msg := &dbus.Message{Type: dbus.TypeSignal, Serial: 1, Path: "/", Interface: "a.b", Member: "C", Body: []any{"x"}}
+	data, err := msg.Encode()
+	require.NoError(t, err)
+
+	_, err = dbus.ReadMessage(bufio.NewReader(bytes.NewReader(data[:len(data)-2])))
+	require.Error(t, err)
+}
+
+func TestCall(t *testing.T) {
+	srv := dbustest.NewServer(t, func(call *dbus.Message) ([]any, *dbus.Error) {
+		switch call.Member {
+		case "Echo":
+			return call.Body, nil
+		case "NameHasOwner":
+			return []any{call.Body[0] == "org.example"}, nil
+		}
+
+		return nil, &dbus.Error{Name: "org.freedesktop.DBus.Error.UnknownMethod", Message: "no such method"}
+	})
+
+	conn, err := dbus.Dial(context.Background(), srv.Address)
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	body, err := conn.Call(context.Background(), "org.example", "/obj", "org.example.Iface", "Echo", "hi", true)
+	require.NoError(t, err)
+	assert.Equal(t, []any{"hi", true}, body)
+
+	owned, err := conn.NameHasOwner(context.Background(), "org.example")
+	require.NoError(t, err)
+	assert.True(t, owned)
+
+	_, err = conn.Call(context.Background(), "org.example", "/obj", "org.example.Iface", "Missing")
+	var dbusErr *dbus.Error
+	require.ErrorAs(t, err, &dbusErr)
+	assert.Equal(t, "org.freedesktop.DBus.Error.UnknownMethod", dbusErr.Name)
+	assert.Equal(t, "no such method", dbusErr.Message)
+
+	calls := srv.Calls()
+	require.Len(t, calls, 3)
+	assert.Equal(t, dbus.ObjectPath("/obj"), calls[0].Path)
+	assert.Equal(t, "org.example", calls[0].Destination)
+}
+
+func TestSessionBusAddress(t *testing.T) {
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "unix:path=/tmp/bus")
+	addr, err := dbus.SessionBusAddress()
+	require.NoError(t, err)
+	assert.Equal(t, "unix:path=/tmp/bus", addr)
+
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "")
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	_, err = dbus.SessionBusAddress()
+	require.Error(t, err)
+}
+
+func TestDialUnsupportedAddress(t *testing.T) {
+	_, err := dbus.Dial(context.Background(), "tcp:host=localhost,port=1234")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported bus address")
+}
+
+func TestDialFallsThroughAddressList(t *testing.T) {
+	srv := dbustest.NewServer(t, func(*dbus.Message) ([]any, *dbus.Error) { return nil, nil })
+
+	conn, err := dbus.Dial(context.Background(), "tcp:host=x;unix:path=/nonexistent/bus;"+srv.Address)
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+}
+
+func TestDialMissingSocket(t *testing.T) {
+	_, err := dbus.Dial(context.Background(), "unix:path=/nonexistent/bus")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "connecting to /nonexistent/bus")
+}
@@ -0,0 +1,129 @@
+// Package dbustest provides a fake session bus for tests.
+package dbustest
+
+import (
+	"bufio"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/felipeelias/claude-notifier/internal/dbus"
+)
+
+// Handler answers a method call with a reply body or a D-Bus error.
+type Handler func(call *dbus.Message) ([]any, *dbus.Error)
+
+// Server is a fake bus that accepts EXTERNAL auth, answers Hello itself and
+// passes every other method call to its handler.
+type Server struct {
+	// Address is suitable for DBUS_SESSION_BUS_ADDRESS.
+	Address string
+
+	mu    sync.Mutex
+	calls []*dbus.Message
+}
+
+// NewServer starts a fake bus listening on a unix socket in a temp dir.
+func NewServer(t *testing.T, handler Handler) *Server {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "bus")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listening on %s: %v", socket, err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	srv := &Server{Address: "unix:path=" + socket}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go srv.serve(conn, handler)
+		}
+	}()
+
+	return srv
+}
+
+// Calls returns the method calls received so far, excluding Hello.
+func (s *Server) Calls() []*dbus.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*dbus.Message(nil), s.calls...)
+}
+
+func (s *Server) serve(conn net.Conn, handler Handler) {
+	defer func() { _ = conn.Close() }()
+	r := bufio.NewReader(conn)
+
+	nul := make([]byte, 1)
+	_, err := r.Read(nul)
+	if err != nil {
+		return
+	}
+	line, err := r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "AUTH EXTERNAL") {
+		_, _ = conn.Write([]byte("REJECTED EXTERNAL\r\n"))
+
+		return
+	}
+	_, _ = conn.Write([]byte("OK 0123456789abcdef0123456789abcdef\r\n"))
+	line, err = r.ReadString('\n')
+	if err != nil || strings.TrimSpace(line) != "BEGIN" {
+		return
+	}
+
+	var serial uint32
+	for {
+		call, err := dbus.ReadMessage(r)
+		if err != nil {
+			return
+		}
+		if call.Type != dbus.TypeMethodCall {
+			continue
+		}
+
+		var body []any
+		var dbusErr *dbus.Error
+		if call.Member == "Hello" && call.Interface == "org.freedesktop.DBus" {
+			body = []any{":1.1"}
+		} else {
+			s.mu.Lock()
+			s.calls = append(s.calls, call)
+			s.mu.Unlock()
+			body, dbusErr = handler(call)
+		}
+
+		serial++
+		reply := &dbus.Message{Type: dbus.TypeMethodReturn, Serial: serial, ReplySerial: call.Serial, Body: body}
+		if dbusErr != nil {
+			reply = &dbus.Message{
+				Type:        dbus.TypeError,
+				Serial:      serial,
+				ReplySerial: call.Serial,
+				ErrorName:   dbusErr.Name,
+				Body:        []any{dbusErr.Message},
+			}
+		}
+
+		// A signal first, as real buses send NameAcquired after Hello.
+		serial++
+		signal := &dbus.Message{Type: dbus.TypeSignal, Serial: serial, Path: "/org/freedesktop/DBus",
+			Interface: "org.freedesktop.DBus", Member: "NameAcquired", Body: []any{":1.1"}}
+		for _, m := range []*dbus.Message{signal, reply} {
+			data, err := m.Encode()
+			if err != nil {
+				return
+			}
+			_, err = conn.Write(data)
+			if err != nil {
+				return
+			}
+		}
+	}
+}
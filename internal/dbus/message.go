@@ -0,0 +1,762 @@
+package dbus
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Message types.
+const (
+	TypeMethodCall   byte = 1
+	TypeMethodReturn byte = 2
+	TypeError        byte = 3
+	TypeSignal       byte = 4
+)
+
+// Header field codes.
+const (
+	fieldPath        byte = 1
+	fieldInterface   byte = 2
+	fieldMember      byte = 3
+	fieldErrorName   byte = 4
+	fieldReplySerial byte = 5
+	fieldDestination byte = 6
+	fieldSender      byte = 7
+	fieldSignature   byte = 8
+)
+
+const (
+	protocolVersion = 1
+	maxMessageSize  = 128 << 20
+	maxDepth        = 32
+)
+
+// ObjectPath is a D-Bus object path ("o").
+type ObjectPath string
+
+// Signature is a D-Bus type signature ("g").
+type Signature string
+
+// Variant is a D-Bus variant ("v"). When encoding, the signature is derived
+// from Value's Go type.
+type Variant struct {
+	Value any
+}
+
+// Message is a single D-Bus message. Body values are decoded to string,
+// bool, byte, integer types, float64, ObjectPath, Signature, Variant, []any
+// (arrays and structs) and map[any]any (dictionaries).
+type Message struct {
+	Type        byte
+	Flags       byte
+	Serial      uint32
+	Path        ObjectPath
+	Interface   string
+	Member      string
+	ErrorName   string
+	ReplySerial uint32
+	Destination string
+	Sender      string
+	Body        []any
+}
+
+// Encode serialises the message in little-endian byte order.
+func (m *Message) Encode() ([]byte, error) {
+	var sig strings.Builder
+	body := &encoder{}
+	for _, v := range m.Body {
+		s, err := signatureOf(v)
+		if err != nil {
+			return nil, err
+		}
+		sig.WriteString(s)
+		err = body.encode(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	type field struct {
+		code byte
+		val  any
+	}
+	var fields []field
+	if m.Path != "" {
+		fields = append(fields, field{fieldPath, m.Path})
+	}
+	if m.Interface != "" {
+		fields = append(fields, field{fieldInterface, m.Interface})
+	}
+	if m.Member != "" {
+		fields = append(fields, field{fieldMember, m.Member})
+	}
+	if m.ErrorName != "" {
+		fields = append(fields, field{fieldErrorName, m.ErrorName})
+	}
+	if m.ReplySerial != 0 {
+		fields = append(fields, field{fieldReplySerial, m.ReplySerial})
+	}
+	if m.Destination != "" {
+		fields = append(fields, field{fieldDestination, m.Destination})
+	}
+	if m.Sender != "" {
+		fields = append(fields, field{fieldSender, m.Sender})
+	}
+	if sig.Len() > 0 {
+		fields = append(fields, field{fieldSignature, Signature(sig.String())})
+	}
+
+	head := &encoder{}
+	head.buf.Write([]byte{'l', m.Type, m.Flags, protocolVersion})
+	head.u32(uint32(len(body.buf.Bytes())))
+	head.u32(m.Serial)
+	head.array(func() error {
+		for _, f := range fields {
+			head.align(8)
+			head.buf.WriteByte(f.code)
+			err := head.encode(Variant{f.val})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	head.align(8)
+	head.buf.Write(body.buf.Bytes())
+
+	return head.buf.Bytes(), nil
+}
+
+// ReadMessage reads one message from r.
+func ReadMessage(r *bufio.Reader) (*Message, error) {
+	fixed := make([]byte, 16)
+	_, err := io.ReadFull(r, fixed)
+	if err != nil {
+		return nil, err
+	}
+
+	var order binary.ByteOrder
+	switch fixed[0] {
+	case 'l':
+		order = binary.LittleEndian
+	case 'B':
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("invalid endianness %q", fixed[0])
+	}
+	bodyLen := order.Uint32(fixed[4:8])
+	fieldsLen := order.Uint32(fixed[12:16])
+	headerLen := 16 + int(fieldsLen)
+	padded := (headerLen + 7) &^ 7
+	total := padded + int(bodyLen)
+	if bodyLen > maxMessageSize || fieldsLen > maxMessageSize || total > maxMessageSize {
+		return nil, errors.New("message too large")
+	}
+
+	data := make([]byte, total)
+	copy(data, fixed)
+	_, err = io.ReadFull(r, data[16:])
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Message{Type: fixed[1], Flags: fixed[2], Serial: order.Uint32(fixed[8:12])}
+
+	head := &decoder{data: data[:headerLen], pos: 12, order: order}
+	fieldsAny, err := head.decode("a(yv)", 0)
+	if err != nil {
+		return nil, fmt.Errorf("decoding header: %w", err)
+	}
+	var bodySig Signature
+	for _, f := range fieldsAny.([]any) {
+		pair := f.([]any)
+		val := pair[1].(Variant).Value
+		switch pair[0].(byte) {
+		case fieldPath:
+			m.Path, _ = val.(ObjectPath)
+		case fieldInterface:
+			m.Interface, _ = val.(string)
+		case fieldMember:
+			m.Member, _ = val.(string)
+		case fieldErrorName:
+			m.ErrorName, _ = val.(string)
+		case fieldReplySerial:
+			m.ReplySerial, _ = val.(uint32)
+		case fieldDestination:
+			m.Destination, _ = val.(string)
+		case fieldSender:
+			m.Sender, _ = val.(string)
+		case fieldSignature:
+			bodySig, _ = val.(Signature)
+		}
+	}
+
+	body := &decoder{data: data[padded:], order: order}
+	for _, typ := range splitSignature(string(bodySig)) {
+		v, err := body.decode(typ, 0)
+		if err != nil {
+			return nil, fmt.Errorf("decoding body: %w", err)
+		}
+		m.Body = append(m.Body, v)
+	}
+
+	return m, nil
+}
+
+// signatureOf returns the D-Bus signature for a supported Go value.
+func signatureOf(v any) (string, error) {
+	switch v := v.(type) {
+	case byte:
+		return "y", nil
+	case bool:
+		return "b", nil
+	case int16:
+		return "n", nil
+	case uint16:
+		return "q", nil
+	case int32:
+		return "i", nil
+	case uint32:
+		return "u", nil
+	case int64:
+		return "x", nil
+	case uint64:
+		return "t", nil
+	case float64:
+		return "d", nil
+	case string:
+		return "s", nil
+	case ObjectPath:
+		return "o", nil
+	case Signature:
+		return "g", nil
+	case Variant:
+		return "v", nil
+	case []byte:
+		return "ay", nil
+	case []string:
+		return "as", nil
+	case map[string]string:
+		return "a{ss}", nil
+	case map[string]Variant:
+		return "a{sv}", nil
+	case []any:
+		// A struct.
+		var b strings.Builder
+		b.WriteByte('(')
+		for _, field := range v {
+			s, err := signatureOf(field)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(s)
+		}
+		b.WriteByte(')')
+
+		return b.String(), nil
+	}
+
+	return "", fmt.Errorf("unsupported type %T", v)
+}
+
+type encoder struct {
+	buf bytes.Buffer
+}
+
+func (e *encoder) align(n int) {
+	for e.buf.Len()%n != 0 {
+		e.buf.WriteByte(0)
+	}
+}
+
+func (e *encoder) u32(v uint32) {
+	e.align(4)
+	_ = binary.Write(&e.buf, binary.LittleEndian, v)
+}
+
+func (e *encoder) fixed(n int, v any) {
+	e.align(n)
+	_ = binary.Write(&e.buf, binary.LittleEndian, v)
+}
+
+func (e *encoder) str(s string) {
+	e.u32(uint32(len(s)))
+	e.buf.WriteString(s)
+	e.buf.WriteByte(0)
+}
+
+// array writes the length prefix, then the elements via fn, and patches the
+// length afterwards. Elements are 8-aligned, which covers every element
+// type this package encodes inside arrays except bytes and strings, for
+// which the padding is a no-op.
+func (e *encoder) array(fn func() error) error {
+	e.u32(0)
+	lenPos := e.buf.Len() - 4
+	e.align(8)
+	start := e.buf.Len()
+	err := fn()
+	if err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(e.buf.Bytes()[lenPos:], uint32(e.buf.Len()-start))
+
+	return nil
+}
+
+func (e *encoder) encode(v any) error {
+	switch v := v.(type) {
+	case byte:
+		e.buf.WriteByte(v)
+	case bool:
+		var b uint32
+		if v {
+			b = 1
+		}
+		e.u32(b)
+	case int16:
+		e.fixed(2, v)
+	case uint16:
+		e.fixed(2, v)
+	case int32:
+		e.fixed(4, v)
+	case uint32:
+		e.u32(v)
+	case int64:
+		e.fixed(8, v)
+	case uint64:
+		e.fixed(8, v)
+	case float64:
+		e.fixed(8, math.Float64bits(v))
+	case string:
+		e.str(v)
+	case ObjectPath:
+		e.str(string(v))
+	case Signature:
+		e.buf.WriteByte(byte(len(v)))
+		e.buf.WriteString(string(v))
+		e.buf.WriteByte(0)
+	case Variant:
+		sig, err := signatureOf(v.Value)
+		if err != nil {
+			return err
+		}
+		err = e.encode(Signature(sig))
+		if err != nil {
+			return err
+		}
+
+		return e.encode(v.Value)
+	case []byte:
+		e.u32(uint32(len(v)))
+		e.buf.Write(v)
+	case []string:
+		e.u32(0)
+		lenPos := e.buf.Len() - 4
+		e.align(4)
+		start := e.buf.Len()
+		for _, s := range v {
+			e.str(s)
+		}
+		binary.LittleEndian.PutUint32(e.buf.Bytes()[lenPos:], uint32(e.buf.Len()-start))
+	case map[string]string:
+		return e.array(func() error {
+			for _, k := range sortedKeys(v) {
+				e.align(8)
+				e.str(k)
+				e.str(v[k])
+			}
+
+			return nil
+		})
+	case map[string]Variant:
+		return e.array(func() error {
+			for _, k := range sortedKeys(v) {
+				e.align(8)
+				e.str(k)
+				err := e.encode(v[k])
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	case []any:
+		e.align(8)
+		for _, field := range v {
+			err := e.encode(field)
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type %T", v)
+	}
+
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+type decoder struct {
+	data  []byte
+	pos   int
+	order binary.ByteOrder
+}
+
+var errShort = errors.New("message truncated")
+
+func (d *decoder) align(n int) error {
+	next := (d.pos + n - 1) / n * n
+	if next > len(d.data) {
+		return errShort
+	}
+	d.pos = next
+
+	return nil
+}
+
+func (d *decoder) take(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.data) {
+		return nil, errShort
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+
+	return b, nil
+}
+
+func (d *decoder) fixed(size int) ([]byte, error) {
+	err := d.align(size)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.take(size)
+}
+
+func (d *decoder) u32() (uint32, error) {
+	b, err := d.fixed(4)
+	if err != nil {
+		return 0, err
+	}
+
+	return d.order.Uint32(b), nil
+}
+
+func (d *decoder) str() (string, error) {
+	n, err := d.u32()
+	if err != nil {
+		return "", err
+	}
+	b, err := d.take(int(n) + 1)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b[:n]), nil
+}
+
+// alignment returns the alignment of the first type in sig.
+func alignment(sig string) int {
+	switch sig[0] {
+	case 'y', 'g', 'v':
+		return 1
+	case 'n', 'q':
+		return 2
+	case 'x', 't', 'd', '(', '{':
+		return 8
+	}
+
+	return 4
+}
+
+func (d *decoder) decode(sig string, depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, errors.New("nesting too deep")
+	}
+	switch sig[0] {
+	case 'y':
+		b, err := d.take(1)
+		if err != nil {
+			return nil, err
+		}
+
+		return b[0], nil
+	case 'b':
+		v, err := d.u32()
+
+		return v != 0, err
+	case 'n':
+		b, err := d.fixed(2)
+		if err != nil {
+			return nil, err
+		}
+
+		return int16(d.order.Uint16(b)), nil
+	case 'q':
+		b, err := d.fixed(2)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.order.Uint16(b), nil
+	case 'i':
+		v, err := d.u32()
+
+		return int32(v), err
+	case 'u', 'h':
+		return d.u32()
+	case 'x', 't', 'd':
+		b, err := d.fixed(8)
+		if err != nil {
+			return nil, err
+		}
+		v := d.order.Uint64(b)
+		switch sig[0] {
+		case 'x':
+			return int64(v), nil
+		case 'd':
+			return math.Float64frombits(v), nil
+		}
+
+		return v, nil
+	case 's':
+		return d.str()
+	case 'o':
+		s, err := d.str()
+
+		return ObjectPath(s), err
+	case 'g':
+		b, err := d.take(1)
+		if err != nil {
+			return nil, err
+		}
+		s, err := d.take(int(b[0]) + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return Signature(s[:b[0]]), nil
+	case 'v':
+		s, err := d.decode("g", depth+1)
+		if err != nil {
+			return nil, err
+		}
+		inner := string(s.(Signature))
+		if len(splitSignature(inner)) != 1 {
+			return nil, fmt.Errorf("invalid variant signature %q", inner)
+		}
+		v, err := d.decode(inner, depth+1)
+
+		return Variant{v}, err
+	case 'a':
+		return d.decodeArray(sig[1:], depth)
+	case '(':
+		err := d.align(8)
+		if err != nil {
+			return nil, err
+		}
+		var fields []any
+		for _, typ := range splitSignature(sig[1 : len(sig)-1]) {
+			v, err := d.decode(typ, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, v)
+		}
+
+		return fields, nil
+	}
+
+	return nil, fmt.Errorf("unsupported signature %q", sig)
+}
+
+func (d *decoder) decodeArray(elem string, depth int) (any, error) {
+	n, err := d.u32()
+	if err != nil {
+		return nil, err
+	}
+	err = d.align(alignment(elem))
+	if err != nil {
+		return nil, err
+	}
+	end := d.pos + int(n)
+	if end > len(d.data) {
+		return nil, errShort
+	}
+
+	if elem[0] == '{' {
+		kv := splitSignature(elem[1 : len(elem)-1])
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid dict signature %q", elem)
+		}
+		out := map[any]any{}
+		for d.pos < end {
+			err = d.align(8)
+			if err != nil {
+				return nil, err
+			}
+			k, err := d.decode(kv[0], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			v, err := d.decode(kv[1], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = v
+		}
+
+		return out, nil
+	}
+
+	out := []any{}
+	for d.pos < end {
+		v, err := d.decode(elem, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+
+	return out, nil
+}
+
+// splitSignature splits a signature into its complete types.
+func splitSignature(sig string) []string {
+	var types []string
+	for len(sig) > 0 {
+		n := typeLen(sig)
+		if n == 0 {
+			return append(types, sig)
+		}
+		types = append(types, sig[:n])
+		sig = sig[n:]
+	}
+
+	return types
+}
+
+func typeLen(sig string) int {
+	switch sig[0] {
+	case 'a':
+		if len(sig) < 2 {
+			return 0
+		}
+		n := typeLen(sig[1:])
+		if n == 0 {
+			return 0
+		}
+
+		return 1 + n
+	case '(', '{':
+		closing := byte(')')
+		if sig[0] == '{' {
+			closing = '}'
+		}
+		depth := 0
+		for i := range len(sig) {
+			switch sig[i] {
+			case '(', '{':
+				depth++
+			case ')', '}':
+				depth--
+				if depth == 0 {
+					if sig[i] != closing {
+						return 0
+					}
+
+					return i + 1
+				}
+			}
+		}
+
+		return 0
+	}
+
+	return 1
+}
+
+// Decode copies a decoded body value into a concrete Go type for the common
+// shapes: string, bool, uint32, []string, map[string]string.
+func Decode(src, dst any) error {
+	switch dst := dst.(type) {
+	case *string:
+		switch v := src.(type) {
+		case string:
+			*dst = v
+		case ObjectPath:
+			*dst = string(v)
+		default:
+			return fmt.Errorf("cannot decode %T into string", src)
+		}
+	case *bool:
+		v, ok := src.(bool)
+		if !ok {
+			return fmt.Errorf("cannot decode %T into bool", src)
+		}
+		*dst = v
+	case *uint32:
+		v, ok := src.(uint32)
+		if !ok {
+			return fmt.Errorf("cannot decode %T into uint32", src)
+		}
+		*dst = v
+	case *[]string:
+		items, ok := src.([]any)
+		if !ok {
+			return fmt.Errorf("cannot decode %T into []string", src)
+		}
+		out := make([]string, 0, len(items))
+		for _, item := range items {
+			var s string
+			err := Decode(item, &s)
+			if err != nil {
+				return err
+			}
+			out = append(out, s)
+		}
+		*dst = out
+	case *map[string]string:
+		items, ok := src.(map[any]any)
+		if !ok {
+			return fmt.Errorf("cannot decode %T into map[string]string", src)
+		}
+		out := make(map[string]string, len(items))
+		for k, v := range items {
+			var ks, vs string
+			err := Decode(k, &ks)
+			if err != nil {
+				return err
+			}
+			err = Decode(v, &vs)
+			if err != nil {
+				return err
+			}
+			out[ks] = vs
+		}
+		*dst = out
+	default:
+		return fmt.Errorf("unsupported destination %s", reflect.TypeOf(dst))
+	}
+
+	return nil
+}
@@ -32,3 +32,32 @@ func Send(ctx context.Context, notifiers []notifier.Notifier, notif notifier.Not
 
 	return errs
 }
+
+// Clear asks every notifier implementing notifier.Clearer to remove the
+// session's notifications, concurrently. Other notifiers are skipped.
+func Clear(ctx context.Context, notifiers []notifier.Notifier, notif notifier.Notification) []error {
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+	)
+
+	for _, dest := range notifiers {
+		clearer, ok := dest.(notifier.Clearer)
+		if !ok {
+			continue
+		}
+		wg.Go(func() {
+			err := clearer.Clear(ctx, notif)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", dest.Name(), err))
+				mu.Unlock()
+			}
+		})
+	}
+
+	wg.Wait()
+
+	return errs
+}
@@ -87,3 +87,32 @@ func TestDispatchRespectsTimeout(t *testing.T) {
 	assert.Len(t, errs, 1)
 	assert.Contains(t, errs[0].Error(), "slow")
 }
+
+type clearingNotifier struct {
+	mockNotifier
+	cleared  []notifier.Notification
+	clearErr error
+}
+
+func (c *clearingNotifier) Clear(_ context.Context, notif notifier.Notification) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cleared = append(c.cleared, notif)
+
+	return c.clearErr
+}
+
+func TestClearOnlyCallsClearers(t *testing.T) {
+	plain := &mockNotifier{name: "plain"}
+	clearer := &clearingNotifier{mockNotifier: mockNotifier{name: "clearer"}}
+	failing := &clearingNotifier{mockNotifier: mockNotifier{name: "failing"}, clearErr: errors.New("fail")}
+
+	notif := notifier.Notification{SessionID: "abc", HookEventName: "UserPromptSubmit"}
+	errs := dispatch.Clear(context.Background(), []notifier.Notifier{plain, clearer, failing}, notif)
+
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "failing")
+	assert.Equal(t, []notifier.Notification{notif}, clearer.cleared)
+	assert.Empty(t, plain.sent)
+	assert.Empty(t, clearer.sent)
+}
@@ -0,0 +1,91 @@
+// Package filter decides which notifier instances receive a notification,
+// from filter keys set on each [[notifiers.*]] block.
+package filter
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+)
+
+// Filter holds the filter keys of one notifier instance. Every key that is
+// set must match; an instance without filter keys receives everything.
+type Filter struct {
+	// On lists the notification types to send, e.g. "permission_prompt".
+	On []string `toml:"on"`
+	// Projects and ExcludeProjects are glob patterns matched against the
+	// project name (the last segment of cwd).
+	Projects        []string `toml:"projects"`
+	ExcludeProjects []string `toml:"exclude_projects"`
+	// CwdMatch and MessageMatch are regular expressions that must match
+	// somewhere in the working directory and the message.
+	CwdMatch     string `toml:"cwd_match"`
+	MessageMatch string `toml:"message_match"`
+
+	cwd     *regexp.Regexp
+	message *regexp.Regexp
+}
+
+// Compile validates the patterns. It must be called before Match.
+func (f *Filter) Compile() error {
+	for _, pattern := range slices.Concat(f.Projects, f.ExcludeProjects) {
+		_, err := path.Match(pattern, "")
+		if err != nil {
+			return fmt.Errorf("project pattern %q: %w", pattern, err)
+		}
+	}
+
+	var err error
+	if f.CwdMatch != "" {
+		f.cwd, err = regexp.Compile(f.CwdMatch)
+		if err != nil {
+			return fmt.Errorf("cwd_match: %w", err)
+		}
+	}
+	if f.MessageMatch != "" {
+		f.message, err = regexp.Compile(f.MessageMatch)
+		if err != nil {
+			return fmt.Errorf("message_match: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Match reports whether the notification passes the filter.
+func (f *Filter) Match(n notifier.Notification) bool {
+	if len(f.On) > 0 && !slices.Contains(f.On, n.NotificationType) {
+		return false
+	}
+
+	project := n.Project()
+	if len(f.Projects) > 0 && !matchAny(f.Projects, project) {
+		return false
+	}
+	if matchAny(f.ExcludeProjects, project) {
+		return false
+	}
+
+	if f.cwd != nil && !f.cwd.MatchString(n.Cwd) {
+		return false
+	}
+	if f.message != nil && !f.message.MatchString(n.Message) {
+		return false
+	}
+
+	return true
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		// Patterns were validated by Compile.
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,71 @@
+package filter_test
+
+import (
+	"testing"
+
+	"github.com/felipeelias/claude-notifier/internal/filter"
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	notif := notifier.Notification{
+		Message:          "Claude needs your permission to use Bash",
+		Cwd:              "/home/me/work/api-server",
+		NotificationType: "permission_prompt",
+	}
+
+	tests := []struct {
+		name   string
+		filter filter.Filter
+		want   bool
+	}{
+		{"empty", filter.Filter{}, true},
+		{"on match", filter.Filter{On: []string{"idle_prompt", "permission_prompt"}}, true},
+		{"on miss", filter.Filter{On: []string{"idle_prompt"}}, false},
+		{"project glob", filter.Filter{Projects: []string{"api-*"}}, true},
+		{"project miss", filter.Filter{Projects: []string{"web", "docs-*"}}, false},
+		{"excluded project", filter.Filter{ExcludeProjects: []string{"*-server"}}, false},
+		{"exclude wins", filter.Filter{Projects: []string{"*"}, ExcludeProjects: []string{"api-server"}}, false},
+		{"cwd match", filter.Filter{CwdMatch: "^/home/me/work/"}, true},
+		{"cwd miss", filter.Filter{CwdMatch: "^/tmp/"}, false},
+		{"message match", filter.Filter{MessageMatch: "(?i)permission"}, true},
+		{"message miss", filter.Filter{MessageMatch: "waiting for your input"}, false},
+		{"all keys", filter.Filter{
+			On: []string{"permission_prompt"}, Projects: []string{"api-*"}, CwdMatch: "work", MessageMatch: "Bash",
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.filter.Compile())
+			assert.Equal(t, tt.want, tt.filter.Match(notif))
+		})
+	}
+}
+
+func TestMatchWithoutType(t *testing.T) {
+	f := filter.Filter{On: []string{"permission_prompt"}}
+	require.NoError(t, f.Compile())
+	assert.False(t, f.Match(notifier.Notification{Message: "hi"}))
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter filter.Filter
+		want   string
+	}{
+		{"bad project glob", filter.Filter{Projects: []string{"[api"}}, `project pattern "[api"`},
+		{"bad exclude glob", filter.Filter{ExcludeProjects: []string{"a\\"}}, `project pattern "a\\"`},
+		{"bad cwd regex", filter.Filter{CwdMatch: "("}, "cwd_match"},
+		{"bad message regex", filter.Filter{MessageMatch: "[z-a]"}, "message_match"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Compile()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
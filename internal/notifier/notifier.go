@@ -14,6 +14,10 @@ type Notification struct {
 	NotificationType string `json:"notification_type"`
 	SessionID        string `json:"session_id"`
 	TranscriptPath   string `json:"transcript_path"`
+	HookEventName    string `json:"hook_event_name"`
+	// Severity is computed from NotificationType by the [global.severity]
+	// config, not read from the hook payload.
+	Severity string `json:"-"`
 }
 
 // Project returns the last path segment of Cwd.
@@ -21,6 +25,12 @@ func (n Notification) Project() string {
 	return filepath.Base(n.Cwd)
 }
 
+// Resumed reports whether the hook event means the user is back in the
+// session, so notifications already shown for it are stale.
+func (n Notification) Resumed() bool {
+	return n.HookEventName == "UserPromptSubmit" || n.HookEventName == "SessionEnd"
+}
+
 // Validate checks that notification fields are within safe size limits.
 func (n Notification) Validate() error {
 	type limit struct {
@@ -35,6 +45,7 @@ func (n Notification) Validate() error {
 		{"NotificationType", n.NotificationType, 64},
 		{"SessionID", n.SessionID, 128},
 		{"TranscriptPath", n.TranscriptPath, 4096},
+		{"HookEventName", n.HookEventName, 64},
 	} {
 		if len(check.value) > check.max {
 			return fmt.Errorf("field %s exceeds maximum length (%d > %d)", check.name, len(check.value), check.max)
@@ -49,3 +60,9 @@ type Notifier interface {
 	Name() string
 	Send(ctx context.Context, n Notification) error
 }
+
+// Clearer is implemented by notifiers that can remove notifications they
+// showed earlier, once the user has resumed the session.
+type Clearer interface {
+	Clear(ctx context.Context, n Notification) error
+}
@@ -31,7 +31,8 @@ func TestNotificationJSON(t *testing.T) {
 		"cwd":"/home/user/project",
 		"notification_type":"idle_prompt",
 		"session_id":"abc123",
-		"transcript_path":"/home/user/.claude/transcript.jsonl"
+		"transcript_path":"/home/user/.claude/transcript.jsonl",
+		"hook_event_name":"Notification"
 	}`
 	var notif notifier.Notification
 	require.NoError(t, json.Unmarshal([]byte(raw), &notif))
@@ -41,9 +42,22 @@ func TestNotificationJSON(t *testing.T) {
 	assert.Equal(t, "idle_prompt", notif.NotificationType)
 	assert.Equal(t, "abc123", notif.SessionID)
 	assert.Equal(t, "/home/user/.claude/transcript.jsonl", notif.TranscriptPath)
+	assert.Equal(t, "Notification", notif.HookEventName)
 	assert.Equal(t, "project", notif.Project())
 }
 
+func TestNotificationResumed(t *testing.T) {
+	for event, want := range map[string]bool{
+		"":                 false,
+		"Notification":     false,
+		"Stop":             false,
+		"UserPromptSubmit": true,
+		"SessionEnd":       true,
+	} {
+		assert.Equal(t, want, notifier.Notification{HookEventName: event}.Resumed(), event)
+	}
+}
+
 func TestValidateAcceptsNormalNotification(t *testing.T) {
 	notif := notifier.Notification{
 		Message:          "Task complete",
@@ -93,3 +107,20 @@ func TestNotifierInterface(t *testing.T) {
 	assert.Len(t, mock.sent, 1)
 	assert.Equal(t, "hello", mock.sent[0].Message)
 }
+
+func TestValidSeverity(t *testing.T) {
+	for _, s := range []string{"low", "normal", "high", "critical"} {
+		assert.True(t, notifier.ValidSeverity(s), s)
+	}
+	for _, s := range []string{"", "urgent", "High"} {
+		assert.False(t, notifier.ValidSeverity(s), s)
+	}
+}
+
+func TestForSeverity(t *testing.T) {
+	m := map[string]string{"high": "urgent", "low": ""}
+	assert.Equal(t, "urgent", notifier.ForSeverity(m, "high", "default"))
+	assert.Empty(t, notifier.ForSeverity(m, "low", "default"))
+	assert.Equal(t, "default", notifier.ForSeverity(m, "normal", "default"))
+	assert.Equal(t, "default", notifier.ForSeverity(nil, "high", "default"))
+}
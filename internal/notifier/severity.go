@@ -0,0 +1,29 @@
+package notifier
+
+// Severity levels, from least to most urgent.
+const (
+	SeverityLow      = "low"
+	SeverityNormal   = "normal"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// ValidSeverity reports whether s is one of the severity levels.
+func ValidSeverity(s string) bool {
+	switch s {
+	case SeverityLow, SeverityNormal, SeverityHigh, SeverityCritical:
+		return true
+	}
+
+	return false
+}
+
+// ForSeverity returns the plugin setting mapped to severity in m, or
+// fallback when m has no entry for it.
+func ForSeverity(m map[string]string, severity, fallback string) string {
+	if value, ok := m[severity]; ok {
+		return value
+	}
+
+	return fallback
+}
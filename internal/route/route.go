@@ -0,0 +1,257 @@
+// Package route picks the notifier instances a notification goes to, from
+// the ordered [[routes]] rules in the config file.
+package route
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/schedule"
+)
+
+// Rule sends notifications matching all of its conditions to the listed
+// notifier instances. Evaluation stops at the first matching rule unless
+// Continue is set.
+type Rule struct {
+	Name      string   `toml:"name"`
+	Match     Match    `toml:"match"`
+	Notifiers []string `toml:"notifiers"`
+	Continue  bool     `toml:"continue"`
+}
+
+// Match holds a rule's conditions. Unset conditions always match.
+type Match struct {
+	// Types lists notification types, e.g. "permission_prompt".
+	Types []string `toml:"types"`
+	// Projects are globs on the project name.
+	Projects []string `toml:"projects"`
+	// Hostnames are globs on the machine's hostname.
+	Hostnames []string `toml:"hostnames"`
+	// Time is a local time window such as "09:00-18:00"; windows may wrap
+	// past midnight ("22:00-07:00").
+	Time string `toml:"time"`
+	// SSH requires (true) or excludes (false) an SSH session.
+	SSH *bool `toml:"ssh"`
+	// Env maps environment variable names to globs their values must match.
+	Env map[string]string `toml:"env"`
+}
+
+// Env is the environment rules are evaluated in.
+type Env struct {
+	Hostname string
+	Now      time.Time
+	SSH      bool
+	Getenv   func(string) string
+}
+
+// CurrentEnv describes the running process.
+func CurrentEnv() Env {
+	hostname, _ := os.Hostname()
+
+	return Env{
+		Hostname: hostname,
+		Now:      time.Now(),
+		SSH:      os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_CLIENT") != "" || os.Getenv("SSH_TTY") != "",
+		Getenv:   os.Getenv,
+	}
+}
+
+// Router evaluates validated rules.
+type Router struct {
+	rules        []rule
+	defaultRoute []string
+}
+
+type rule struct {
+	Rule
+	window *schedule.Window
+}
+
+// New validates the rules. defaultRoute receives notifications that no
+// rule matches.
+func New(rules []Rule, defaultRoute []string) (*Router, error) {
+	r := &Router{defaultRoute: defaultRoute}
+	for i, ru := range rules {
+		compiled, err := compile(ru)
+		if err != nil {
+			return nil, fmt.Errorf("route %s: %w", label(i, ru.Name), err)
+		}
+		r.rules = append(r.rules, compiled)
+	}
+
+	return r, nil
+}
+
+func compile(ru Rule) (rule, error) {
+	if len(ru.Notifiers) == 0 {
+		return rule{}, errors.New("notifiers is required")
+	}
+	patterns := slices.Concat(ru.Match.Projects, ru.Match.Hostnames)
+	for _, value := range ru.Match.Env {
+		patterns = append(patterns, value)
+	}
+	for _, pattern := range patterns {
+		_, err := path.Match(pattern, "")
+		if err != nil {
+			return rule{}, fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+	}
+
+	compiled := rule{Rule: ru}
+	if ru.Match.Time != "" {
+		window, err := schedule.ParseWindow(ru.Match.Time)
+		if err != nil {
+			return rule{}, err
+		}
+		compiled.window = &window
+	}
+
+	return compiled, nil
+}
+
+// Targets lists every instance ID the rules and default route refer to.
+func (r *Router) Targets() []string {
+	seen := map[string]bool{}
+	for _, target := range r.defaultRoute {
+		seen[target] = true
+	}
+	for _, ru := range r.rules {
+		for _, target := range ru.Notifiers {
+			seen[target] = true
+		}
+	}
+	targets := make([]string, 0, len(seen))
+	for target := range seen {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	return targets
+}
+
+// Step records how one rule was evaluated.
+type Step struct {
+	Rule      string
+	Evaluated bool
+	Matched   bool
+	// Reason says why an evaluated rule did not match.
+	Reason string
+}
+
+// Result is the outcome of routing a notification.
+type Result struct {
+	// Targets are the instance IDs to send to, in rule order, without
+	// duplicates.
+	Targets []string
+	Steps   []Step
+	// Default is set when no rule matched and the default route was used.
+	Default bool
+}
+
+// Route evaluates the rules in order against notif.
+func (r *Router) Route(notif notifier.Notification, env Env) Result {
+	var res Result
+	matched, stopped := false, false
+	for i, ru := range r.rules {
+		step := Step{Rule: label(i, ru.Name)}
+		if stopped {
+			res.Steps = append(res.Steps, step)
+
+			continue
+		}
+		step.Evaluated = true
+		step.Reason = ru.mismatch(notif, env)
+		step.Matched = step.Reason == ""
+		res.Steps = append(res.Steps, step)
+		if !step.Matched {
+			continue
+		}
+
+		matched = true
+		res.Targets = appendNew(res.Targets, ru.Notifiers)
+		stopped = !ru.Continue
+	}
+
+	if !matched {
+		res.Default = true
+		res.Targets = appendNew(res.Targets, r.defaultRoute)
+	}
+
+	return res
+}
+
+// mismatch returns why the rule does not match, or "" when it does.
+func (ru rule) mismatch(notif notifier.Notification, env Env) string {
+	m := ru.Match
+	if len(m.Types) > 0 && !slices.Contains(m.Types, notif.NotificationType) {
+		return fmt.Sprintf("type %q not in %v", notif.NotificationType, m.Types)
+	}
+	if len(m.Projects) > 0 && !matchAny(m.Projects, notif.Project()) {
+		return fmt.Sprintf("project %q does not match %v", notif.Project(), m.Projects)
+	}
+	if len(m.Hostnames) > 0 && !matchAny(m.Hostnames, env.Hostname) {
+		return fmt.Sprintf("hostname %q does not match %v", env.Hostname, m.Hostnames)
+	}
+	if ru.window != nil && !ru.window.Contains(env.Now) {
+		return fmt.Sprintf("time %s outside %s", env.Now.Format("15:04"), m.Time)
+	}
+	if m.SSH != nil && *m.SSH != env.SSH {
+		if env.SSH {
+			return "in an SSH session"
+		}
+
+		return "not in an SSH session"
+	}
+
+	names := make([]string, 0, len(m.Env))
+	for name := range m.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := ""
+		if env.Getenv != nil {
+			value = env.Getenv(name)
+		}
+		if ok, _ := path.Match(m.Env[name], value); !ok || value == "" {
+			return fmt.Sprintf("$%s=%q does not match %q", name, value, m.Env[name])
+		}
+	}
+
+	return ""
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		// Patterns were validated by New.
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func appendNew(targets, add []string) []string {
+	for _, target := range add {
+		if !slices.Contains(targets, target) {
+			targets = append(targets, target)
+		}
+	}
+
+	return targets
+}
+
+func label(i int, name string) string {
+	if name == "" {
+		return fmt.Sprintf("#%d", i+1)
+	}
+
+	return fmt.Sprintf("#%d %q", i+1, name)
+}
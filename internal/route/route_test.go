@@ -0,0 +1,139 @@
+package route_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEnv() route.Env {
+	env := map[string]string{"CI": "true", "TERM_PROGRAM": "iTerm.app"}
+
+	return route.Env{
+		Hostname: "work-laptop",
+		Now:      time.Date(2026, 3, 2, 14, 30, 0, 0, time.Local),
+		Getenv:   func(name string) string { return env[name] },
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestMatchConditions(t *testing.T) {
+	notif := notifier.Notification{Cwd: "/src/api-server", NotificationType: "permission_prompt"}
+
+	tests := []struct {
+		name  string
+		match route.Match
+		want  string
+	}{
+		{"empty", route.Match{}, ""},
+		{"type", route.Match{Types: []string{"permission_prompt"}}, ""},
+		{"type miss", route.Match{Types: []string{"idle_prompt"}}, `type "permission_prompt" not in [idle_prompt]`},
+		{"project", route.Match{Projects: []string{"api-*"}}, ""},
+		{"project miss", route.Match{Projects: []string{"web"}}, `project "api-server" does not match [web]`},
+		{"hostname", route.Match{Hostnames: []string{"work-*"}}, ""},
+		{"hostname miss", route.Match{Hostnames: []string{"home-*"}}, `hostname "work-laptop" does not match [home-*]`},
+		{"time", route.Match{Time: "09:00-18:00"}, ""},
+		{"time miss", route.Match{Time: "18:00-09:00"}, "time 14:30 outside 18:00-09:00"},
+		{"time end is exclusive", route.Match{Time: "09:00-14:30"}, "time 14:30 outside 09:00-14:30"},
+		{"ssh", route.Match{SSH: ptr(false)}, ""},
+		{"ssh miss", route.Match{SSH: ptr(true)}, "not in an SSH session"},
+		{"env", route.Match{Env: map[string]string{"CI": "true", "TERM_PROGRAM": "iTerm*"}}, ""},
+		{"env miss", route.Match{Env: map[string]string{"CI": "false"}}, `$CI="true" does not match "false"`},
+		{"env unset", route.Match{Env: map[string]string{"HOME": "*"}}, `$HOME="" does not match "*"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, err := route.New([]route.Rule{{Match: tt.match, Notifiers: []string{"phone"}}}, nil)
+			require.NoError(t, err)
+
+			res := router.Route(notif, testEnv())
+			require.Len(t, res.Steps, 1)
+			assert.Equal(t, tt.want, res.Steps[0].Reason)
+			assert.Equal(t, tt.want == "", res.Steps[0].Matched)
+		})
+	}
+}
+
+func TestTimeWindowWrapsMidnight(t *testing.T) {
+	router, err := route.New([]route.Rule{{Match: route.Match{Time: "22:00-07:00"}, Notifiers: []string{"phone"}}}, nil)
+	require.NoError(t, err)
+
+	env := testEnv()
+	for clock, want := range map[int]bool{23: true, 3: true, 7: false, 12: false} {
+		env.Now = time.Date(2026, 3, 2, clock, 0, 0, 0, time.Local)
+		assert.Equal(t, want, router.Route(notifier.Notification{}, env).Steps[0].Matched, clock)
+	}
+}
+
+func TestFirstMatchAndContinue(t *testing.T) {
+	rules := []route.Rule{
+		{Name: "log everything", Notifiers: []string{"loki"}, Continue: true},
+		{Name: "permission", Match: route.Match{Types: []string{"permission_prompt"}}, Notifiers: []string{"phone", "loki"}},
+		{Name: "work", Match: route.Match{Projects: []string{"api-*"}}, Notifiers: []string{"slack"}},
+	}
+	router, err := route.New(rules, []string{"desktop"})
+	require.NoError(t, err)
+
+	res := router.Route(notifier.Notification{Cwd: "/src/api", NotificationType: "permission_prompt"}, testEnv())
+	assert.Equal(t, []string{"loki", "phone"}, res.Targets)
+	assert.False(t, res.Default)
+	assert.Equal(t, []route.Step{
+		{Rule: `#1 "log everything"`, Evaluated: true, Matched: true},
+		{Rule: `#2 "permission"`, Evaluated: true, Matched: true},
+		{Rule: `#3 "work"`},
+	}, res.Steps)
+
+	res = router.Route(notifier.Notification{Cwd: "/src/api-server", NotificationType: "idle_prompt"}, testEnv())
+	assert.Equal(t, []string{"loki", "slack"}, res.Targets)
+	assert.False(t, res.Default)
+}
+
+func TestDefaultRoute(t *testing.T) {
+	rules := []route.Rule{{Match: route.Match{Types: []string{"permission_prompt"}}, Notifiers: []string{"phone"}}}
+	router, err := route.New(rules, []string{"desktop"})
+	require.NoError(t, err)
+
+	res := router.Route(notifier.Notification{NotificationType: "idle_prompt"}, testEnv())
+	assert.Equal(t, []string{"desktop"}, res.Targets)
+	assert.True(t, res.Default)
+
+	router, err = route.New(rules, nil)
+	require.NoError(t, err)
+	assert.Empty(t, router.Route(notifier.Notification{}, testEnv()).Targets)
+}
+
+func TestTargets(t *testing.T) {
+	router, err := route.New([]route.Rule{
+		{Notifiers: []string{"phone", "loki"}},
+		{Notifiers: []string{"loki"}},
+	}, []string{"desktop"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"desktop", "loki", "phone"}, router.Targets())
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule route.Rule
+		want string
+	}{
+		{"no notifiers", route.Rule{}, `route #1: notifiers is required`},
+		{"bad project glob", route.Rule{Name: "x", Match: route.Match{Projects: []string{"["}}, Notifiers: []string{"a"}}, `route #1 "x": pattern "["`},
+		{"bad env glob", route.Rule{Match: route.Match{Env: map[string]string{"A": "["}}, Notifiers: []string{"a"}}, `pattern "["`},
+		{"bad window", route.Rule{Match: route.Match{Time: "9-5"}, Notifiers: []string{"a"}}, `invalid clock time "9"`},
+		{"no dash", route.Rule{Match: route.Match{Time: "09:00"}, Notifiers: []string{"a"}}, "want HH:MM-HH:MM"},
+		{"empty window", route.Rule{Match: route.Match{Time: "09:00-09:00"}, Notifiers: []string{"a"}}, "empty window"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := route.New([]route.Rule{tt.rule}, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Package schedule implements quiet hours: the times at which a notifier,
+// or claude-notifier as a whole, sends notifications.
+package schedule
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	// Embedded zone data, so timezone works on systems without it.
+	_ "time/tzdata"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+)
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Schedule limits sending to active hours on some weekdays. The zero
+// Schedule is always active.
+type Schedule struct {
+	// ActiveHours lists windows such as "09:00-18:00"; empty means all day.
+	ActiveHours []string `toml:"active_hours"`
+	// Weekdays lists days ("mon") or ranges ("mon-fri"); empty means every
+	// day. A window that wraps past midnight belongs to the day it starts.
+	Weekdays []string `toml:"weekdays"`
+	// Timezone is an IANA name such as "Europe/Berlin"; empty means local.
+	Timezone string `toml:"timezone"`
+	// BypassOn lists notification types and severity levels that are sent
+	// even outside the schedule.
+	BypassOn []string `toml:"bypass_on"`
+
+	windows  []Window
+	days     map[time.Weekday]bool
+	location *time.Location
+}
+
+// Compile validates the schedule. It must be called before Allows.
+func (s *Schedule) Compile() error {
+	s.windows = nil
+	for _, hours := range s.ActiveHours {
+		w, err := ParseWindow(hours)
+		if err != nil {
+			return fmt.Errorf("active_hours: %w", err)
+		}
+		s.windows = append(s.windows, w)
+	}
+
+	s.days = nil
+	for _, spec := range s.Weekdays {
+		days, err := parseWeekdays(spec)
+		if err != nil {
+			return err
+		}
+		if s.days == nil {
+			s.days = map[time.Weekday]bool{}
+		}
+		for _, day := range days {
+			s.days[day] = true
+		}
+	}
+
+	s.location = time.Local
+	if s.Timezone != "" {
+		loc, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			return fmt.Errorf("timezone: %w", err)
+		}
+		s.location = loc
+	}
+
+	return nil
+}
+
+// parseWeekdays parses "mon" or a range such as "mon-fri" or "fri-mon".
+func parseWeekdays(spec string) ([]time.Weekday, error) {
+	first, last, isRange := strings.Cut(strings.ToLower(strings.TrimSpace(spec)), "-")
+	start, ok := weekdayNames[first]
+	if !ok {
+		return nil, fmt.Errorf("weekdays: unknown day %q (want mon, tue, wed, thu, fri, sat or sun)", first)
+	}
+	if !isRange {
+		return []time.Weekday{start}, nil
+	}
+	end, ok := weekdayNames[last]
+	if !ok {
+		return nil, fmt.Errorf("weekdays: unknown day %q (want mon, tue, wed, thu, fri, sat or sun)", last)
+	}
+
+	days := []time.Weekday{start}
+	for day := start; day != end; {
+		day = (day + 1) % 7
+		days = append(days, day)
+	}
+
+	return days, nil
+}
+
+// Allows reports whether notif may be sent at now, and why not otherwise.
+func (s *Schedule) Allows(notif notifier.Notification, now time.Time) (bool, string) {
+	if s.Bypassed(notif) {
+		return true, ""
+	}
+
+	loc := s.location
+	if loc == nil {
+		loc = time.Local
+	}
+	now = now.In(loc)
+
+	day := now.Weekday()
+	if len(s.windows) > 0 {
+		in := false
+		for _, w := range s.windows {
+			if w.Contains(now) {
+				in = true
+				if w.from > w.to && now.Hour()*60+now.Minute() < w.to {
+					// Past midnight: the window started the day before.
+					day = (day + 6) % 7
+				}
+
+				break
+			}
+		}
+		if !in {
+			return false, fmt.Sprintf("%s is outside active_hours %v", now.Format("15:04"), s.ActiveHours)
+		}
+	}
+	if s.days != nil && !s.days[day] {
+		return false, fmt.Sprintf("%s is not in weekdays %v", strings.ToLower(day.String()[:3]), s.Weekdays)
+	}
+
+	return true, ""
+}
+
+// Bypassed reports whether notif's type or severity is in bypass_on.
+func (s *Schedule) Bypassed(notif notifier.Notification) bool {
+	return slices.Contains(s.BypassOn, notif.NotificationType) ||
+		(notif.Severity != "" && slices.Contains(s.BypassOn, notif.Severity))
+}
@@ -0,0 +1,92 @@
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/schedule"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllows(t *testing.T) {
+	// Monday 2 March 2026, 14:30 UTC.
+	now := time.Date(2026, 3, 2, 14, 30, 0, 0, time.UTC)
+	notif := notifier.Notification{NotificationType: "idle_prompt", Severity: notifier.SeverityNormal}
+
+	tests := []struct {
+		name     string
+		schedule schedule.Schedule
+		want     string
+	}{
+		{"empty", schedule.Schedule{}, ""},
+		{"in window", schedule.Schedule{ActiveHours: []string{"09:00-18:00"}}, ""},
+		{"outside window", schedule.Schedule{ActiveHours: []string{"18:00-09:00"}}, "14:30 is outside active_hours [18:00-09:00]"},
+		{"end is exclusive", schedule.Schedule{ActiveHours: []string{"09:00-14:30"}}, "14:30 is outside active_hours [09:00-14:30]"},
+		{"second window", schedule.Schedule{ActiveHours: []string{"07:00-09:00", "14:00-15:00"}}, ""},
+		{"weekday", schedule.Schedule{Weekdays: []string{"mon"}}, ""},
+		{"weekday miss", schedule.Schedule{Weekdays: []string{"tue", "wed"}}, "mon is not in weekdays [tue wed]"},
+		{"weekday range", schedule.Schedule{Weekdays: []string{"mon-fri"}}, ""},
+		{"weekday range wraps", schedule.Schedule{Weekdays: []string{"fri-mon"}}, ""},
+		{"weekday range miss", schedule.Schedule{Weekdays: []string{"Tue-Sun"}}, "mon is not in weekdays [Tue-Sun]"},
+		{"timezone", schedule.Schedule{ActiveHours: []string{"09:00-18:00"}, Timezone: "Asia/Tokyo"}, "23:30 is outside active_hours [09:00-18:00]"},
+		{"timezone changes day", schedule.Schedule{Weekdays: []string{"mon"}, Timezone: "Pacific/Kiritimati"}, "tue is not in weekdays [mon]"},
+		{"bypass type", schedule.Schedule{Weekdays: []string{"sat"}, BypassOn: []string{"idle_prompt"}}, ""},
+		{"bypass severity", schedule.Schedule{ActiveHours: []string{"18:00-09:00"}, BypassOn: []string{"normal"}}, ""},
+		{"bypass miss", schedule.Schedule{Weekdays: []string{"sat"}, BypassOn: []string{"permission_prompt", "high"}}, "mon is not in weekdays [sat]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.schedule.Compile())
+			ok, reason := tt.schedule.Allows(notif, now)
+			assert.Equal(t, tt.want == "", ok)
+			assert.Equal(t, tt.want, reason)
+		})
+	}
+}
+
+func TestAllowsWindowPastMidnight(t *testing.T) {
+	// A Friday night window still applies in the early hours of Saturday.
+	s := schedule.Schedule{ActiveHours: []string{"22:00-02:00"}, Weekdays: []string{"fri"}, Timezone: "UTC"}
+	require.NoError(t, s.Compile())
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{"friday evening", time.Date(2026, 3, 6, 23, 0, 0, 0, time.UTC), true},
+		{"saturday early", time.Date(2026, 3, 7, 1, 0, 0, 0, time.UTC), true},
+		{"saturday evening", time.Date(2026, 3, 7, 23, 0, 0, 0, time.UTC), false},
+		{"friday early", time.Date(2026, 3, 6, 1, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, _ := s.Allows(notifier.Notification{}, tt.now)
+			assert.Equal(t, tt.want, ok)
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule schedule.Schedule
+		want     string
+	}{
+		{"bad window", schedule.Schedule{ActiveHours: []string{"9-5"}}, `active_hours: time "9-5": invalid clock time "9"`},
+		{"no dash", schedule.Schedule{ActiveHours: []string{"09:00"}}, "want HH:MM-HH:MM"},
+		{"empty window", schedule.Schedule{ActiveHours: []string{"09:00-09:00"}}, "empty window"},
+		{"bad day", schedule.Schedule{Weekdays: []string{"monday"}}, `weekdays: unknown day "monday"`},
+		{"bad range end", schedule.Schedule{Weekdays: []string{"mon-xyz"}}, `weekdays: unknown day "xyz"`},
+		{"bad timezone", schedule.Schedule{Timezone: "Mars/Olympus"}, "timezone:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schedule.Compile()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a daily time window, which may wrap past midnight.
+type Window struct {
+	from, to int // minutes since midnight
+}
+
+// ParseWindow parses "HH:MM-HH:MM", e.g. "09:00-18:00" or "22:00-07:00".
+func ParseWindow(window string) (Window, error) {
+	start, end, ok := strings.Cut(window, "-")
+	if !ok {
+		return Window{}, fmt.Errorf("time %q: want HH:MM-HH:MM", window)
+	}
+	from, err := parseClock(strings.TrimSpace(start))
+	if err != nil {
+		return Window{}, fmt.Errorf("time %q: %w", window, err)
+	}
+	to, err := parseClock(strings.TrimSpace(end))
+	if err != nil {
+		return Window{}, fmt.Errorf("time %q: %w", window, err)
+	}
+	if from == to {
+		return Window{}, fmt.Errorf("time %q is an empty window", window)
+	}
+
+	return Window{from: from, to: to}, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid clock time %q", s)
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains reports whether t's clock time is in the window. The start is
+// inclusive and the end exclusive.
+func (w Window) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if w.from <= w.to {
+		return minute >= w.from && minute < w.to
+	}
+
+	return minute >= w.from || minute < w.to
+}
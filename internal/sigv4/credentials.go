@@ -0,0 +1,146 @@
+package sigv4
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoCredentials is returned when no credentials source is configured.
+var ErrNoCredentials = errors.New("no AWS credentials found (set access keys, AWS_ACCESS_KEY_ID or a shared credentials profile)")
+
+// LoadCredentials resolves credentials the way the AWS CLI does for static
+// keys: AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY/AWS_SESSION_TOKEN first,
+// then the profile in the shared credentials file
+// ($AWS_SHARED_CREDENTIALS_FILE, else ~/.aws/credentials). An explicit
+// profile skips the environment keys; an empty one means $AWS_PROFILE, else
+// "default".
+func LoadCredentials(profile string) (Credentials, error) {
+	creds := Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if profile == "" && creds.AccessKeyID != "" && creds.SecretAccessKey != "" {
+		return creds, nil
+	}
+
+	profile = profileName(profile)
+	path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if path == "" {
+		path = filepath.Join(homeDir(), ".aws", "credentials")
+	}
+	section, err := readSection(path, profile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Credentials{}, ErrNoCredentials
+		}
+
+		return Credentials{}, err
+	}
+	if section == nil {
+		return Credentials{}, fmt.Errorf("profile %q not found in %s", profile, path)
+	}
+
+	creds = Credentials{
+		AccessKeyID:     section["aws_access_key_id"],
+		SecretAccessKey: section["aws_secret_access_key"],
+		SessionToken:    section["aws_session_token"],
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return Credentials{}, fmt.Errorf("profile %q in %s has no static keys", profile, path)
+	}
+
+	return creds, nil
+}
+
+// LoadRegion returns $AWS_REGION, $AWS_DEFAULT_REGION or the region of the
+// profile in the shared config file ($AWS_CONFIG_FILE, else ~/.aws/config),
+// in that order. It returns "" when none is set.
+func LoadRegion(profile string) string {
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region := os.Getenv(env); region != "" {
+			return region
+		}
+	}
+
+	path := os.Getenv("AWS_CONFIG_FILE")
+	if path == "" {
+		path = filepath.Join(homeDir(), ".aws", "config")
+	}
+	profile = profileName(profile)
+	name := "profile " + profile
+	if profile == "default" {
+		name = "default"
+	}
+	section, err := readSection(path, name)
+	if err != nil || section == nil {
+		return ""
+	}
+
+	return section["region"]
+}
+
+func profileName(profile string) string {
+	if profile == "" {
+		profile = os.Getenv("AWS_PROFILE")
+	}
+	if profile == "" {
+		profile = "default"
+	}
+
+	return profile
+}
+
+func homeDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return os.Getenv("HOME")
+	}
+
+	return home
+}
+
+// readSection returns the key/value pairs of the [name] section of an INI
+// file, or nil if the section does not exist.
+func readSection(path, name string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var section map[string]string
+	inSection := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inSection = strings.TrimSpace(line[1:len(line)-1]) == name
+			if inSection && section == nil {
+				section = map[string]string{}
+			}
+
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if ok {
+			section[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+		}
+	}
+	err = scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	return section, nil
+}
@@ -0,0 +1,142 @@
+package sigv4_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/sigv4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test vectors from the AWS Signature Version 4 documentation and test suite.
+var (
+	exampleCreds = sigv4.Credentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	exampleTime = time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+)
+
+func TestSignGetVanilla(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, sigv4.Sign(req, nil, exampleCreds, "us-east-1", "service", exampleTime))
+
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, "+
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"))
+}
+
+func TestSignQueryAndContentType(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Version=2010-05-08&Action=ListUsers", nil)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	require.NoError(t, sigv4.Sign(req, nil, exampleCreds, "us-east-1", "iam", exampleTime))
+
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, "+
+		"SignedHeaders=content-type;host;x-amz-date, "+
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+		req.Header.Get("Authorization"))
+}
+
+func TestSignSessionToken(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "https://sns.us-east-1.amazonaws.com/", nil)
+	require.NoError(t, err)
+	creds := exampleCreds
+	creds.SessionToken = "token"
+
+	require.NoError(t, sigv4.Sign(req, []byte("Action=Publish"), creds, "us-east-1", "sns", exampleTime))
+
+	assert.Equal(t, "token", req.Header.Get("X-Amz-Security-Token"))
+	assert.Contains(t, req.Header.Get("Authorization"), "SignedHeaders=host;x-amz-date;x-amz-security-token,")
+}
+
+func TestSignMissingKeys(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	require.NoError(t, err)
+	assert.Error(t, sigv4.Sign(req, nil, sigv4.Credentials{}, "us-east-1", "sns", exampleTime))
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	return path
+}
+
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, env := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN",
+		"AWS_PROFILE", "AWS_REGION", "AWS_DEFAULT_REGION"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "missing"))
+}
+
+func TestLoadCredentialsFromEnv(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "token")
+
+	creds, err := sigv4.LoadCredentials("")
+	require.NoError(t, err)
+	assert.Equal(t, sigv4.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token"}, creds)
+}
+
+func TestLoadCredentialsFromSharedFile(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", writeFile(t, `[default]
+aws_access_key_id = AKIDDEFAULT
+aws_secret_access_key = default-secret
+
+# comment
+[work]
+aws_access_key_id=AKIDWORK
+aws_secret_access_key=work-secret
+`))
+
+	creds, err := sigv4.LoadCredentials("")
+	require.NoError(t, err)
+	assert.Equal(t, "AKIDDEFAULT", creds.AccessKeyID)
+
+	t.Setenv("AWS_PROFILE", "work")
+	creds, err = sigv4.LoadCredentials("")
+	require.NoError(t, err)
+	assert.Equal(t, "work-secret", creds.SecretAccessKey)
+
+	_, err = sigv4.LoadCredentials("missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `profile "missing" not found`)
+}
+
+func TestLoadCredentialsNone(t *testing.T) {
+	clearEnv(t)
+
+	_, err := sigv4.LoadCredentials("")
+	assert.ErrorIs(t, err, sigv4.ErrNoCredentials)
+}
+
+func TestLoadRegion(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("AWS_CONFIG_FILE", writeFile(t, "[default]\nregion = eu-west-1\n\n[profile work]\nregion = ap-south-1\n"))
+
+	assert.Equal(t, "eu-west-1", sigv4.LoadRegion(""))
+	assert.Equal(t, "ap-south-1", sigv4.LoadRegion("work"))
+	assert.Empty(t, sigv4.LoadRegion("other"))
+
+	t.Setenv("AWS_DEFAULT_REGION", "us-west-2")
+	assert.Equal(t, "us-west-2", sigv4.LoadRegion("work"))
+	t.Setenv("AWS_REGION", "us-east-2")
+	assert.Equal(t, "us-east-2", sigv4.LoadRegion("work"))
+}
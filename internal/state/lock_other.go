@@ -0,0 +1,39 @@
+//go:build !unix
+
+package state
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// lockStale is how old a lock file must be to belong to a process that
+// died without removing it.
+const lockStale = 30 * time.Second
+
+// lock creates path exclusively, waiting while another process holds it.
+func lock(path string) (func(), error) {
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, stateFilePerms)
+		if err == nil {
+			_ = file.Close()
+
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > lockStale {
+			_ = os.Remove(path)
+
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.New("timed out waiting for " + path)
+		}
+		time.Sleep(lockRetry)
+	}
+}
@@ -0,0 +1,43 @@
+//go:build unix
+
+package state
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+// lock takes an exclusive flock on path, waiting up to lockTimeout for
+// other holders.
+func lock(path string) (func(), error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, stateFilePerms)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) && !errors.Is(err, syscall.EINTR) {
+			_ = file.Close()
+
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			_ = file.Close()
+
+			return nil, errors.New("timed out waiting for " + path)
+		}
+		time.Sleep(lockRetry)
+	}
+
+	return func() {
+		_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		_ = file.Close()
+	}, nil
+}
@@ -0,0 +1,35 @@
+//go:build unix
+
+package state_test
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateGivesUpOnHeldLock(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits for the lock timeout")
+	}
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	held, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	require.NoError(t, err)
+	defer func() { _ = held.Close() }()
+	require.NoError(t, syscall.Flock(int(held.Fd()), syscall.LOCK_EX))
+
+	start := time.Now()
+	called := false
+	err = state.Update(path, time.Now(), func(*state.State) { called = true })
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out")
+	assert.False(t, called)
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
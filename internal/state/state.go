@@ -0,0 +1,170 @@
+// Package state keeps data between invocations in a JSON file. Each hook
+// runs a fresh process, so de-duplication and rate limits live here, under
+// a lock so concurrent sessions don't lose each other's updates.
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/fsutil"
+)
+
+const (
+	stateFilePerms = 0600
+	stateDirPerms  = 0700
+
+	lockRetry = 10 * time.Millisecond
+	// lockTimeout bounds the wait for another invocation holding the lock,
+	// so a hung process can't block every later hook.
+	lockTimeout = 5 * time.Second
+)
+
+// Dir returns the directory for state kept between invocations, following
+// the XDG base directory spec ($XDG_STATE_HOME, else ~/.local/state).
+func Dir() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		dir = os.ExpandEnv("$HOME/.local/state")
+	}
+
+	return dir + "/claude-notifier"
+}
+
+// State is the content of the state file.
+type State struct {
+	// Seen maps de-duplication keys to the time their window ends.
+	Seen map[string]time.Time `json:"seen,omitempty"`
+	// Buckets holds the rate limit token bucket of each notifier id.
+	Buckets map[string]Bucket `json:"buckets,omitempty"`
+}
+
+// Bucket is a token bucket that refills to its capacity once a minute.
+type Bucket struct {
+	Tokens  float64   `json:"tokens"`
+	Updated time.Time `json:"updated"`
+}
+
+// Update locks the state file at path, loads it, drops what expired at now,
+// calls fn and saves the result. A missing or unreadable file starts out
+// empty.
+func Update(path string, now time.Time, fn func(*State)) error {
+	s := &State{}
+
+	return UpdateJSON(path, s, func() error {
+		if s.Seen == nil {
+			s.Seen = map[string]time.Time{}
+		}
+		if s.Buckets == nil {
+			s.Buckets = map[string]Bucket{}
+		}
+		s.prune(now)
+		fn(s)
+
+		return nil
+	})
+}
+
+// UpdateJSON locks the JSON file at path, decodes it into v, calls fn and
+// writes v back unless fn fails. A missing or unreadable file leaves v as
+// it is. Concurrent invocations updating the same file wait for each other.
+func UpdateJSON(path string, v any, fn func() error) error {
+	err := os.MkdirAll(filepath.Dir(path), stateDirPerms)
+	if err != nil {
+		return fmt.Errorf("creating state dir: %w", err)
+	}
+
+	unlock, err := lock(path + ".lock")
+	if err != nil {
+		return fmt.Errorf("locking %s: %w", filepath.Base(path), err)
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		_ = json.Unmarshal(data, v)
+	}
+
+	err = fn()
+	if err != nil {
+		return err
+	}
+
+	data, err = json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", filepath.Base(path), err)
+	}
+	err = fsutil.WriteFile(path, append(data, '\n'), stateFilePerms)
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", filepath.Base(path), err)
+	}
+
+	return nil
+}
+
+// prune drops expired keys and buckets that have refilled, which behave
+// the same as missing ones.
+func (s *State) prune(now time.Time) {
+	for key, until := range s.Seen {
+		if !now.Before(until) {
+			delete(s.Seen, key)
+		}
+	}
+	for id, b := range s.Buckets {
+		if now.Sub(b.Updated) >= time.Minute {
+			delete(s.Buckets, id)
+		}
+	}
+}
+
+// Duplicate reports whether key was recorded less than its window ago.
+// Otherwise it records key for window from now.
+func (s *State) Duplicate(key string, now time.Time, window time.Duration) bool {
+	if until, ok := s.Seen[key]; ok && now.Before(until) {
+		return true
+	}
+	s.Seen[key] = now.Add(window)
+
+	return false
+}
+
+// Forget removes key, so the next notification with it is not a duplicate.
+func (s *State) Forget(key string) {
+	delete(s.Seen, key)
+}
+
+// Take takes a token from id's bucket, which holds perMinute tokens and
+// refills at perMinute per minute. It reports false when the bucket is
+// empty.
+func (s *State) Take(id string, perMinute int, now time.Time) bool {
+	tokens := s.tokens(id, perMinute, now)
+	if tokens < 1 {
+		s.Buckets[id] = Bucket{Tokens: tokens, Updated: now}
+
+		return false
+	}
+	s.Buckets[id] = Bucket{Tokens: tokens - 1, Updated: now}
+
+	return true
+}
+
+// Refund puts back a token taken from id's bucket.
+func (s *State) Refund(id string, perMinute int, now time.Time) {
+	tokens := min(float64(perMinute), s.tokens(id, perMinute, now)+1)
+	s.Buckets[id] = Bucket{Tokens: tokens, Updated: now}
+}
+
+// tokens returns the tokens in id's bucket at now.
+func (s *State) tokens(id string, perMinute int, now time.Time) float64 {
+	capacity := float64(perMinute)
+	b, ok := s.Buckets[id]
+	if !ok {
+		return capacity
+	}
+	elapsed := max(now.Sub(b.Updated), 0)
+
+	return min(capacity, b.Tokens+elapsed.Minutes()*capacity)
+}
@@ -0,0 +1,196 @@
+package state_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newState() *state.State {
+	return &state.State{Seen: map[string]time.Time{}, Buckets: map[string]state.Bucket{}}
+}
+
+func TestDuplicate(t *testing.T) {
+	s := newState()
+	now := time.Date(2026, 3, 2, 14, 30, 0, 0, time.UTC)
+
+	assert.False(t, s.Duplicate("a", now, 5*time.Minute))
+	assert.True(t, s.Duplicate("a", now.Add(time.Minute), 5*time.Minute))
+	assert.False(t, s.Duplicate("b", now.Add(time.Minute), 5*time.Minute), "other keys are independent")
+	assert.True(t, s.Duplicate("a", now.Add(4*time.Minute), 5*time.Minute), "duplicates don't extend the window")
+	assert.False(t, s.Duplicate("a", now.Add(5*time.Minute), 5*time.Minute))
+}
+
+func TestTake(t *testing.T) {
+	s := newState()
+	now := time.Date(2026, 3, 2, 14, 30, 0, 0, time.UTC)
+
+	for range 3 {
+		assert.True(t, s.Take("phone", 3, now))
+	}
+	assert.False(t, s.Take("phone", 3, now), "bucket is empty")
+	assert.True(t, s.Take("desktop", 3, now), "buckets are per id")
+
+	assert.False(t, s.Take("phone", 3, now.Add(10*time.Second)), "half a token refilled")
+	assert.True(t, s.Take("phone", 3, now.Add(20*time.Second)), "one token refilled")
+	assert.False(t, s.Take("phone", 3, now.Add(20*time.Second)))
+
+	for range 3 {
+		assert.True(t, s.Take("phone", 3, now.Add(time.Hour)), "refills up to capacity")
+	}
+	assert.False(t, s.Take("phone", 3, now.Add(time.Hour)))
+}
+
+func TestTakeClockGoesBack(t *testing.T) {
+	s := newState()
+	now := time.Date(2026, 3, 2, 14, 30, 0, 0, time.UTC)
+
+	assert.True(t, s.Take("phone", 1, now))
+	assert.False(t, s.Take("phone", 1, now.Add(-time.Hour)))
+}
+
+func TestUpdatePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "state.json")
+	now := time.Now()
+
+	require.NoError(t, state.Update(path, now, func(s *state.State) {
+		assert.False(t, s.Duplicate("a", now, time.Hour))
+		assert.True(t, s.Take("phone", 1, now))
+	}))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	require.NoError(t, state.Update(path, now, func(s *state.State) {
+		assert.True(t, s.Duplicate("a", now, time.Hour))
+		assert.False(t, s.Take("phone", 1, now))
+	}))
+}
+
+func TestUpdatePrunes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	past := time.Now().Add(-time.Hour)
+
+	require.NoError(t, state.Update(path, past, func(s *state.State) {
+		s.Duplicate("old", past, time.Minute)
+		s.Take("phone", 1, past)
+	}))
+	require.NoError(t, state.Update(path, time.Now(), func(s *state.State) {
+		assert.Empty(t, s.Seen)
+		assert.Empty(t, s.Buckets)
+	}))
+}
+
+func TestUpdatePrunesAtNow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	now := time.Date(2026, 3, 2, 14, 30, 0, 0, time.UTC)
+
+	require.NoError(t, state.Update(path, now, func(s *state.State) {
+		s.Duplicate("a", now, time.Minute)
+		s.Take("phone", 1, now)
+	}))
+	require.NoError(t, state.Update(path, now.Add(30*time.Second), func(s *state.State) {
+		assert.Contains(t, s.Seen, "a", "pruning uses the given time, not the wall clock")
+		assert.Contains(t, s.Buckets, "phone")
+	}))
+}
+
+func TestUpdateCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0600))
+
+	require.NoError(t, state.Update(path, time.Now(), func(s *state.State) {
+		assert.False(t, s.Duplicate("a", time.Now(), time.Minute))
+	}))
+}
+
+func TestUpdateConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sent := 0
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, state.Update(path, now, func(s *state.State) {
+				if s.Take("phone", 5, now) {
+					mu.Lock()
+					sent++
+					mu.Unlock()
+				}
+			}))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 5, sent)
+}
+
+func TestUpdateJSONConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "threads.json")
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m := map[string]int{}
+			assert.NoError(t, state.UpdateJSON(path, &m, func() error {
+				m[strconv.Itoa(i)] = i
+
+				return nil
+			}))
+		}()
+	}
+	wg.Wait()
+
+	m := map[string]int{}
+	require.NoError(t, state.UpdateJSON(path, &m, func() error { return nil }))
+	assert.Len(t, m, 20, "no update is lost")
+}
+
+func TestUpdateJSONError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	m := map[string]int{}
+
+	err := state.UpdateJSON(path, &m, func() error { return errors.New("boom") })
+	require.EqualError(t, err, "boom")
+	assert.NoFileExists(t, path)
+}
+
+func TestForget(t *testing.T) {
+	s := newState()
+	now := time.Date(2026, 3, 2, 14, 30, 0, 0, time.UTC)
+
+	assert.False(t, s.Duplicate("a", now, 5*time.Minute))
+	s.Forget("a")
+	assert.False(t, s.Duplicate("a", now, 5*time.Minute))
+}
+
+func TestRefund(t *testing.T) {
+	s := newState()
+	now := time.Date(2026, 3, 2, 14, 30, 0, 0, time.UTC)
+
+	assert.True(t, s.Take("phone", 1, now))
+	assert.False(t, s.Take("phone", 1, now))
+	s.Refund("phone", 1, now)
+	assert.True(t, s.Take("phone", 1, now))
+
+	s.Refund("desktop", 2, now)
+	s.Refund("desktop", 2, now)
+	assert.True(t, s.Take("desktop", 2, now))
+	assert.True(t, s.Take("desktop", 2, now))
+	assert.False(t, s.Take("desktop", 2, now), "refunds don't exceed capacity")
+}
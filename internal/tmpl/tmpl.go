@@ -20,6 +20,7 @@ func BuildContext(notif notifier.Notification, vars map[string]string) map[strin
 		"NotificationType": notif.NotificationType,
 		"SessionID":        notif.SessionID,
 		"TranscriptPath":   notif.TranscriptPath,
+		"Severity":         notif.Severity,
 	}
 	for k, val := range vars {
 		if k == "" {
@@ -17,6 +17,7 @@ func TestBuildContext(t *testing.T) {
 		NotificationType: "idle_prompt",
 		SessionID:        "abc123",
 		TranscriptPath:   "/tmp/transcript",
+		Severity:         "normal",
 	}
 	ctx := tmpl.BuildContext(notif, nil)
 
@@ -27,6 +28,7 @@ func TestBuildContext(t *testing.T) {
 	assert.Equal(t, "idle_prompt", ctx["NotificationType"])
 	assert.Equal(t, "abc123", ctx["SessionID"])
 	assert.Equal(t, "/tmp/transcript", ctx["TranscriptPath"])
+	assert.Equal(t, "normal", ctx["Severity"])
 }
 
 func TestBuildContextWithVars(t *testing.T) {
@@ -0,0 +1,368 @@
+// Package transcript renders excerpts of Claude Code session transcripts
+// (the JSONL file at a notification's transcript_path).
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Formats accepted by Options.Format.
+const (
+	FormatMarkdown = "markdown"
+	FormatText     = "text"
+)
+
+const (
+	maxToolInput  = 200
+	maxToolResult = 500
+	truncatedNote = "[earlier content truncated]\n\n"
+	redacted      = "[REDACTED]"
+)
+
+// defaultRedactions match common credentials. A match keeps any captured
+// "key=" prefix and replaces the secret.
+var defaultRedactions = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`(?i)((?:api[_-]?key|secret|token|passw(?:or)?d|authorization)["']?\s*[:=]\s*["']?(?:(?:bearer|basic|token)\s+)?)[^\s"',;]+`),
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]{8,}`),
+	regexp.MustCompile(`AKIA[0-9A-Z]{16}`),
+	regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{30,}|github_pat_[A-Za-z0-9_]{20,})`),
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`),
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`),
+}
+
+// Options controls what Excerpt renders.
+type Options struct {
+	// Turns is the number of trailing turns to include. A turn is a user
+	// prompt, or everything the assistant did in response to one, tool
+	// calls included.
+	Turns int
+	// Format is FormatMarkdown (default) or FormatText.
+	Format string
+	// MaxBytes caps the output; the oldest content is dropped first. Zero
+	// means no cap.
+	MaxBytes int
+	// Redact holds extra regular expressions whose matches are replaced,
+	// on top of the built-in credential patterns.
+	Redact []string
+}
+
+// turn is a run of transcript entries from one speaker.
+type turn struct {
+	user  bool
+	parts []string
+}
+
+// entry is one line of the transcript file. Only conversation messages are
+// used; bookkeeping entries and subagent (sidechain) messages are skipped.
+type entry struct {
+	Type        string `json:"type"`
+	IsMeta      bool   `json:"isMeta"`
+	IsSidechain bool   `json:"isSidechain"`
+	Message     struct {
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+type block struct {
+	Type    string          `json:"type"`
+	Text    string          `json:"text"`
+	Name    string          `json:"name"`
+	Input   json.RawMessage `json:"input"`
+	Content json.RawMessage `json:"content"`
+	IsError bool            `json:"is_error"`
+}
+
+// Excerpt renders the last opts.Turns turns of the transcript at path, with
+// credentials redacted.
+func Excerpt(path string, opts Options) (string, error) {
+	if opts.Turns <= 0 {
+		return "", errors.New("turns must be positive")
+	}
+	markdown := true
+	switch opts.Format {
+	case FormatMarkdown, "":
+	case FormatText:
+		markdown = false
+	default:
+		return "", fmt.Errorf("unknown format %q (want markdown or text)", opts.Format)
+	}
+	r := renderer{markdown: markdown, redactions: defaultRedactions}
+	for _, pattern := range opts.Redact {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", fmt.Errorf("redact pattern %q: %w", pattern, err)
+		}
+		r.redactions = append(r.redactions[:len(r.redactions):len(r.redactions)], re)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("opening transcript: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	turns, err := r.readTurns(file, opts.Turns)
+	if err != nil {
+		return "", err
+	}
+	if len(turns) == 0 {
+		return "", errors.New("transcript has no messages")
+	}
+
+	var buf strings.Builder
+	for i, t := range turns {
+		if i > 0 && !markdown {
+			buf.WriteString("\n")
+		}
+		speaker := "Claude"
+		if t.user {
+			speaker = "User"
+		}
+		if markdown {
+			buf.WriteString("### " + speaker + "\n\n")
+		} else {
+			buf.WriteString(speaker + ":\n")
+		}
+		for _, part := range t.parts {
+			buf.WriteString(part + "\n")
+			if markdown {
+				buf.WriteString("\n")
+			}
+		}
+	}
+
+	return capBytes(strings.TrimRight(buf.String(), "\n")+"\n", opts.MaxBytes), nil
+}
+
+// renderer turns transcript entries into text.
+type renderer struct {
+	markdown   bool
+	redactions []*regexp.Regexp
+}
+
+// redact replaces credentials in s. It runs before anything is truncated,
+// so a cut can never leave part of a secret unmatched.
+func (r renderer) redact(s string) string {
+	for _, re := range r.redactions {
+		if re.NumSubexp() > 0 {
+			s = re.ReplaceAllString(s, "${1}"+redacted)
+		} else {
+			s = re.ReplaceAllLiteralString(s, redacted)
+		}
+	}
+
+	return s
+}
+
+// readTurns keeps the last n turns while streaming through the file.
+func (r renderer) readTurns(src io.Reader, n int) ([]turn, error) {
+	var turns []turn
+	reader := bufio.NewReader(src)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			user, parts := r.parseEntry(line)
+			if len(parts) > 0 {
+				if len(turns) > 0 && turns[len(turns)-1].user == user && !user {
+					last := &turns[len(turns)-1]
+					last.parts = append(last.parts, parts...)
+				} else {
+					turns = append(turns, turn{user: user, parts: parts})
+					if len(turns) > n {
+						turns = turns[1:]
+					}
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return turns, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading transcript: %w", err)
+		}
+	}
+}
+
+// parseEntry returns whether the entry starts a user turn, and its rendered
+// parts. Tool results are sent as user messages but belong to the
+// assistant's turn.
+func (r renderer) parseEntry(line []byte) (bool, []string) {
+	var e entry
+	if json.Unmarshal(line, &e) != nil || e.IsMeta || e.IsSidechain {
+		return false, nil
+	}
+	if e.Type != "user" && e.Type != "assistant" {
+		return false, nil
+	}
+
+	var text string
+	if json.Unmarshal(e.Message.Content, &text) == nil {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return false, nil
+		}
+
+		return e.Type == "user", []string{r.redact(text)}
+	}
+
+	var blocks []block
+	if json.Unmarshal(e.Message.Content, &blocks) != nil {
+		return false, nil
+	}
+
+	user := false
+	var parts []string
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			if t := strings.TrimSpace(b.Text); t != "" {
+				parts = append(parts, r.redact(t))
+				user = e.Type == "user"
+			}
+		case "tool_use":
+			parts = append(parts, r.toolCall(b))
+		case "tool_result":
+			parts = append(parts, r.toolResult(b))
+		}
+	}
+
+	return user, parts
+}
+
+func (r renderer) toolCall(b block) string {
+	summary := r.toolSummary(b.Input)
+	if r.markdown {
+		return "**" + b.Name + "** " + codeSpan(summary)
+	}
+
+	return "[" + b.Name + "] " + summary
+}
+
+// toolSummary picks the most telling input field, else the compact JSON.
+func (r renderer) toolSummary(input json.RawMessage) string {
+	var fields map[string]any
+	if json.Unmarshal(input, &fields) == nil {
+		for _, key := range []string{"command", "file_path", "path", "pattern", "url", "query", "description"} {
+			if s, ok := fields[key].(string); ok && s != "" {
+				return truncate(oneLine(r.redact(s)), maxToolInput)
+			}
+		}
+	}
+
+	return truncate(oneLine(r.redact(string(input))), maxToolInput)
+}
+
+func (r renderer) toolResult(b block) string {
+	var text string
+	if json.Unmarshal(b.Content, &text) != nil {
+		var blocks []block
+		if json.Unmarshal(b.Content, &blocks) == nil {
+			var texts []string
+			for _, inner := range blocks {
+				if inner.Type == "text" {
+					texts = append(texts, inner.Text)
+				}
+			}
+			text = strings.Join(texts, "\n")
+		}
+	}
+	text = truncate(strings.TrimSpace(r.redact(text)), maxToolResult)
+
+	label := "Result"
+	if b.IsError {
+		label = "Error"
+	}
+	if r.markdown {
+		fence := codeFence(text)
+
+		return label + ":\n" + fence + "\n" + text + "\n" + fence
+	}
+
+	return label + ":\n" + indent(text)
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// truncate cuts s to at most limit bytes on a rune boundary, ending in an
+// ellipsis when there is room for one.
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	suffix := "…"
+	cut := limit - len(suffix)
+	if cut < 0 {
+		cut, suffix = max(limit, 0), ""
+	}
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+
+	return s[:cut] + suffix
+}
+
+func indent(s string) string {
+	return "    " + strings.ReplaceAll(s, "\n", "\n    ")
+}
+
+// codeSpan wraps s in enough backticks that backticks inside s do not end
+// the span early.
+func codeSpan(s string) string {
+	ticks := strings.Repeat("`", longestRun(s, '`')+1)
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		return ticks + " " + s + " " + ticks
+	}
+
+	return ticks + s + ticks
+}
+
+func codeFence(s string) string {
+	return strings.Repeat("`", max(3, longestRun(s, '`')+1))
+}
+
+func longestRun(s string, c byte) int {
+	longest, run := 0, 0
+	for i := range len(s) {
+		if s[i] == c {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+
+	return longest
+}
+
+// capBytes keeps at most the last limit bytes of s, starting at a line
+// boundary.
+func capBytes(s string, limit int) string {
+	if limit <= 0 || len(s) <= limit {
+		return s
+	}
+	keep := limit - len(truncatedNote)
+	if keep <= 0 {
+		return truncate(truncatedNote, limit)
+	}
+	tail := s[len(s)-keep:]
+	if i := strings.IndexByte(tail, '\n'); i >= 0 && i < len(tail)-1 {
+		tail = tail[i+1:]
+	}
+	for len(tail) > 0 && !utf8.RuneStart(tail[0]) {
+		tail = tail[1:]
+	}
+
+	return truncatedNote + tail
+}
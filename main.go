@@ -6,16 +6,46 @@ import (
 
 	appcli "github.com/felipeelias/claude-notifier/internal/cli"
 	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/plugins/auto"
+	"github.com/felipeelias/claude-notifier/plugins/aws"
+	"github.com/felipeelias/claude-notifier/plugins/bus"
+	"github.com/felipeelias/claude-notifier/plugins/github"
+	"github.com/felipeelias/claude-notifier/plugins/hue"
+	"github.com/felipeelias/claude-notifier/plugins/irc"
+	"github.com/felipeelias/claude-notifier/plugins/kdeconnect"
+	"github.com/felipeelias/claude-notifier/plugins/loki"
+	"github.com/felipeelias/claude-notifier/plugins/metrics"
+	"github.com/felipeelias/claude-notifier/plugins/nextcloudtalk"
 	"github.com/felipeelias/claude-notifier/plugins/ntfy"
+	"github.com/felipeelias/claude-notifier/plugins/signedbot"
+	"github.com/felipeelias/claude-notifier/plugins/speech"
 	"github.com/felipeelias/claude-notifier/plugins/terminalnotifier"
+	"github.com/felipeelias/claude-notifier/plugins/twilio"
+	"github.com/felipeelias/claude-notifier/plugins/webpush"
+	"github.com/felipeelias/claude-notifier/plugins/xmpp"
 )
 
 var version = "dev"
 
 func main() {
 	reg := notifier.NewRegistry()
+	auto.Register(reg)
+	aws.Register(reg)
+	bus.Register(reg)
+	github.Register(reg)
+	hue.Register(reg)
+	irc.Register(reg)
+	kdeconnect.Register(reg)
+	loki.Register(reg)
+	metrics.Register(reg)
+	nextcloudtalk.Register(reg)
 	ntfy.Register(reg)
+	signedbot.Register(reg)
+	speech.Register(reg)
 	terminalnotifier.Register(reg)
+	twilio.Register(reg)
+	webpush.Register(reg)
+	xmpp.Register(reg)
 
 	app := appcli.New(version, reg)
 
@@ -0,0 +1,284 @@
+package auto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/tmpl"
+)
+
+const (
+	defaultTitle   = "Claude Code ({{.Project}})"
+	defaultMessage = "{{.Message}}"
+)
+
+// defaultBackends is the detection order: native desktop notifications
+// first, then the terminal the user is attached to.
+var defaultBackends = []string{
+	backendTerminalNotifier, backendOsascript, backendDBus, backendTerminal, backendTmux,
+}
+
+// Auto picks the best available desktop notification backend at send time,
+// so one config works on macOS, Linux desktops, SSH sessions and tmux.
+type Auto struct {
+	Backends             []string          `toml:"backends"`
+	Title                string            `toml:"title"`
+	Message              string            `toml:"message"`
+	Sound                string            `toml:"sound"`
+	Icon                 string            `toml:"icon"`
+	Urgency              string            `toml:"urgency"`
+	TerminalNotifierPath string            `toml:"terminal_notifier_path"`
+	Group                string            `toml:"group"`
+	Open                 string            `toml:"open"`
+	TmuxPath             string            `toml:"tmux_path"`
+	TTY                  string            `toml:"tty"`
+	TerminalProtocol     string            `toml:"terminal_protocol"`
+	DesktopOverSSH       bool              `toml:"desktop_over_ssh"`
+	Vars                 map[string]string `toml:"vars"`
+
+	// SeveritySound and SeverityUrgency map severity levels to sounds and
+	// urgencies, overriding Sound and Urgency for the levels they list.
+	SeveritySound   map[string]string `toml:"severity_sound"`
+	SeverityUrgency map[string]string `toml:"severity_urgency"`
+}
+
+// ApplyDefaults sets sane defaults on a new Auto instance.
+func ApplyDefaults(n *Auto) {
+	n.Backends = append([]string(nil), defaultBackends...)
+	n.Title = defaultTitle
+	n.Message = defaultMessage
+	n.Urgency = "normal"
+	n.TerminalNotifierPath = "terminal-notifier"
+	n.Group = "{{.SessionID}}"
+	n.TmuxPath = "tmux"
+	n.TTY = "/dev/tty"
+	n.TerminalProtocol = protocolOSC9
+}
+
+func (n *Auto) Name() string { return "auto" }
+
+// backend is one way of showing a notification.
+type backend interface {
+	// available reports whether the backend can be used right now, and why
+	// not otherwise. It may keep resources open for send; close releases
+	// them either way.
+	available(ctx context.Context) (bool, string)
+	send(ctx context.Context, notif notifier.Notification, title, message string) error
+	close()
+}
+
+// clearer is a backend that can remove a notification it showed.
+type clearer interface {
+	clear(ctx context.Context, notif notifier.Notification) error
+}
+
+func (n *Auto) Send(ctx context.Context, notif notifier.Notification) error {
+	tctx := tmpl.BuildContext(notif, n.Vars)
+
+	titleTmpl := n.Title
+	if titleTmpl == "" {
+		titleTmpl = defaultTitle
+	}
+	title, err := tmpl.Render("title", titleTmpl, tctx)
+	if err != nil {
+		return err
+	}
+
+	msgTmpl := n.Message
+	if msgTmpl == "" {
+		msgTmpl = defaultMessage
+	}
+	message, err := tmpl.Render("message", msgTmpl, tctx)
+	if err != nil {
+		return err
+	}
+
+	name, b, skipped, err := n.choose(ctx, notif.Severity)
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		return errors.New("no notification backend available (" + strings.Join(skipped, "; ") + ")")
+	}
+	defer b.close()
+
+	slog.Info("auto: sending notification", "backend", name)
+	err = b.send(ctx, notif, title, message)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
+
+// Clear removes the session's notification through the backend Send would
+// pick, when that backend can remove notifications.
+func (n *Auto) Clear(ctx context.Context, notif notifier.Notification) error {
+	name, b, _, err := n.choose(ctx, notif.Severity)
+	if err != nil || b == nil {
+		return err
+	}
+	defer b.close()
+
+	c, ok := b.(clearer)
+	if !ok {
+		return nil
+	}
+	err = c.clear(ctx, notif)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
+
+// choose returns the first available backend, which the caller must close,
+// or nil along with the reasons each one was skipped.
+func (n *Auto) choose(ctx context.Context, severity string) (string, backend, []string, error) {
+	names := n.Backends
+	if len(names) == 0 {
+		names = defaultBackends
+	}
+
+	ssh := sshSession()
+	var skipped []string
+	for _, name := range names {
+		b, err := n.backend(name, severity)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		if ssh && isDesktop(name) && !n.DesktopOverSSH {
+			// A desktop notification would show on the remote machine.
+			skipped = append(skipped, name+": SSH session")
+
+			continue
+		}
+
+		ok, reason := b.available(ctx)
+		if !ok {
+			b.close()
+			skipped = append(skipped, name+": "+reason)
+
+			continue
+		}
+
+		return name, b, skipped, nil
+	}
+
+	return "", nil, skipped, nil
+}
+
+func (n *Auto) backend(name, severity string) (backend, error) {
+	switch name {
+	case backendTerminalNotifier:
+		return &terminalNotifierBackend{auto: n}, nil
+	case backendOsascript:
+		return &osascriptBackend{sound: notifier.ForSeverity(n.SeveritySound, severity, n.Sound)}, nil
+	case backendDBus:
+		return &dbusBackend{icon: n.Icon, urgency: notifier.ForSeverity(n.SeverityUrgency, severity, n.Urgency)}, nil
+	case backendTerminal:
+		return &terminalBackend{tty: n.TTY, protocol: n.TerminalProtocol}, nil
+	case backendTmux:
+		return &tmuxBackend{path: n.TmuxPath}, nil
+	}
+
+	return nil, fmt.Errorf("unknown backend %q (want %s)", name, strings.Join(defaultBackends, ", "))
+}
+
+func isDesktop(name string) bool {
+	return name == backendTerminalNotifier || name == backendOsascript || name == backendDBus
+}
+
+func sshSession() bool {
+	for _, env := range []string{"SSH_CONNECTION", "SSH_CLIENT", "SSH_TTY"} {
+		if os.Getenv(env) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SampleConfig returns example TOML configuration.
+func (n *Auto) SampleConfig() string {
+	return `## Desktop notifications with the best backend for where Claude runs
+## The first available backend is used, and the choice is logged:
+##   terminal-notifier  macOS, when terminal-notifier is installed
+##   osascript          macOS built-in notifications
+##   dbus               Linux/BSD desktops with a notification daemon
+##   terminal           SSH sessions: an escape sequence to the local terminal
+##   tmux               a tmux status line message
+## Desktop backends are skipped in SSH sessions, where they would show on the
+## remote machine
+[[notifiers.auto]]
+
+## Backends to try, in order
+# backends = ["terminal-notifier", "osascript", "dbus", "terminal", "tmux"]
+
+## Go templates for the title and message
+## Available variables: {{.Message}}, {{.Title}}, {{.Project}}, {{.Cwd}},
+## {{.NotificationType}}, {{.SessionID}}, {{.TranscriptPath}}, {{.Severity}}
+## Custom variables from [notifiers.auto.vars] are also available, title-cased
+# title = "Claude Code ({{.Project}})"
+# message = "{{.Message}}"
+
+## macOS sound name ("default" for the system default)
+# sound = ""
+
+## D-Bus: icon name or path, and urgency (low, normal or critical)
+# icon = ""
+# urgency = "normal"
+
+## Sound and urgency per severity level (low, normal, high, critical),
+## overriding sound and urgency for the levels listed; severity comes from
+## [global.severity]
+# severity_sound = { high = "Ping", critical = "Sosumi" }
+# severity_urgency = { low = "low", critical = "critical" }
+
+## Path to the terminal-notifier binary
+# terminal_notifier_path = "terminal-notifier"
+
+## terminal-notifier: group ID, where a new notification replaces the previous
+## one in the group and the group is removed when the session resumes; and a
+## URL to open when clicking the notification (not templated)
+# group = "{{.SessionID}}"
+# open = ""
+
+## Path to the tmux binary
+# tmux_path = "tmux"
+
+## Terminal written to by the terminal backend
+# tty = "/dev/tty"
+
+## Terminal backend escape sequence: "osc9" (iTerm2, WezTerm, Windows
+## Terminal, Ghostty), "osc777" (foot, rxvt, Konsole, WezTerm) or "bell"
+## Inside tmux, set "allow-passthrough on" for the sequence to reach the terminal
+# terminal_protocol = "osc9"
+
+## Use desktop backends in SSH sessions too (e.g. with X11 forwarding)
+# desktop_over_ssh = false
+
+## User-defined template variables
+## Keys are title-cased for template access (env -> {{.Env}})
+# [notifiers.auto.vars]
+# env = "production"
+`
+}
+
+// Register adds auto to the given plugin registry.
+func Register(reg *notifier.Registry) {
+	err := reg.Register("auto", func() notifier.Notifier {
+		n := &Auto{}
+		ApplyDefaults(n)
+
+		return n
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,286 @@
+package auto_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/felipeelias/claude-notifier/internal/dbus"
+	"github.com/felipeelias/claude-notifier/internal/dbus/dbustest"
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/plugins/auto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var notif = notifier.Notification{
+	Message: "Claude needs permission",
+	Cwd:     "/home/user/billing-api",
+}
+
+// isolate clears everything detection looks at: no bus, no SSH, no tmux.
+func isolate(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "darwin" {
+		t.Skip("detection prefers the macOS backends")
+	}
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "unix:path="+filepath.Join(t.TempDir(), "nobus"))
+	for _, env := range []string{"SSH_CONNECTION", "SSH_CLIENT", "SSH_TTY", "TMUX"} {
+		t.Setenv(env, "")
+	}
+}
+
+// fakeDaemon starts a bus where org.freedesktop.Notifications is running.
+func fakeDaemon(t *testing.T) *dbustest.Server {
+	t.Helper()
+	srv := dbustest.NewServer(t, func(call *dbus.Message) ([]any, *dbus.Error) {
+		switch call.Member {
+		case "NameHasOwner":
+			return []any{call.Body[0] == "org.freedesktop.Notifications"}, nil
+		case "Notify":
+			return []any{uint32(7)}, nil
+		}
+
+		return nil, &dbus.Error{Name: "org.freedesktop.DBus.Error.UnknownMethod"}
+	})
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", srv.Address)
+
+	return srv
+}
+
+// fakeTmux creates a tmux stand-in that logs its args.
+func fakeTmux(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "args.log")
+	script := filepath.Join(dir, "tmux")
+	content := fmt.Sprintf("#!/bin/sh\nprintf '%%s\\n' \"$@\" >> %s\n", logFile)
+	require.NoError(t, os.WriteFile(script, []byte(content), 0755))
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1234,0")
+
+	return script, logFile
+}
+
+func newAuto() *auto.Auto {
+	p := &auto.Auto{}
+	auto.ApplyDefaults(p)
+
+	return p
+}
+
+func TestAutoName(t *testing.T) {
+	p := &auto.Auto{}
+	assert.Equal(t, "auto", p.Name())
+}
+
+func TestAutoDefaults(t *testing.T) {
+	p := newAuto()
+	assert.Equal(t, []string{"terminal-notifier", "osascript", "dbus", "terminal", "tmux"}, p.Backends)
+	assert.Equal(t, "Claude Code ({{.Project}})", p.Title)
+	assert.Equal(t, "{{.Message}}", p.Message)
+	assert.Equal(t, "normal", p.Urgency)
+	assert.Equal(t, "/dev/tty", p.TTY)
+	assert.Equal(t, "osc9", p.TerminalProtocol)
+	assert.Equal(t, "{{.SessionID}}", p.Group)
+	assert.False(t, p.DesktopOverSSH)
+}
+
+func TestAutoImplementsNotifier(t *testing.T) {
+	var _ notifier.Notifier = &auto.Auto{}
+	var _ notifier.Clearer = &auto.Auto{}
+}
+
+func TestAutoPicksDBusDaemon(t *testing.T) {
+	isolate(t)
+	srv := fakeDaemon(t)
+
+	p := newAuto()
+	p.Urgency = "critical"
+	require.NoError(t, p.Send(context.Background(), notif))
+
+	var notify *dbus.Message
+	for _, c := range srv.Calls() {
+		if c.Member == "Notify" {
+			notify = c
+		}
+	}
+	require.NotNil(t, notify)
+	assert.Equal(t, "org.freedesktop.Notifications", notify.Destination)
+	assert.Equal(t, dbus.ObjectPath("/org/freedesktop/Notifications"), notify.Path)
+	require.Len(t, notify.Body, 8)
+	assert.Equal(t, "claude-notifier", notify.Body[0])
+	assert.Equal(t, "Claude Code (billing-api)", notify.Body[3])
+	assert.Equal(t, "Claude needs permission", notify.Body[4])
+	assert.Equal(t, map[any]any{"urgency": dbus.Variant{Value: byte(2)}}, notify.Body[6])
+	assert.Equal(t, int32(-1), notify.Body[7])
+}
+
+func TestAutoFallsBackToTmux(t *testing.T) {
+	isolate(t)
+	// The bus is up but no notification daemon owns the name.
+	srv := dbustest.NewServer(t, func(*dbus.Message) ([]any, *dbus.Error) { return []any{false}, nil })
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", srv.Address)
+	script, logFile := fakeTmux(t)
+
+	p := newAuto()
+	p.TmuxPath = script
+	p.Message = "{{.Message}} #1"
+	require.NoError(t, p.Send(context.Background(), notif))
+
+	data, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"display-message", "--", "Claude Code (billing-api): Claude needs permission ##1"},
+		strings.Split(strings.TrimSpace(string(data)), "\n"))
+}
+
+func TestAutoSSHUsesTerminal(t *testing.T) {
+	isolate(t)
+	srv := fakeDaemon(t)
+	t.Setenv("SSH_CONNECTION", "10.0.0.2 52000 10.0.0.1 22")
+	tty := filepath.Join(t.TempDir(), "tty")
+	require.NoError(t, os.WriteFile(tty, nil, 0600))
+
+	p := newAuto()
+	p.TTY = tty
+	p.Message = "{{.Message}}\x1b]0;pwned\x07"
+	require.NoError(t, p.Send(context.Background(), notif))
+
+	data, err := os.ReadFile(tty)
+	require.NoError(t, err)
+	assert.Equal(t, "\x1b]9;Claude Code (billing-api): Claude needs permission]0;pwned\x07", string(data))
+	for _, c := range srv.Calls() {
+		assert.NotEqual(t, "Notify", c.Member, "desktop notifications are skipped over SSH")
+	}
+}
+
+func TestAutoTerminalOSC777InsideTmux(t *testing.T) {
+	isolate(t)
+	t.Setenv("SSH_TTY", "/dev/pts/3")
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1234,0")
+	tty := filepath.Join(t.TempDir(), "tty")
+	require.NoError(t, os.WriteFile(tty, nil, 0600))
+
+	p := newAuto()
+	p.TTY = tty
+	p.TerminalProtocol = "osc777"
+	p.Title = "a;b"
+	require.NoError(t, p.Send(context.Background(), notif))
+
+	data, err := os.ReadFile(tty)
+	require.NoError(t, err)
+	assert.Equal(t, "\x1bPtmux;\x1b\x1b]777;notify;a,b;Claude needs permission\x1b\x1b\\\x1b\\", string(data))
+}
+
+func TestAutoDesktopOverSSH(t *testing.T) {
+	isolate(t)
+	srv := fakeDaemon(t)
+	t.Setenv("SSH_CLIENT", "10.0.0.2 52000 22")
+
+	p := newAuto()
+	p.DesktopOverSSH = true
+	require.NoError(t, p.Send(context.Background(), notif))
+
+	var members []string
+	for _, c := range srv.Calls() {
+		members = append(members, c.Member)
+	}
+	assert.Contains(t, members, "Notify")
+}
+
+func TestAutoNothingAvailable(t *testing.T) {
+	isolate(t)
+
+	p := newAuto()
+	err := p.Send(context.Background(), notif)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no notification backend available")
+	assert.Contains(t, err.Error(), "dbus: no session bus")
+	assert.Contains(t, err.Error(), "terminal: not an SSH session")
+	assert.Contains(t, err.Error(), "tmux: not inside tmux")
+}
+
+func TestAutoValidation(t *testing.T) {
+	isolate(t)
+	fakeDaemon(t)
+
+	tests := []struct {
+		name string
+		p    auto.Auto
+		want string
+	}{
+		{"unknown backend", auto.Auto{Backends: []string{"growl"}}, `unknown backend "growl"`},
+		{"bad urgency", auto.Auto{Backends: []string{"dbus"}, Urgency: "urgent"}, "unknown urgency"},
+		{"bad template", auto.Auto{Message: "{{.Invalid"}, "rendering message template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.p.Send(context.Background(), notif)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestAutoSeverityUrgency(t *testing.T) {
+	isolate(t)
+	srv := fakeDaemon(t)
+
+	p := newAuto()
+	p.SeverityUrgency = map[string]string{"high": "critical"}
+	critical := notif
+	critical.Severity = "high"
+	require.NoError(t, p.Send(context.Background(), critical))
+
+	var notify *dbus.Message
+	for _, c := range srv.Calls() {
+		if c.Member == "Notify" {
+			notify = c
+		}
+	}
+	require.NotNil(t, notify)
+	assert.Equal(t, map[any]any{"urgency": dbus.Variant{Value: byte(2)}}, notify.Body[6])
+}
+
+func TestAutoClearWithoutClearingBackend(t *testing.T) {
+	isolate(t)
+	script, logFile := fakeTmux(t)
+
+	p := newAuto()
+	p.TmuxPath = script
+	require.NoError(t, p.Clear(context.Background(), notif))
+	assert.NoFileExists(t, logFile, "tmux messages can't be removed")
+}
+
+func TestAutoTerminalNotifierGroupAndClear(t *testing.T) {
+	if runtime.GOOS != "darwin" {
+		t.Skip("terminal-notifier is only used on macOS")
+	}
+	for _, env := range []string{"SSH_CONNECTION", "SSH_CLIENT", "SSH_TTY"} {
+		t.Setenv(env, "")
+	}
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "args.log")
+	script := filepath.Join(dir, "terminal-notifier")
+	content := fmt.Sprintf("#!/bin/sh\nprintf '%%s\\n' \"$@\" >> %s\n", logFile)
+	require.NoError(t, os.WriteFile(script, []byte(content), 0755))
+
+	p := newAuto()
+	p.TerminalNotifierPath = script
+	p.Group = "claude-{{.SessionID}}"
+	p.Open = "https://example.com"
+	n := notif
+	n.SessionID = "abc"
+	require.NoError(t, p.Send(context.Background(), n))
+	require.NoError(t, p.Clear(context.Background(), n))
+
+	data, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	args := string(data)
+	assert.Contains(t, args, "-group\nclaude-abc\n")
+	assert.Contains(t, args, "-open\nhttps://example.com\n")
+	assert.Contains(t, args, "-remove\nclaude-abc\n")
+}
@@ -0,0 +1,282 @@
+package auto
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/felipeelias/claude-notifier/internal/dbus"
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/plugins/terminalnotifier"
+)
+
+const (
+	backendTerminalNotifier = "terminal-notifier"
+	backendOsascript        = "osascript"
+	backendDBus             = "dbus"
+	backendTerminal         = "terminal"
+	backendTmux             = "tmux"
+
+	protocolOSC9   = "osc9"
+	protocolOSC777 = "osc777"
+	protocolBell   = "bell"
+
+	notificationsName  = "org.freedesktop.Notifications"
+	notificationsPath  = "/org/freedesktop/Notifications"
+	defaultExpireTimer = int32(-1)
+)
+
+// terminalNotifierBackend delegates to the terminal-notifier plugin.
+type terminalNotifierBackend struct {
+	auto *Auto
+}
+
+func (b *terminalNotifierBackend) available(context.Context) (bool, string) {
+	if runtime.GOOS != "darwin" {
+		return false, "not macOS"
+	}
+	_, err := exec.LookPath(b.path())
+	if err != nil {
+		return false, "terminal-notifier not installed"
+	}
+
+	return true, ""
+}
+
+func (b *terminalNotifierBackend) path() string {
+	if b.auto.TerminalNotifierPath == "" {
+		return "terminal-notifier"
+	}
+
+	return b.auto.TerminalNotifierPath
+}
+
+func (b *terminalNotifierBackend) send(ctx context.Context, notif notifier.Notification, _, _ string) error {
+	return b.delegate().Send(ctx, notif)
+}
+
+func (b *terminalNotifierBackend) clear(ctx context.Context, notif notifier.Notification) error {
+	return b.delegate().Clear(ctx, notif)
+}
+
+// delegate returns the terminal-notifier plugin configured from auto.
+func (b *terminalNotifierBackend) delegate() *terminalnotifier.TerminalNotifier {
+	tn := &terminalnotifier.TerminalNotifier{}
+	terminalnotifier.ApplyDefaults(tn)
+	tn.Path = b.path()
+	tn.Title = b.auto.Title
+	tn.Message = b.auto.Message
+	tn.Sound = b.auto.Sound
+	tn.SeveritySound = b.auto.SeveritySound
+	tn.Vars = b.auto.Vars
+	if b.auto.Group != "" {
+		tn.Group = b.auto.Group
+	}
+	tn.Open = b.auto.Open
+
+	return tn
+}
+
+func (b *terminalNotifierBackend) close() {}
+
+// osascriptBackend shows a notification through AppleScript. The text is
+// passed as script arguments, never spliced into the script.
+type osascriptBackend struct {
+	sound string
+}
+
+func (b *osascriptBackend) available(context.Context) (bool, string) {
+	if runtime.GOOS != "darwin" {
+		return false, "not macOS"
+	}
+	_, err := exec.LookPath("osascript")
+	if err != nil {
+		return false, "osascript not found"
+	}
+
+	return true, ""
+}
+
+func (b *osascriptBackend) send(ctx context.Context, _ notifier.Notification, title, message string) error {
+	script := "display notification (item 2 of argv) with title (item 3 of argv)"
+	if b.sound != "" {
+		script += " sound name (item 4 of argv)"
+	}
+	// The first argument is a fixed word so that a message starting with
+	// "-" is not taken for an option.
+	args := []string{"-e", "on run argv", "-e", script, "-e", "end run", "claude-notifier", message, title}
+	if b.sound != "" {
+		args = append(args, b.sound)
+	}
+
+	output, err := exec.CommandContext(ctx, "osascript", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("running osascript: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+
+	return nil
+}
+
+func (b *osascriptBackend) close() {}
+
+// dbusBackend calls org.freedesktop.Notifications.Notify on the session bus.
+type dbusBackend struct {
+	icon    string
+	urgency string
+	conn    *dbus.Conn
+}
+
+func (b *dbusBackend) available(ctx context.Context) (bool, string) {
+	conn, err := dbus.SessionBus(ctx)
+	if err != nil {
+		return false, "no session bus"
+	}
+	b.conn = conn
+
+	running, err := conn.NameHasOwner(ctx, notificationsName)
+	if err != nil || !running {
+		return false, "no notification daemon"
+	}
+
+	return true, ""
+}
+
+func (b *dbusBackend) send(ctx context.Context, _ notifier.Notification, title, message string) error {
+	urgency := byte(1)
+	switch b.urgency {
+	case "low":
+		urgency = 0
+	case "critical":
+		urgency = 2
+	case "normal", "":
+	default:
+		return fmt.Errorf("unknown urgency %q (want low, normal or critical)", b.urgency)
+	}
+
+	hints := map[string]dbus.Variant{"urgency": {Value: urgency}}
+	_, err := b.conn.Call(ctx, notificationsName, notificationsPath, notificationsName, "Notify",
+		"claude-notifier", uint32(0), b.icon, title, message, []string{}, hints, defaultExpireTimer)
+
+	return err
+}
+
+func (b *dbusBackend) close() {
+	if b.conn != nil {
+		_ = b.conn.Close()
+	}
+}
+
+// terminalBackend writes a notification escape sequence to the terminal,
+// which reaches the user's local terminal emulator through SSH.
+type terminalBackend struct {
+	tty      string
+	protocol string
+	file     *os.File
+}
+
+func (b *terminalBackend) available(context.Context) (bool, string) {
+	if !sshSession() {
+		return false, "not an SSH session"
+	}
+	tty := b.tty
+	if tty == "" {
+		tty = "/dev/tty"
+	}
+	file, err := os.OpenFile(tty, os.O_WRONLY, 0)
+	if err != nil {
+		return false, "no terminal"
+	}
+	b.file = file
+
+	return true, ""
+}
+
+func (b *terminalBackend) send(_ context.Context, _ notifier.Notification, title, message string) error {
+	title, message = sanitize(title), sanitize(message)
+
+	var seq string
+	switch b.protocol {
+	case protocolOSC9, "":
+		seq = "\x1b]9;" + title + ": " + message + "\x07"
+	case protocolOSC777:
+		seq = "\x1b]777;notify;" + strings.ReplaceAll(title, ";", ",") + ";" + message + "\x1b\\"
+	case protocolBell:
+		seq = "\x07"
+	default:
+		return fmt.Errorf("unknown terminal_protocol %q (want osc9, osc777 or bell)", b.protocol)
+	}
+
+	// tmux swallows unknown sequences unless they are wrapped for passthrough.
+	if os.Getenv("TMUX") != "" && seq != "\x07" {
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+
+	_, err := b.file.WriteString(seq)
+	if err != nil {
+		return fmt.Errorf("writing to terminal: %w", err)
+	}
+
+	return nil
+}
+
+func (b *terminalBackend) close() {
+	if b.file != nil {
+		_ = b.file.Close()
+	}
+}
+
+// sanitize drops control characters so notification text cannot end the
+// escape sequence early or inject its own.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return ' '
+		case r < ' ' || r == 0x7f || (r >= 0x80 && r < 0xa0):
+			return -1
+		}
+
+		return r
+	}, s)
+}
+
+// tmuxBackend shows the notification in the tmux status line.
+type tmuxBackend struct {
+	path string
+}
+
+func (b *tmuxBackend) available(context.Context) (bool, string) {
+	if os.Getenv("TMUX") == "" {
+		return false, "not inside tmux"
+	}
+	_, err := exec.LookPath(b.binary())
+	if err != nil {
+		return false, "tmux not found"
+	}
+
+	return true, ""
+}
+
+func (b *tmuxBackend) binary() string {
+	if b.path == "" {
+		return "tmux"
+	}
+
+	return b.path
+}
+
+func (b *tmuxBackend) send(ctx context.Context, _ notifier.Notification, title, message string) error {
+	// display-message expands formats; "##" is a literal "#".
+	text := strings.ReplaceAll(sanitize(title+": "+message), "#", "##")
+	output, err := exec.CommandContext(ctx, b.binary(), "display-message", "--", text).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("running %s: %s: %w", b.binary(), strings.TrimSpace(string(output)), err)
+	}
+
+	return nil
+}
+
+func (b *tmuxBackend) close() {}
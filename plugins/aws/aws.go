@@ -0,0 +1,483 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/sigv4"
+	"github.com/felipeelias/claude-notifier/internal/tmpl"
+)
+
+const (
+	httpTimeout     = 30 * time.Second
+	httpErrorStatus = 400
+	maxErrorBody    = 4096
+	maxAttributes   = 10
+	maxSubjectLen   = 100
+
+	serviceSNS = "sns"
+	serviceSQS = "sqs"
+
+	formatText = "text"
+	formatJSON = "json"
+
+	defaultSubject = "Claude Code: {{.Project}}"
+	defaultMessage = "{{.Message}}"
+	defaultGroupID = "{{.SessionID}}"
+	fallbackGroup  = "claude-notifier"
+)
+
+var httpClient = &http.Client{
+	Timeout: httpTimeout,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// AWS publishes notifications to an SNS topic or sends them to an SQS queue.
+type AWS struct {
+	Service         string            `toml:"service"`
+	TopicARN        string            `toml:"topic_arn"`
+	QueueURL        string            `toml:"queue_url"`
+	Region          string            `toml:"region"`
+	Endpoint        string            `toml:"endpoint"`
+	AccessKeyID     string            `toml:"access_key_id"`
+	SecretAccessKey string            `toml:"secret_access_key"`
+	SessionToken    string            `toml:"session_token"`
+	Profile         string            `toml:"profile"`
+	Format          string            `toml:"format"`
+	Subject         string            `toml:"subject"`
+	Message         string            `toml:"message"`
+	GroupID         string            `toml:"group_id"`
+	AllowRepeats    bool              `toml:"allow_repeats"`
+	Attributes      map[string]string `toml:"attributes"`
+	Vars            map[string]string `toml:"vars"`
+}
+
+// ApplyDefaults sets sane defaults on a new AWS instance.
+func ApplyDefaults(n *AWS) {
+	n.Service = serviceSNS
+	n.Format = formatText
+	n.Subject = defaultSubject
+	n.Message = defaultMessage
+	n.GroupID = defaultGroupID
+}
+
+func (n *AWS) Name() string { return "aws" }
+
+// jsonMessage is the message body in json format.
+type jsonMessage struct {
+	Message          string `json:"message"`
+	Title            string `json:"title,omitempty"`
+	Project          string `json:"project"`
+	Cwd              string `json:"cwd"`
+	NotificationType string `json:"notification_type"`
+	SessionID        string `json:"session_id"`
+	TranscriptPath   string `json:"transcript_path,omitempty"`
+}
+
+func (n *AWS) Send(ctx context.Context, notif notifier.Notification) error {
+	service, target, err := n.target()
+	if err != nil {
+		return err
+	}
+
+	tctx := tmpl.BuildContext(notif, n.Vars)
+
+	msgTmpl := n.Message
+	if msgTmpl == "" {
+		msgTmpl = defaultMessage
+	}
+	message, err := tmpl.Render("message", msgTmpl, tctx)
+	if err != nil {
+		return err
+	}
+
+	switch n.Format {
+	case formatText, "":
+	case formatJSON:
+		data, err := json.Marshal(jsonMessage{
+			Message:          message,
+			Title:            notif.Title,
+			Project:          notif.Project(),
+			Cwd:              notif.Cwd,
+			NotificationType: notif.NotificationType,
+			SessionID:        notif.SessionID,
+			TranscriptPath:   notif.TranscriptPath,
+		})
+		if err != nil {
+			return fmt.Errorf("encoding message: %w", err)
+		}
+		message = string(data)
+	default:
+		return fmt.Errorf("unknown format %q (want text or json)", n.Format)
+	}
+
+	attrs, err := n.attributes(notif, tctx)
+	if err != nil {
+		return err
+	}
+
+	form := url.Values{}
+	endpoint := n.Endpoint
+	switch service {
+	case serviceSNS:
+		form.Set("Action", "Publish")
+		form.Set("Version", "2010-03-31")
+		form.Set("TopicArn", target)
+		form.Set("Message", message)
+		subject, err := n.subject(tctx)
+		if err != nil {
+			return err
+		}
+		if subject != "" {
+			form.Set("Subject", subject)
+		}
+		setAttributes(form, "MessageAttributes.entry", attrs)
+	case serviceSQS:
+		form.Set("Action", "SendMessage")
+		form.Set("Version", "2012-11-05")
+		form.Set("QueueUrl", target)
+		form.Set("MessageBody", message)
+		setAttributes(form, "MessageAttribute", attrs)
+		if endpoint == "" {
+			endpoint = target
+		}
+	}
+
+	if strings.HasSuffix(target, ".fifo") {
+		groupID, err := n.groupID(tctx)
+		if err != nil {
+			return err
+		}
+		form.Set("MessageGroupId", groupID)
+		form.Set("MessageDeduplicationId", n.deduplicationID(notif, message))
+	}
+
+	region := n.region(service, target)
+	if region == "" {
+		return errors.New("region is required (set region, AWS_REGION or a profile region)")
+	}
+	if endpoint == "" {
+		endpoint = "https://sns." + region + ".amazonaws.com/"
+	}
+
+	creds, err := n.credentials()
+	if err != nil {
+		return err
+	}
+
+	return post(ctx, endpoint, form, creds, region, service)
+}
+
+// target returns the service and the topic ARN or queue URL to send to.
+func (n *AWS) target() (string, string, error) {
+	switch n.Service {
+	case serviceSNS, "":
+		if n.TopicARN == "" {
+			return "", "", errors.New("topic_arn is required for sns")
+		}
+
+		return serviceSNS, n.TopicARN, nil
+	case serviceSQS:
+		if n.QueueURL == "" {
+			return "", "", errors.New("queue_url is required for sqs")
+		}
+
+		return serviceSQS, n.QueueURL, nil
+	}
+
+	return "", "", fmt.Errorf("unknown service %q (want sns or sqs)", n.Service)
+}
+
+// region returns the configured region, else the one in the topic ARN
+// (arn:aws:sns:<region>:...) or queue host (sqs.<region>.amazonaws.com),
+// else the environment and shared config.
+func (n *AWS) region(service, target string) string {
+	if n.Region != "" {
+		return n.Region
+	}
+	switch service {
+	case serviceSNS:
+		parts := strings.Split(target, ":")
+		if len(parts) >= 6 && parts[3] != "" {
+			return parts[3]
+		}
+	case serviceSQS:
+		u, err := url.Parse(target)
+		if err == nil {
+			parts := strings.Split(u.Hostname(), ".")
+			if len(parts) >= 4 && parts[0] == serviceSQS && strings.HasSuffix(u.Hostname(), ".amazonaws.com") {
+				return parts[1]
+			}
+		}
+	}
+
+	return sigv4.LoadRegion(n.Profile)
+}
+
+// credentials uses the configured keys, falling back to the standard
+// environment and shared credentials chain.
+func (n *AWS) credentials() (sigv4.Credentials, error) {
+	if n.AccessKeyID != "" || n.SecretAccessKey != "" {
+		if n.AccessKeyID == "" || n.SecretAccessKey == "" {
+			return sigv4.Credentials{}, errors.New("access_key_id and secret_access_key must be set together")
+		}
+
+		return sigv4.Credentials{
+			AccessKeyID:     n.AccessKeyID,
+			SecretAccessKey: n.SecretAccessKey,
+			SessionToken:    n.SessionToken,
+		}, nil
+	}
+
+	creds, err := sigv4.LoadCredentials(n.Profile)
+	if err != nil {
+		return sigv4.Credentials{}, fmt.Errorf("loading credentials: %w", err)
+	}
+
+	return creds, nil
+}
+
+// subject renders the SNS subject. SNS only accepts a single line of
+// printable ASCII up to 100 characters, so anything else is replaced.
+func (n *AWS) subject(tctx map[string]string) (string, error) {
+	if n.Subject == "" {
+		return "", nil
+	}
+	subject, err := tmpl.Render("subject", n.Subject, tctx)
+	if err != nil {
+		return "", err
+	}
+
+	var buf strings.Builder
+	for _, r := range strings.Join(strings.Fields(subject), " ") {
+		if r < ' ' || r > '~' {
+			r = '?'
+		}
+		buf.WriteRune(r)
+	}
+	subject = buf.String()
+	if len(subject) > maxSubjectLen {
+		subject = subject[:maxSubjectLen]
+	}
+
+	return subject, nil
+}
+
+func (n *AWS) groupID(tctx map[string]string) (string, error) {
+	groupTmpl := n.GroupID
+	if groupTmpl == "" {
+		groupTmpl = defaultGroupID
+	}
+	groupID, err := tmpl.Render("group_id", groupTmpl, tctx)
+	if err != nil {
+		return "", err
+	}
+	if groupID == "" {
+		return fallbackGroup, nil
+	}
+
+	return groupID, nil
+}
+
+// deduplicationID derives the FIFO deduplication ID from the session, type
+// and message, so AWS drops repeats (and retries) of a notification within
+// its five minute deduplication interval. With allow_repeats, every send
+// gets its own ID instead.
+func (n *AWS) deduplicationID(notif notifier.Notification, message string) string {
+	key := notif.SessionID + "\x00" + notif.NotificationType + "\x00" + message
+	if n.AllowRepeats {
+		key += "\x00" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	}
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// attributes returns the message attributes: the notification fields plus
+// user-defined templated ones. Empty values are left out since AWS rejects
+// them.
+func (n *AWS) attributes(notif notifier.Notification, tctx map[string]string) (map[string]string, error) {
+	attrs := map[string]string{}
+	for name, value := range map[string]string{
+		"notification_type": notif.NotificationType,
+		"project":           notif.Project(),
+		"session_id":        notif.SessionID,
+		"cwd":               notif.Cwd,
+	} {
+		if value != "" {
+			attrs[name] = value
+		}
+	}
+
+	for name, value := range n.Attributes {
+		switch name {
+		case "notification_type", "project", "session_id", "cwd":
+			return nil, fmt.Errorf("attribute %q conflicts with a built-in attribute", name)
+		}
+		rendered, err := tmpl.Render("attribute "+name, value, tctx)
+		if err != nil {
+			return nil, err
+		}
+		if rendered != "" {
+			attrs[name] = rendered
+		}
+	}
+
+	if len(attrs) > maxAttributes {
+		return nil, fmt.Errorf("too many message attributes (%d > %d)", len(attrs), maxAttributes)
+	}
+
+	return attrs, nil
+}
+
+func setAttributes(form url.Values, prefix string, attrs map[string]string) {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		key := prefix + "." + strconv.Itoa(i+1)
+		form.Set(key+".Name", name)
+		form.Set(key+".Value.DataType", "String")
+		form.Set(key+".Value.StringValue", attrs[name])
+	}
+}
+
+// errorResponse is the XML error body of the SNS and SQS query APIs.
+type errorResponse struct {
+	Error struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	} `xml:"Error"`
+}
+
+func post(ctx context.Context, endpoint string, form url.Values, creds sigv4.Credentials, region, service string) error {
+	body := []byte(form.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	err = sigv4.Sign(req, body, creds, region, service, time.Now())
+	if err != nil {
+		return fmt.Errorf("signing request: %w", err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode >= httpErrorStatus {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		var apiErr errorResponse
+		if xml.Unmarshal(data, &apiErr) == nil && apiErr.Error.Code != "" {
+			return fmt.Errorf("server returned %s: %s: %s", resp.Status, apiErr.Error.Code, apiErr.Error.Message)
+		}
+
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	return nil
+}
+
+// SampleConfig returns example TOML configuration.
+func (n *AWS) SampleConfig() string {
+	return `## Amazon SNS topics or SQS queues (SigV4-signed, no SDK required)
+## https://docs.aws.amazon.com/sns/latest/api/API_Publish.html
+## https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_SendMessage.html
+[[notifiers.aws]]
+
+## Service: "sns" (publish to a topic) or "sqs" (send to a queue)
+# service = "sns"
+
+## Topic ARN for sns, queue URL for sqs
+## Names ending in .fifo get a message group ID and a deduplication ID
+topic_arn = "arn:aws:sns:us-east-1:123456789012:claude-notifications"
+# queue_url = "https://sqs.us-east-1.amazonaws.com/123456789012/claude-notifications"
+
+## Region; defaults to the one in the topic ARN or queue URL, then
+## AWS_REGION, AWS_DEFAULT_REGION and the profile in ~/.aws/config
+# region = "us-east-1"
+
+## Endpoint override, e.g. LocalStack or ElasticMQ
+# endpoint = "http://localhost:4566"
+
+## Static credentials; when unset, AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY
+## (and AWS_SESSION_TOKEN) are used, then the profile in ~/.aws/credentials
+# access_key_id = ""
+# secret_access_key = ""
+# session_token = ""
+
+## Shared credentials/config profile (defaults to AWS_PROFILE, then "default")
+# profile = ""
+
+## Message format: "text" sends the rendered message; "json" sends an object
+## with message (rendered), title, project, cwd, notification_type,
+## session_id and transcript_path
+# format = "text"
+
+## Go templates for the SNS subject (single line of ASCII, max 100 chars)
+## and the message
+## Available variables: {{.Message}}, {{.Title}}, {{.Project}}, {{.Cwd}},
+## {{.NotificationType}}, {{.SessionID}}, {{.TranscriptPath}}, {{.Severity}}
+## Custom variables from [notifiers.aws.vars] are also available, title-cased
+# subject = "Claude Code: {{.Project}}"
+# message = "{{.Message}}"
+
+## Go template for the FIFO message group ID; one group per Claude session
+## keeps each session's notifications in order
+# group_id = "{{.SessionID}}"
+
+## FIFO deduplication IDs come from the session, type and message, so AWS
+## drops identical notifications sent within five minutes; set to true to
+## deliver every one
+# allow_repeats = false
+
+## Extra message attributes (Go templates). The attributes notification_type,
+## project, session_id and cwd are always set; at most 10 in total
+# [notifiers.aws.attributes]
+# host = "laptop"
+
+## User-defined template variables
+## Keys are title-cased for template access (env -> {{.Env}})
+# [notifiers.aws.vars]
+# env = "production"
+`
+}
+
+// Register adds aws to the given plugin registry.
+func Register(reg *notifier.Registry) {
+	err := reg.Register("aws", func() notifier.Notifier {
+		n := &AWS{}
+		ApplyDefaults(n)
+
+		return n
+	})
+	if err != nil {
+		panic(err)
+	}
+}
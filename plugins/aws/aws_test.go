@@ -0,0 +1,268 @@
+package aws_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/plugins/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var notif = notifier.Notification{
+	Message:          "Claude needs permission",
+	Cwd:              "/home/user/billing-api",
+	NotificationType: "permission_prompt",
+	SessionID:        "sess-1",
+}
+
+type request struct {
+	path   string
+	header http.Header
+	form   url.Values
+}
+
+// fakeAWS records query API requests and answers them with status.
+func fakeAWS(t *testing.T, status int, body string) (*httptest.Server, func() []request) {
+	t.Helper()
+	var (
+		mu   sync.Mutex
+		reqs []request
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		mu.Lock()
+		reqs = append(reqs, request{path: r.URL.Path, header: r.Header.Clone(), form: r.PostForm})
+		mu.Unlock()
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []request {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]request(nil), reqs...)
+	}
+}
+
+// isolate keeps the developer's AWS environment out of the tests.
+func isolate(t *testing.T) {
+	t.Helper()
+	for _, env := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN",
+		"AWS_PROFILE", "AWS_REGION", "AWS_DEFAULT_REGION"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "missing"))
+}
+
+func TestAWSName(t *testing.T) {
+	p := &aws.AWS{}
+	assert.Equal(t, "aws", p.Name())
+}
+
+func TestAWSDefaults(t *testing.T) {
+	p := &aws.AWS{}
+	aws.ApplyDefaults(p)
+	assert.Equal(t, "sns", p.Service)
+	assert.Equal(t, "text", p.Format)
+	assert.Equal(t, "Claude Code: {{.Project}}", p.Subject)
+	assert.Equal(t, "{{.Message}}", p.Message)
+	assert.Equal(t, "{{.SessionID}}", p.GroupID)
+}
+
+func TestAWSImplementsNotifier(t *testing.T) {
+	var _ notifier.Notifier = &aws.AWS{}
+}
+
+func TestAWSSNSPublish(t *testing.T) {
+	isolate(t)
+	srv, reqs := fakeAWS(t, http.StatusOK, "<PublishResponse/>")
+
+	p := &aws.AWS{}
+	aws.ApplyDefaults(p)
+	p.TopicARN = "arn:aws:sns:eu-west-1:123456789012:claude"
+	p.Endpoint = srv.URL
+	p.AccessKeyID = "AKID"
+	p.SecretAccessKey = "secret"
+	p.Attributes = map[string]string{"env": "{{.Env}}"}
+	p.Vars = map[string]string{"env": "prod"}
+
+	require.NoError(t, p.Send(context.Background(), notif))
+
+	got := reqs()
+	require.Len(t, got, 1)
+	form := got[0].form
+	assert.Equal(t, "Publish", form.Get("Action"))
+	assert.Equal(t, "arn:aws:sns:eu-west-1:123456789012:claude", form.Get("TopicArn"))
+	assert.Equal(t, "Claude needs permission", form.Get("Message"))
+	assert.Equal(t, "Claude Code: billing-api", form.Get("Subject"))
+	assert.Empty(t, form.Get("MessageGroupId"))
+
+	// Attributes are sorted by name.
+	assert.Equal(t, "cwd", form.Get("MessageAttributes.entry.1.Name"))
+	assert.Equal(t, "env", form.Get("MessageAttributes.entry.2.Name"))
+	assert.Equal(t, "prod", form.Get("MessageAttributes.entry.2.Value.StringValue"))
+	assert.Equal(t, "notification_type", form.Get("MessageAttributes.entry.3.Name"))
+	assert.Equal(t, "permission_prompt", form.Get("MessageAttributes.entry.3.Value.StringValue"))
+	assert.Equal(t, "String", form.Get("MessageAttributes.entry.4.Value.DataType"))
+	assert.Equal(t, "session_id", form.Get("MessageAttributes.entry.5.Name"))
+
+	assert.Regexp(t, `^AWS4-HMAC-SHA256 Credential=AKID/\d{8}/eu-west-1/sns/aws4_request, `+
+		`SignedHeaders=content-type;host;x-amz-date, Signature=[0-9a-f]{64}$`, got[0].header.Get("Authorization"))
+}
+
+func TestAWSSQSFIFOFromEnvCredentials(t *testing.T) {
+	isolate(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDENV")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "token")
+	t.Setenv("AWS_REGION", "us-east-2")
+	srv, reqs := fakeAWS(t, http.StatusOK, "<SendMessageResponse/>")
+
+	p := &aws.AWS{Service: "sqs", QueueURL: srv.URL + "/000000000000/claude.fifo", Format: "json"}
+	require.NoError(t, p.Send(context.Background(), notif))
+	require.NoError(t, p.Send(context.Background(), notif))
+
+	got := reqs()
+	require.Len(t, got, 2)
+	assert.Equal(t, "/000000000000/claude.fifo", got[0].path, "posts to the queue URL without an endpoint override")
+	form := got[0].form
+	assert.Equal(t, "SendMessage", form.Get("Action"))
+	assert.Equal(t, srv.URL+"/000000000000/claude.fifo", form.Get("QueueUrl"))
+	assert.JSONEq(t, `{"message":"Claude needs permission","project":"billing-api","cwd":"/home/user/billing-api",`+
+		`"notification_type":"permission_prompt","session_id":"sess-1"}`, form.Get("MessageBody"))
+	assert.Equal(t, "project", form.Get("MessageAttribute.3.Name"))
+	assert.Equal(t, "sess-1", form.Get("MessageGroupId"))
+	assert.Len(t, form.Get("MessageDeduplicationId"), 64)
+	assert.Equal(t, form.Get("MessageDeduplicationId"), got[1].form.Get("MessageDeduplicationId"),
+		"the same notification gets the same deduplication ID")
+
+	assert.Equal(t, "token", got[0].header.Get("X-Amz-Security-Token"))
+	assert.Contains(t, got[0].header.Get("Authorization"), "Credential=AKIDENV/")
+	assert.Contains(t, got[0].header.Get("Authorization"), "/us-east-2/sqs/aws4_request")
+}
+
+func TestAWSFIFODeduplicationID(t *testing.T) {
+	isolate(t)
+	srv, reqs := fakeAWS(t, http.StatusOK, "")
+
+	p := &aws.AWS{TopicARN: "arn:aws:sns:us-east-1:1:claude.fifo", Endpoint: srv.URL,
+		AccessKeyID: "AKID", SecretAccessKey: "secret"}
+	other := notif
+	other.Message = "Claude is waiting for your input"
+	require.NoError(t, p.Send(context.Background(), notif))
+	require.NoError(t, p.Send(context.Background(), other))
+	p.AllowRepeats = true
+	require.NoError(t, p.Send(context.Background(), notif))
+	require.NoError(t, p.Send(context.Background(), notif))
+
+	got := reqs()
+	require.Len(t, got, 4)
+	ids := make([]string, 0, len(got))
+	for _, r := range got {
+		ids = append(ids, r.form.Get("MessageDeduplicationId"))
+	}
+	assert.NotEqual(t, ids[0], ids[1], "different messages get different IDs")
+	assert.NotEqual(t, ids[0], ids[2], "allow_repeats makes each ID unique")
+	assert.NotEqual(t, ids[2], ids[3])
+}
+
+func TestAWSFIFOGroupFallback(t *testing.T) {
+	isolate(t)
+	srv, reqs := fakeAWS(t, http.StatusOK, "")
+
+	p := &aws.AWS{TopicARN: "arn:aws:sns:us-east-1:1:claude.fifo", Endpoint: srv.URL,
+		AccessKeyID: "AKID", SecretAccessKey: "secret"}
+	noSession := notif
+	noSession.SessionID = ""
+	require.NoError(t, p.Send(context.Background(), noSession))
+
+	got := reqs()
+	require.Len(t, got, 1)
+	assert.Equal(t, "claude-notifier", got[0].form.Get("MessageGroupId"))
+	assert.Empty(t, got[0].form.Get("Subject"))
+}
+
+func TestAWSSharedCredentialsProfile(t *testing.T) {
+	isolate(t)
+	dir := t.TempDir()
+	creds := filepath.Join(dir, "credentials")
+	require.NoError(t, writeFile(creds, "[ci]\naws_access_key_id = AKIDCI\naws_secret_access_key = ci-secret\n"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", creds)
+	srv, reqs := fakeAWS(t, http.StatusOK, "")
+
+	p := &aws.AWS{TopicARN: "arn:aws:sns:ap-south-1:1:claude", Endpoint: srv.URL, Profile: "ci"}
+	require.NoError(t, p.Send(context.Background(), notif))
+
+	got := reqs()
+	require.Len(t, got, 1)
+	assert.Contains(t, got[0].header.Get("Authorization"), "Credential=AKIDCI/")
+	assert.Contains(t, got[0].header.Get("Authorization"), "/ap-south-1/sns/")
+}
+
+func TestAWSSubjectSanitized(t *testing.T) {
+	isolate(t)
+	srv, reqs := fakeAWS(t, http.StatusOK, "")
+
+	p := &aws.AWS{TopicARN: "arn:aws:sns:us-east-1:1:claude", Endpoint: srv.URL,
+		AccessKeyID: "AKID", SecretAccessKey: "secret", Subject: "Claude\n{{.Message}} ✓"}
+	require.NoError(t, p.Send(context.Background(), notif))
+
+	got := reqs()
+	require.Len(t, got, 1)
+	assert.Equal(t, "Claude Claude needs permission ?", got[0].form.Get("Subject"))
+}
+
+func TestAWSServerError(t *testing.T) {
+	isolate(t)
+	srv, _ := fakeAWS(t, http.StatusForbidden, `<ErrorResponse><Error><Type>Sender</Type>`+
+		`<Code>AuthorizationError</Code><Message>not authorized</Message></Error></ErrorResponse>`)
+
+	p := &aws.AWS{TopicARN: "arn:aws:sns:us-east-1:1:claude", Endpoint: srv.URL,
+		AccessKeyID: "AKID", SecretAccessKey: "secret"}
+	err := p.Send(context.Background(), notif)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403")
+	assert.Contains(t, err.Error(), "AuthorizationError: not authorized")
+}
+
+func TestAWSValidation(t *testing.T) {
+	isolate(t)
+	arn := "arn:aws:sns:us-east-1:1:claude"
+	tests := []struct {
+		name string
+		p    aws.AWS
+		want string
+	}{
+		{"no topic", aws.AWS{}, "topic_arn is required"},
+		{"no queue", aws.AWS{Service: "sqs"}, "queue_url is required"},
+		{"bad service", aws.AWS{Service: "sms"}, "unknown service"},
+		{"bad format", aws.AWS{TopicARN: arn, Format: "xml"}, "unknown format"},
+		{"no region", aws.AWS{Service: "sqs", QueueURL: "http://localhost:9324/q"}, "region is required"},
+		{"no credentials", aws.AWS{TopicARN: arn}, "no AWS credentials found"},
+		{"half keys", aws.AWS{TopicARN: arn, AccessKeyID: "AKID"}, "must be set together"},
+		{"builtin attribute", aws.AWS{TopicARN: arn, Attributes: map[string]string{"project": "x"}}, "conflicts with a built-in"},
+		{"bad template", aws.AWS{TopicARN: arn, Message: "{{.Invalid"}, "rendering message template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.p.Send(context.Background(), notif)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func writeFile(path, content string) error {
+	return os.WriteFile(path, []byte(content), 0600)
+}
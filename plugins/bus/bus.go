@@ -0,0 +1,267 @@
+package bus
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/tmpl"
+)
+
+const (
+	dialTimeout    = 30 * time.Second
+	backendNATS    = "nats"
+	backendRedis   = "redis"
+	defaultSubject = "claude.{{.Project}}.{{.NotificationType}}"
+)
+
+// Bus publishes notifications as JSON to a NATS subject or Redis channel.
+type Bus struct {
+	Backend            string            `toml:"backend"`
+	Address            string            `toml:"address"`
+	Subject            string            `toml:"subject"`
+	TLS                bool              `toml:"tls"`
+	InsecureSkipVerify bool              `toml:"insecure_skip_verify"`
+	Username           string            `toml:"username"`
+	Password           string            `toml:"password"`
+	Token              string            `toml:"token"`
+	Vars               map[string]string `toml:"vars"`
+}
+
+// ApplyDefaults sets sane defaults on a new Bus instance.
+func ApplyDefaults(n *Bus) {
+	n.Backend = backendNATS
+	n.Address = "127.0.0.1:4222"
+	n.Subject = defaultSubject
+}
+
+func (n *Bus) Name() string { return "bus" }
+
+// event is the JSON document published for every notification.
+type event struct {
+	notifier.Notification
+
+	Project   string    `json:"project"`
+	Hostname  string    `json:"hostname"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func (n *Bus) Send(ctx context.Context, notif notifier.Notification) error {
+	if n.Address == "" {
+		return errors.New("address is required")
+	}
+	var publish func(ctx context.Context, c *conn, subject string, payload []byte) error
+	switch n.Backend {
+	case backendNATS, "":
+		publish = n.publishNATS
+	case backendRedis:
+		publish = n.publishRedis
+	default:
+		return fmt.Errorf("unknown backend %q (want nats or redis)", n.Backend)
+	}
+
+	tctx := tmpl.BuildContext(notif, n.Vars)
+
+	subjectTmpl := n.Subject
+	if subjectTmpl == "" {
+		subjectTmpl = defaultSubject
+	}
+	subject, err := tmpl.Render("subject", subjectTmpl, tctx)
+	if err != nil {
+		return err
+	}
+	if n.Backend != backendRedis {
+		subject = natsSubject(subject)
+	}
+
+	hostname, _ := os.Hostname()
+	payload, err := json.Marshal(event{
+		Notification: notif,
+		Project:      notif.Project(),
+		Hostname:     hostname,
+		Timestamp:    time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("encoding payload: %w", err)
+	}
+
+	c, err := n.dial(ctx, n.TLS && n.Backend == backendRedis)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	// Unblock any pending read or write as soon as the context ends.
+	stop := context.AfterFunc(ctx, func() { _ = c.raw.Close() })
+	defer stop()
+
+	err = publish(ctx, c, subject, payload)
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("publishing: %w", ctx.Err())
+	}
+
+	return err
+}
+
+// conn is a line-oriented connection that can be upgraded to TLS in place,
+// as NATS does after its INFO line.
+type conn struct {
+	net.Conn
+
+	raw net.Conn
+	r   *bufio.Reader
+}
+
+func (c *conn) close() { _ = c.Close() }
+
+func (c *conn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("reading from server: %w", err)
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (c *conn) write(data []byte) error {
+	_, err := c.Write(data)
+	if err != nil {
+		return fmt.Errorf("writing to server: %w", err)
+	}
+
+	return nil
+}
+
+func (n *Bus) tlsConfig() (*tls.Config, error) {
+	host, _, err := net.SplitHostPort(n.Address)
+	if err != nil {
+		return nil, fmt.Errorf("parsing address: %w", err)
+	}
+
+	return &tls.Config{
+		ServerName:         host,
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: n.InsecureSkipVerify, //nolint:gosec // opt-in for self-signed servers
+	}, nil
+}
+
+func (n *Bus) dial(ctx context.Context, useTLS bool) (*conn, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	raw, err := dialer.DialContext(ctx, "tcp", n.Address)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", n.Address, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = raw.SetDeadline(deadline)
+	}
+
+	c := &conn{Conn: raw, raw: raw, r: bufio.NewReader(raw)}
+	if useTLS {
+		err = n.upgradeTLS(ctx, c)
+		if err != nil {
+			c.close()
+
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+func (n *Bus) upgradeTLS(ctx context.Context, c *conn) error {
+	cfg, err := n.tlsConfig()
+	if err != nil {
+		return err
+	}
+	tlsConn := tls.Client(c.Conn, cfg)
+	err = tlsConn.HandshakeContext(ctx)
+	if err != nil {
+		return fmt.Errorf("TLS handshake: %w", err)
+	}
+	c.Conn = tlsConn
+	c.r = bufio.NewReader(tlsConn)
+
+	return nil
+}
+
+// natsSubject makes a rendered subject valid for NATS: whitespace and
+// wildcards become underscores and empty tokens are filled in.
+func natsSubject(subject string) string {
+	subject = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\r', '\n', '*', '>':
+			return '_'
+		}
+
+		return r
+	}, subject)
+
+	tokens := strings.Split(subject, ".")
+	for i, tok := range tokens {
+		if tok == "" {
+			tokens[i] = "_"
+		}
+	}
+
+	return strings.Join(tokens, ".")
+}
+
+// SampleConfig returns example TOML configuration.
+func (n *Bus) SampleConfig() string {
+	return `## Publish notifications as JSON to a message bus (NATS or Redis pub/sub)
+[[notifiers.bus]]
+
+## Backend: "nats" or "redis"
+backend = "nats"
+
+## Server address as host:port (required)
+address = "127.0.0.1:4222"
+
+## Go template for the NATS subject or Redis channel
+## For NATS, whitespace and wildcards are replaced and empty tokens become "_"
+## Available variables: {{.Message}}, {{.Title}}, {{.Project}}, {{.Cwd}},
+## {{.NotificationType}}, {{.SessionID}}, {{.TranscriptPath}}, {{.Severity}}
+## Custom variables from [notifiers.bus.vars] are also available, title-cased
+# subject = "claude.{{.Project}}.{{.NotificationType}}"
+
+## Use TLS (NATS upgrades after INFO; Redis uses TLS from the start)
+# tls = false
+
+## Skip TLS certificate verification (self-signed servers only)
+# insecure_skip_verify = false
+
+## Username and password (NATS user/pass, Redis ACL user/password)
+## For Redis without ACLs, set only password
+# username = ""
+# password = ""
+
+## NATS auth token
+# token = ""
+
+## User-defined template variables
+## Keys are title-cased for template access (env -> {{.Env}})
+# [notifiers.bus.vars]
+# env = "production"
+`
+}
+
+// Register adds bus to the given plugin registry.
+func Register(reg *notifier.Registry) {
+	err := reg.Register("bus", func() notifier.Notifier {
+		n := &Bus{}
+		ApplyDefaults(n)
+
+		return n
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,371 @@
+package bus_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/plugins/bus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type published struct {
+	subject string
+	payload []byte
+	connect map[string]any
+	auth    []string
+}
+
+type fakeServer struct {
+	addr string
+	mu   sync.Mutex
+	msgs []published
+	done chan struct{}
+}
+
+func (f *fakeServer) wait(t *testing.T) []published {
+	t.Helper()
+	select {
+	case <-f.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("fake server did not finish")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]published(nil), f.msgs...)
+}
+
+func (f *fakeServer) record(p published) {
+	f.mu.Lock()
+	f.msgs = append(f.msgs, p)
+	f.mu.Unlock()
+}
+
+func serve(t *testing.T, handle func(net.Conn, *fakeServer)) *fakeServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	srv := &fakeServer{addr: listener.Addr().String(), done: make(chan struct{})}
+	go func() {
+		defer close(srv.done)
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+		handle(conn, srv)
+	}()
+
+	return srv
+}
+
+func serverTLSConfig() *tls.Config {
+	certSrv := httptest.NewTLSServer(http.NotFoundHandler())
+	certSrv.Close()
+
+	return &tls.Config{Certificates: certSrv.TLS.Certificates, MinVersion: tls.VersionTLS12}
+}
+
+// fakeNATS implements enough of the NATS server protocol for one publish.
+func fakeNATS(t *testing.T, tlsCfg *tls.Config, pubErr string) *fakeServer {
+	t.Helper()
+
+	return serve(t, func(conn net.Conn, srv *fakeServer) {
+		info := map[string]any{"server_id": "fake", "tls_required": tlsCfg != nil}
+		infoJSON, _ := json.Marshal(info)
+		_, _ = fmt.Fprintf(conn, "INFO %s\r\n", infoJSON)
+
+		if tlsCfg != nil {
+			tlsConn := tls.Server(conn, tlsCfg)
+			if tlsConn.Handshake() != nil {
+				return
+			}
+			conn = tlsConn
+		}
+
+		r := bufio.NewReader(conn)
+		var current published
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			switch {
+			case strings.HasPrefix(line, "CONNECT "):
+				_ = json.Unmarshal([]byte(strings.TrimPrefix(line, "CONNECT ")), &current.connect)
+			case strings.HasPrefix(line, "PUB "):
+				fields := strings.Fields(line)
+				size, _ := strconv.Atoi(fields[len(fields)-1])
+				payload := make([]byte, size+2)
+				_, _ = io.ReadFull(r, payload)
+				current.subject = fields[1]
+				current.payload = payload[:size]
+				srv.record(current)
+				if pubErr != "" {
+					_, _ = fmt.Fprintf(conn, "-ERR '%s'\r\n", pubErr)
+
+					return
+				}
+			case line == "PING":
+				_, _ = fmt.Fprint(conn, "PONG\r\n")
+
+				return
+			}
+		}
+	})
+}
+
+// readRESP reads one RESP array of bulk strings.
+func readRESP(r *bufio.Reader) ([]string, error) {
+	header, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(header[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, count)
+	for range count {
+		sizeLine, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(sizeLine[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		_, err = io.ReadFull(r, buf)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:size]))
+	}
+
+	return args, nil
+}
+
+// fakeRedis implements AUTH, PUBLISH and QUIT.
+func fakeRedis(t *testing.T, password string) *fakeServer {
+	t.Helper()
+
+	return serve(t, func(conn net.Conn, srv *fakeServer) {
+		r := bufio.NewReader(conn)
+		var current published
+		authed := password == ""
+		for {
+			args, err := readRESP(r)
+			if err != nil {
+				return
+			}
+			switch strings.ToUpper(args[0]) {
+			case "AUTH":
+				current.auth = args[1:]
+				if args[len(args)-1] != password {
+					_, _ = fmt.Fprint(conn, "-WRONGPASS invalid username-password pair\r\n")
+
+					continue
+				}
+				authed = true
+				_, _ = fmt.Fprint(conn, "+OK\r\n")
+			case "PUBLISH":
+				if !authed {
+					_, _ = fmt.Fprint(conn, "-NOAUTH Authentication required.\r\n")
+
+					continue
+				}
+				current.subject = args[1]
+				current.payload = []byte(args[2])
+				srv.record(current)
+				_, _ = fmt.Fprint(conn, ":1\r\n")
+			case "QUIT":
+				_, _ = fmt.Fprint(conn, "+OK\r\n")
+
+				return
+			}
+		}
+	})
+}
+
+func TestBusName(t *testing.T) {
+	p := &bus.Bus{}
+	assert.Equal(t, "bus", p.Name())
+}
+
+func TestBusDefaults(t *testing.T) {
+	p := &bus.Bus{}
+	bus.ApplyDefaults(p)
+	assert.Equal(t, "nats", p.Backend)
+	assert.Equal(t, "127.0.0.1:4222", p.Address)
+	assert.Equal(t, "claude.{{.Project}}.{{.NotificationType}}", p.Subject)
+}
+
+func TestBusImplementsNotifier(t *testing.T) {
+	var _ notifier.Notifier = &bus.Bus{}
+}
+
+func TestBusNATSPublish(t *testing.T) {
+	srv := fakeNATS(t, nil, "")
+
+	p := &bus.Bus{}
+	bus.ApplyDefaults(p)
+	p.Address = srv.addr
+	p.Token = "s3cret"
+
+	notif := notifier.Notification{
+		Message:          "Claude needs permission",
+		Cwd:              "/home/user/billing-api",
+		NotificationType: "permission_prompt",
+		SessionID:        "sess-1",
+	}
+	require.NoError(t, p.Send(context.Background(), notif))
+
+	msgs := srv.wait(t)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "claude.billing-api.permission_prompt", msgs[0].subject)
+	assert.Equal(t, "s3cret", msgs[0].connect["auth_token"])
+	assert.Equal(t, false, msgs[0].connect["verbose"])
+
+	var event map[string]any
+	require.NoError(t, json.Unmarshal(msgs[0].payload, &event))
+	assert.Equal(t, "Claude needs permission", event["message"])
+	assert.Equal(t, "permission_prompt", event["notification_type"])
+	assert.Equal(t, "sess-1", event["session_id"])
+	assert.Equal(t, "billing-api", event["project"])
+	assert.Contains(t, event, "hostname")
+	assert.Contains(t, event, "timestamp")
+}
+
+func TestBusNATSSubjectIsSanitized(t *testing.T) {
+	srv := fakeNATS(t, nil, "")
+
+	p := &bus.Bus{Address: srv.addr, Subject: "claude.{{.Project}}.{{.NotificationType}}"}
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{Cwd: "/tmp/my project"}))
+
+	msgs := srv.wait(t)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "claude.my_project._", msgs[0].subject)
+}
+
+func TestBusNATSUserPassOverTLS(t *testing.T) {
+	srv := fakeNATS(t, serverTLSConfig(), "")
+
+	p := &bus.Bus{
+		Backend:            "nats",
+		Address:            srv.addr,
+		Subject:            "claude.events",
+		TLS:                true,
+		InsecureSkipVerify: true,
+		Username:           "bot",
+		Password:           "pw",
+	}
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{Message: "hi"}))
+
+	msgs := srv.wait(t)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "bot", msgs[0].connect["user"])
+	assert.Equal(t, "pw", msgs[0].connect["pass"])
+	assert.Equal(t, true, msgs[0].connect["tls_required"])
+}
+
+func TestBusNATSRequiresTLSWhenServerDoes(t *testing.T) {
+	srv := fakeNATS(t, serverTLSConfig(), "")
+
+	p := &bus.Bus{Address: srv.addr, Subject: "claude.events"}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires TLS")
+}
+
+func TestBusNATSServerError(t *testing.T) {
+	srv := fakeNATS(t, nil, "Permissions Violation for Publish to claude.events")
+
+	p := &bus.Bus{Address: srv.addr, Subject: "claude.events"}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Permissions Violation")
+}
+
+func TestBusRedisPublish(t *testing.T) {
+	srv := fakeRedis(t, "pw")
+
+	p := &bus.Bus{
+		Backend:  "redis",
+		Address:  srv.addr,
+		Subject:  "claude:{{.Project}}:{{.NotificationType}}",
+		Username: "default",
+		Password: "pw",
+	}
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{
+		Message:          "done",
+		Cwd:              "/home/user/billing-api",
+		NotificationType: "idle_prompt",
+	}))
+
+	msgs := srv.wait(t)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, []string{"default", "pw"}, msgs[0].auth)
+	assert.Equal(t, "claude:billing-api:idle_prompt", msgs[0].subject)
+
+	var event map[string]any
+	require.NoError(t, json.Unmarshal(msgs[0].payload, &event))
+	assert.Equal(t, "done", event["message"])
+}
+
+func TestBusRedisWrongPassword(t *testing.T) {
+	srv := fakeRedis(t, "pw")
+
+	p := &bus.Bus{Backend: "redis", Address: srv.addr, Subject: "claude", Password: "nope"}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "WRONGPASS")
+}
+
+func TestBusRespectsContextDeadline(t *testing.T) {
+	// Accept but never send INFO.
+	srv := serve(t, func(conn net.Conn, _ *fakeServer) {
+		_, _ = io.Copy(io.Discard, conn)
+	})
+
+	p := &bus.Bus{Address: srv.addr, Subject: "claude"}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := p.Send(ctx, notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestBusUnknownBackend(t *testing.T) {
+	p := &bus.Bus{Backend: "kafka", Address: "127.0.0.1:1"}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown backend")
+}
+
+func TestBusBadTemplate(t *testing.T) {
+	p := &bus.Bus{Address: "127.0.0.1:1", Subject: "{{.Invalid"}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rendering subject template")
+}
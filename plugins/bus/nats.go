@@ -0,0 +1,100 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type natsInfo struct {
+	TLSRequired  bool `json:"tls_required"`
+	AuthRequired bool `json:"auth_required"`
+}
+
+type natsConnect struct {
+	Verbose     bool   `json:"verbose"`
+	Pedantic    bool   `json:"pedantic"`
+	TLSRequired bool   `json:"tls_required"`
+	Name        string `json:"name"`
+	Lang        string `json:"lang"`
+	Version     string `json:"version"`
+	Protocol    int    `json:"protocol"`
+	User        string `json:"user,omitempty"`
+	Pass        string `json:"pass,omitempty"`
+	AuthToken   string `json:"auth_token,omitempty"`
+}
+
+// publishNATS speaks the NATS client protocol: read INFO, optionally upgrade
+// to TLS, send CONNECT and PUB, then PING and wait for PONG so that any
+// -ERR for the publish is reported before we disconnect.
+func (n *Bus) publishNATS(ctx context.Context, c *conn, subject string, payload []byte) error {
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	infoJSON, ok := strings.CutPrefix(line, "INFO ")
+	if !ok {
+		return fmt.Errorf("unexpected greeting %q", line)
+	}
+	var info natsInfo
+	err = json.Unmarshal([]byte(infoJSON), &info)
+	if err != nil {
+		return fmt.Errorf("parsing INFO: %w", err)
+	}
+
+	if info.TLSRequired && !n.TLS {
+		return errors.New("server requires TLS (set tls = true)")
+	}
+	if n.TLS {
+		err = n.upgradeTLS(ctx, c)
+		if err != nil {
+			return err
+		}
+	}
+
+	connect, err := json.Marshal(natsConnect{
+		TLSRequired: n.TLS,
+		Name:        "claude-notifier",
+		Lang:        "go",
+		Version:     "1",
+		Protocol:    1,
+		User:        n.Username,
+		Pass:        n.Password,
+		AuthToken:   n.Token,
+	})
+	if err != nil {
+		return fmt.Errorf("encoding CONNECT: %w", err)
+	}
+
+	var buf []byte
+	buf = append(buf, "CONNECT "...)
+	buf = append(buf, connect...)
+	buf = append(buf, "\r\nPUB "+subject+" "+strconv.Itoa(len(payload))+"\r\n"...)
+	buf = append(buf, payload...)
+	buf = append(buf, "\r\nPING\r\n"...)
+	err = c.write(buf)
+	if err != nil {
+		return err
+	}
+
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			err = c.write([]byte("PONG\r\n"))
+			if err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("server error: %s", strings.Trim(strings.TrimPrefix(line, "-ERR "), "'"))
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// publishRedis speaks RESP: optional AUTH, PUBLISH, then QUIT.
+func (n *Bus) publishRedis(_ context.Context, c *conn, channel string, payload []byte) error {
+	if n.Password != "" {
+		args := []string{"AUTH"}
+		if n.Username != "" {
+			args = append(args, n.Username)
+		}
+		_, err := c.command(append(args, n.Password)...)
+		if err != nil {
+			return fmt.Errorf("authenticating: %w", err)
+		}
+	}
+
+	_, err := c.command("PUBLISH", channel, string(payload))
+	if err != nil {
+		return fmt.Errorf("publishing: %w", err)
+	}
+
+	_, _ = c.command("QUIT")
+
+	return nil
+}
+
+// command sends a RESP array of bulk strings and returns the simple reply.
+func (c *conn) command(args ...string) (string, error) {
+	var b strings.Builder
+	b.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		b.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n")
+	}
+	err := c.write([]byte(b.String()))
+	if err != nil {
+		return "", err
+	}
+
+	line, err := c.readLine()
+	if err != nil {
+		return "", err
+	}
+	if line == "" {
+		return "", errors.New("empty reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", errors.New(line[1:])
+	}
+
+	return "", fmt.Errorf("unexpected reply %q", line)
+}
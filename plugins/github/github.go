@@ -0,0 +1,403 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/tmpl"
+)
+
+const (
+	httpTimeout     = 30 * time.Second
+	httpErrorStatus = 400
+	maxErrorBody    = 1024
+	maxDescription  = 140
+	perPage         = 100
+	maxPages        = 10
+
+	modeComment = "comment"
+	modeStatus  = "status"
+
+	defaultAPIURL      = "https://api.github.com"
+	defaultHost        = "github.com"
+	defaultRemote      = "origin"
+	defaultContext     = "claude-code"
+	defaultState       = "pending"
+	defaultMessage     = "**Claude Code is waiting** ({{.NotificationType}})\n\n{{.Message}}"
+	defaultDescription = "{{.Message}}"
+	markerPrefix       = "<!-- claude-notifier:session="
+)
+
+var httpClient = &http.Client{
+	Timeout: httpTimeout,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// GitHub comments on, or sets a commit status for, the open pull request of
+// the branch checked out in the notification's working directory.
+type GitHub struct {
+	Token       string            `toml:"token"`
+	APIURL      string            `toml:"api_url"`
+	Mode        string            `toml:"mode"`
+	Remote      string            `toml:"remote"`
+	Repo        string            `toml:"repo"`
+	Message     string            `toml:"message"`
+	Context     string            `toml:"context"`
+	State       string            `toml:"state"`
+	Description string            `toml:"description"`
+	TargetURL   string            `toml:"target_url"`
+	Vars        map[string]string `toml:"vars"`
+}
+
+// ApplyDefaults sets sane defaults on a new GitHub instance.
+func ApplyDefaults(n *GitHub) {
+	n.Mode = modeComment
+	n.Remote = defaultRemote
+	n.Message = defaultMessage
+	n.Context = defaultContext
+	n.State = defaultState
+	n.Description = defaultDescription
+}
+
+func (n *GitHub) Name() string { return "github" }
+
+type pullRequest struct {
+	Number int `json:"number"`
+	Head   struct {
+		SHA string `json:"sha"`
+	} `json:"head"`
+}
+
+type comment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+func (n *GitHub) Send(ctx context.Context, notif notifier.Notification) error {
+	switch n.Mode {
+	case modeComment, modeStatus, "":
+	default:
+		return fmt.Errorf("unknown mode %q (want comment or status)", n.Mode)
+	}
+
+	token := n.Token
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+	if token == "" {
+		return errors.New("token is required (or set GITHUB_TOKEN)")
+	}
+
+	remote := n.Remote
+	if remote == "" {
+		remote = defaultRemote
+	}
+	repo, err := findRepository(notif.Cwd, remote)
+	if err != nil {
+		return err
+	}
+	if n.Repo != "" {
+		owner, name, ok := strings.Cut(n.Repo, "/")
+		if !ok || owner == "" || name == "" {
+			return fmt.Errorf("repo %q must be owner/name", n.Repo)
+		}
+		repo.owner, repo.name = owner, name
+	}
+
+	base, err := n.apiURL(repo.host)
+	if err != nil {
+		return err
+	}
+	api := &client{base: base, token: token}
+	tctx := tmpl.BuildContext(notif, n.Vars)
+
+	pr, err := api.findPullRequest(ctx, repo)
+	if err != nil {
+		return err
+	}
+	if pr == nil {
+		slog.Debug("github: no open pull request", "repo", repo.owner+"/"+repo.name, "branch", repo.branch)
+
+		return nil
+	}
+
+	if n.Mode == modeStatus {
+		return n.setStatus(ctx, api, repo, pr, tctx)
+	}
+
+	return n.stickyComment(ctx, api, repo, pr, notif.SessionID, tctx)
+}
+
+// apiURL returns the configured API base, or api.github.com for github.com
+// remotes. Other hosts need an explicit api_url, so the token is never sent
+// to a host the user did not configure.
+func (n *GitHub) apiURL(host string) (string, error) {
+	if n.APIURL != "" {
+		return strings.TrimRight(n.APIURL, "/"), nil
+	}
+	if host == "" || host == defaultHost {
+		return defaultAPIURL, nil
+	}
+
+	return "", fmt.Errorf("remote host %q is not github.com; set api_url for GitHub Enterprise Server", host)
+}
+
+// stickyComment updates the comment carrying the session's marker, or posts
+// a new one, so each session has a single comment on the pull request.
+// Without a session ID there is nothing to match, so a new comment is posted.
+func (n *GitHub) stickyComment(ctx context.Context, api *client, repo repository, pr *pullRequest,
+	sessionID string, tctx map[string]string) error {
+	msgTmpl := n.Message
+	if msgTmpl == "" {
+		msgTmpl = defaultMessage
+	}
+	message, err := tmpl.Render("message", msgTmpl, tctx)
+	if err != nil {
+		return err
+	}
+
+	base := "/repos/" + url.PathEscape(repo.owner) + "/" + url.PathEscape(repo.name)
+	if sessionID == "" {
+		return api.postComment(ctx, base, pr, map[string]string{"body": message})
+	}
+
+	marker := markerPrefix + sessionID + " -->"
+	body := map[string]string{"body": message + "\n\n" + marker}
+
+	for page := 1; page <= maxPages; page++ {
+		var comments []comment
+		path := base + "/issues/" + strconv.Itoa(pr.Number) + "/comments?per_page=" + strconv.Itoa(perPage) +
+			"&page=" + strconv.Itoa(page)
+		err = api.do(ctx, http.MethodGet, path, nil, &comments)
+		if err != nil {
+			return fmt.Errorf("listing comments: %w", err)
+		}
+		for _, c := range comments {
+			if strings.Contains(c.Body, marker) {
+				err = api.do(ctx, http.MethodPatch, base+"/issues/comments/"+strconv.FormatInt(c.ID, 10), body, nil)
+				if err != nil {
+					return fmt.Errorf("updating comment: %w", err)
+				}
+
+				return nil
+			}
+		}
+		if len(comments) < perPage {
+			break
+		}
+	}
+
+	return api.postComment(ctx, base, pr, body)
+}
+
+// setStatus sets a commit status on the pull request's head commit.
+func (n *GitHub) setStatus(ctx context.Context, api *client, repo repository, pr *pullRequest,
+	tctx map[string]string) error {
+	state := n.State
+	switch state {
+	case "":
+		state = defaultState
+	case "pending", "success", "failure", "error":
+	default:
+		return fmt.Errorf("unknown state %q (want pending, success, failure or error)", state)
+	}
+
+	descTmpl := n.Description
+	if descTmpl == "" {
+		descTmpl = defaultDescription
+	}
+	description, err := tmpl.Render("description", descTmpl, tctx)
+	if err != nil {
+		return err
+	}
+	description = strings.Join(strings.Fields(description), " ")
+	if runes := []rune(description); len(runes) > maxDescription {
+		description = string(runes[:maxDescription-1]) + "…"
+	}
+
+	targetURL := ""
+	if n.TargetURL != "" {
+		targetURL, err = tmpl.Render("target_url", n.TargetURL, tctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	statusContext := n.Context
+	if statusContext == "" {
+		statusContext = defaultContext
+	}
+
+	body := map[string]string{"state": state, "description": description, "context": statusContext}
+	if targetURL != "" {
+		body["target_url"] = targetURL
+	}
+	path := "/repos/" + url.PathEscape(repo.owner) + "/" + url.PathEscape(repo.name) + "/statuses/" + pr.Head.SHA
+	err = api.do(ctx, http.MethodPost, path, body, nil)
+	if err != nil {
+		return fmt.Errorf("setting status: %w", err)
+	}
+
+	return nil
+}
+
+// client is a minimal GitHub REST API client.
+type client struct {
+	base  string
+	token string
+}
+
+func (c *client) findPullRequest(ctx context.Context, repo repository) (*pullRequest, error) {
+	query := url.Values{"state": {"open"}, "head": {repo.headOwner + ":" + repo.branch}}
+	path := "/repos/" + url.PathEscape(repo.owner) + "/" + url.PathEscape(repo.name) + "/pulls?" + query.Encode()
+
+	var pulls []pullRequest
+	err := c.do(ctx, http.MethodGet, path, nil, &pulls)
+	if err != nil {
+		return nil, fmt.Errorf("finding pull request: %w", err)
+	}
+	if len(pulls) == 0 {
+		return nil, nil
+	}
+
+	return &pulls[0], nil
+}
+
+func (c *client) postComment(ctx context.Context, base string, pr *pullRequest, body map[string]string) error {
+	err := c.do(ctx, http.MethodPost, base+"/issues/"+strconv.Itoa(pr.Number)+"/comments", body, nil)
+	if err != nil {
+		return fmt.Errorf("posting comment: %w", err)
+	}
+
+	return nil
+}
+
+func (c *client) do(ctx context.Context, method, path string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encoding payload: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, reader)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode >= httpErrorStatus {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("server returned %s: %s", resp.Status, apiErr.Message)
+		}
+
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	if result != nil {
+		err = json.NewDecoder(resp.Body).Decode(result)
+		if err != nil {
+			return fmt.Errorf("decoding response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SampleConfig returns example TOML configuration.
+func (n *GitHub) SampleConfig() string {
+	return `## GitHub pull request comments or commit statuses
+## The repository and branch come from the git checkout at the notification's
+## cwd; nothing is sent when the branch has no open pull request
+[[notifiers.github]]
+
+## Token with pull request (comment) or commit status (status) write access
+## Defaults to GITHUB_TOKEN, then GH_TOKEN
+# token = ""
+
+## API base; defaults to https://api.github.com for github.com remotes
+## Required for other hosts, e.g. https://github.example.com/api/v3 for
+## GitHub Enterprise Server
+# api_url = "https://api.github.com"
+
+## Mode: "comment" keeps one comment per Claude session up to date;
+## "status" sets a commit status on the pull request's head commit
+# mode = "comment"
+
+## Git remote that points at the GitHub repository
+# remote = "origin"
+
+## Repository the pull requests are opened against (owner/name), when it is
+## not the remote's, e.g. the upstream of a fork
+# repo = ""
+
+## Go template for the comment (GitHub Markdown)
+## Available variables: {{.Message}}, {{.Title}}, {{.Project}}, {{.Cwd}},
+## {{.NotificationType}}, {{.SessionID}}, {{.TranscriptPath}}, {{.Severity}}
+## Custom variables from [notifiers.github.vars] are also available, title-cased
+# message = "**Claude Code is waiting** ({{.NotificationType}})\n\n{{.Message}}"
+
+## Commit status settings (status mode)
+## state is one of pending, success, failure or error; description and
+## target_url are Go templates (description is cut to 140 characters)
+# context = "claude-code"
+# state = "pending"
+# description = "{{.Message}}"
+# target_url = ""
+
+## User-defined template variables
+## Keys are title-cased for template access (env -> {{.Env}})
+# [notifiers.github.vars]
+# env = "production"
+`
+}
+
+// Register adds github to the given plugin registry.
+func Register(reg *notifier.Registry) {
+	err := reg.Register("github", func() notifier.Notifier {
+		n := &GitHub{}
+		ApplyDefaults(n)
+
+		return n
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,167 @@
+package github
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// repository is the GitHub repository and branch checked out in a directory.
+type repository struct {
+	host   string
+	owner  string
+	name   string
+	branch string
+	// headOwner owns the branch; it differs from owner when pull requests
+	// come from a fork.
+	headOwner string
+}
+
+// findRepository walks up from dir to the enclosing git checkout and reads
+// the current branch from HEAD and the owner/name from the remote URL.
+// Worktrees and submodules (a .git file pointing elsewhere) are followed.
+func findRepository(dir, remote string) (repository, error) {
+	gitDir, err := findGitDir(dir)
+	if err != nil {
+		return repository{}, err
+	}
+
+	head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return repository{}, fmt.Errorf("reading HEAD: %w", err)
+	}
+	ref, ok := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: refs/heads/")
+	if !ok {
+		return repository{}, errors.New("HEAD is detached, not on a branch")
+	}
+
+	// Worktrees keep their own HEAD but share the config of the main repo.
+	commonDir := gitDir
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir = strings.TrimSpace(string(data))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+	}
+
+	remoteURL, err := remoteURL(filepath.Join(commonDir, "config"), remote)
+	if err != nil {
+		return repository{}, err
+	}
+	repo, err := parseRemote(remoteURL)
+	if err != nil {
+		return repository{}, err
+	}
+	repo.branch = ref
+	repo.headOwner = repo.owner
+
+	return repo, nil
+}
+
+func findGitDir(dir string) (string, error) {
+	if dir == "" {
+		return "", errors.New("notification has no cwd")
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		path := filepath.Join(dir, ".git")
+		info, err := os.Stat(path)
+		if err == nil {
+			if info.IsDir() {
+				return path, nil
+			}
+
+			return readGitFile(path)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("%s is not inside a git repository", dir)
+		}
+		dir = parent
+	}
+}
+
+// readGitFile resolves a "gitdir: <path>" .git file.
+func readGitFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return "", fmt.Errorf("%s is not a gitdir file", path)
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+
+	return gitDir, nil
+}
+
+// remoteURL reads the url of [remote "<name>"] from a git config file.
+func remoteURL(configPath, remote string) (string, error) {
+	file, err := os.Open(configPath)
+	if err != nil {
+		return "", fmt.Errorf("reading git config: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	section := `[remote "` + remote + `"]`
+	inSection := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inSection = line == section
+
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if ok && strings.TrimSpace(key) == "url" {
+			return strings.TrimSpace(value), nil
+		}
+	}
+
+	return "", fmt.Errorf("remote %q not found in git config", remote)
+}
+
+// parseRemote extracts host, owner and name from the remote URL forms git
+// accepts: https://host/owner/name(.git), ssh://git@host[:port]/owner/name
+// and git@host:owner/name.
+func parseRemote(remote string) (repository, error) {
+	var host, path string
+	if strings.Contains(remote, "://") {
+		u, err := url.Parse(remote)
+		if err != nil {
+			return repository{}, fmt.Errorf("parsing remote %q: %w", remote, err)
+		}
+		host, path = u.Hostname(), u.Path
+	} else {
+		rest := remote
+		if _, after, ok := strings.Cut(rest, "@"); ok {
+			rest = after
+		}
+		host, path, _ = strings.Cut(rest, ":")
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	owner, name, found := strings.Cut(path, "/")
+	if host == "" || !found || owner == "" || name == "" || strings.Contains(name, "/") {
+		return repository{}, fmt.Errorf("cannot parse GitHub repository from remote %q", remote)
+	}
+
+	return repository{host: host, owner: owner, name: name}, nil
+}
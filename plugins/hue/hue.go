@@ -0,0 +1,439 @@
+package hue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+)
+
+const (
+	httpTimeout     = 30 * time.Second
+	httpErrorStatus = 400
+	maxResponseBody = 64 << 10
+	restoreTimeout  = 5 * time.Second
+
+	effectAlert   = "alert"
+	effectBreathe = "breathe"
+	effectNone    = "none"
+)
+
+var httpClient = &http.Client{
+	Timeout: httpTimeout,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// namedColors maps a few friendly names to RGB.
+var namedColors = map[string][3]uint8{
+	"red":    {255, 0, 0},
+	"green":  {0, 255, 0},
+	"blue":   {0, 0, 255},
+	"amber":  {255, 191, 0},
+	"orange": {255, 128, 0},
+	"yellow": {255, 255, 0},
+	"purple": {128, 0, 255},
+	"white":  {255, 255, 255},
+}
+
+// Rule describes what the lights do for a notification type.
+type Rule struct {
+	Effect     string        `toml:"effect"`
+	Color      string        `toml:"color"`
+	Brightness int           `toml:"brightness"`
+	Duration   time.Duration `toml:"duration"`
+}
+
+// Hue flashes or recolours Philips Hue lights through the bridge's local
+// REST API.
+type Hue struct {
+	Bridge     string          `toml:"bridge"`
+	AppKey     string          `toml:"app_key"`
+	Lights     []string        `toml:"lights"`
+	Groups     []string        `toml:"groups"`
+	Effect     string          `toml:"effect"`
+	Color      string          `toml:"color"`
+	Brightness int             `toml:"brightness"`
+	Duration   time.Duration   `toml:"duration"`
+	Types      map[string]Rule `toml:"types"`
+}
+
+// ApplyDefaults sets sane defaults on a new Hue instance.
+func ApplyDefaults(n *Hue) {
+	n.Effect = effectAlert
+	n.Duration = 3 * time.Second
+}
+
+func (n *Hue) Name() string { return "hue" }
+
+// lightState is the subset of a light's state that can be restored.
+type lightState struct {
+	On        bool      `json:"on"`
+	Bri       *int      `json:"bri"`
+	Hue       *int      `json:"hue"`
+	Sat       *int      `json:"sat"`
+	XY        []float64 `json:"xy"`
+	CT        *int      `json:"ct"`
+	ColorMode string    `json:"colormode"`
+}
+
+func (n *Hue) Send(ctx context.Context, notif notifier.Notification) error {
+	if n.Bridge == "" {
+		return errors.New("bridge is required")
+	}
+	if n.AppKey == "" {
+		return errors.New("app_key is required")
+	}
+	if len(n.Lights) == 0 && len(n.Groups) == 0 {
+		return errors.New("at least one light or group is required")
+	}
+
+	eff := Rule{Effect: n.Effect, Color: n.Color, Brightness: n.Brightness, Duration: n.Duration}
+	if typed, ok := n.Types[notif.NotificationType]; ok {
+		eff = typed
+		if eff.Duration == 0 {
+			eff.Duration = n.Duration
+		}
+	}
+
+	change := map[string]any{}
+	switch eff.Effect {
+	case effectAlert, "":
+		change["alert"] = "select"
+	case effectBreathe:
+		change["alert"] = "lselect"
+	case effectNone:
+	default:
+		return fmt.Errorf("unknown effect %q (want alert, breathe or none)", eff.Effect)
+	}
+	if eff.Color != "" {
+		rgb, err := parseColor(eff.Color)
+		if err != nil {
+			return err
+		}
+		change["on"] = true
+		change["xy"] = rgbToXY(rgb)
+	}
+	if eff.Brightness > 0 {
+		change["on"] = true
+		change["bri"] = min(eff.Brightness, 254)
+	}
+	if len(change) == 0 {
+		return nil
+	}
+
+	// Only colour and brightness changes need restoring; alerts end on their own.
+	temporary := eff.Color != "" || eff.Brightness > 0
+	var saved map[string]lightState
+	if temporary {
+		ids, err := n.affectedLights(ctx)
+		if err != nil {
+			return err
+		}
+		saved, err = n.saveStates(ctx, ids)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := n.apply(ctx, change)
+	if err != nil || !temporary {
+		return err
+	}
+
+	timer := time.NewTimer(eff.Duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	// Restore even when the context ended so the lights are not left changed.
+	restoreCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), restoreTimeout)
+	defer cancel()
+
+	return n.restore(restoreCtx, saved)
+}
+
+func (n *Hue) apply(ctx context.Context, change map[string]any) error {
+	var errs []error
+	for _, id := range n.Lights {
+		err := n.call(ctx, http.MethodPut, "lights/"+url.PathEscape(id)+"/state", change, nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("light %s: %w", id, err))
+		}
+	}
+	for _, id := range n.Groups {
+		err := n.call(ctx, http.MethodPut, "groups/"+url.PathEscape(id)+"/action", change, nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("group %s: %w", id, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// affectedLights returns the configured lights plus the members of the
+// configured groups, without duplicates.
+func (n *Hue) affectedLights(ctx context.Context) ([]string, error) {
+	seen := map[string]bool{}
+	var ids []string
+	add := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, id := range n.Lights {
+		add(id)
+	}
+	for _, gid := range n.Groups {
+		var group struct {
+			Lights []string `json:"lights"`
+		}
+		err := n.call(ctx, http.MethodGet, "groups/"+url.PathEscape(gid), nil, &group)
+		if err != nil {
+			return nil, fmt.Errorf("group %s: %w", gid, err)
+		}
+		for _, id := range group.Lights {
+			add(id)
+		}
+	}
+
+	return ids, nil
+}
+
+func (n *Hue) saveStates(ctx context.Context, ids []string) (map[string]lightState, error) {
+	saved := make(map[string]lightState, len(ids))
+	for _, id := range ids {
+		var light struct {
+			State lightState `json:"state"`
+		}
+		err := n.call(ctx, http.MethodGet, "lights/"+url.PathEscape(id), nil, &light)
+		if err != nil {
+			return nil, fmt.Errorf("light %s: %w", id, err)
+		}
+		saved[id] = light.State
+	}
+
+	return saved, nil
+}
+
+func (n *Hue) restore(ctx context.Context, saved map[string]lightState) error {
+	var errs []error
+	for id, state := range saved {
+		err := n.call(ctx, http.MethodPut, "lights/"+url.PathEscape(id)+"/state", restoreBody(state), nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("restoring light %s: %w", id, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// restoreBody builds the state change that puts a light back the way it was,
+// sending only the colour attributes of its previous colour mode.
+func restoreBody(s lightState) map[string]any {
+	body := map[string]any{"on": s.On, "alert": "none"}
+	if s.Bri != nil {
+		body["bri"] = *s.Bri
+	}
+	switch s.ColorMode {
+	case "xy":
+		if len(s.XY) == 2 {
+			body["xy"] = s.XY
+		}
+	case "ct":
+		if s.CT != nil {
+			body["ct"] = *s.CT
+		}
+	case "hs":
+		if s.Hue != nil {
+			body["hue"] = *s.Hue
+		}
+		if s.Sat != nil {
+			body["sat"] = *s.Sat
+		}
+	}
+
+	return body
+}
+
+// baseURL accepts a bare host or IP, or a full URL (useful for tests).
+func (n *Hue) baseURL() string {
+	base := n.Bridge
+	if !strings.Contains(base, "://") {
+		base = "http://" + base
+	}
+
+	return strings.TrimRight(base, "/") + "/api/" + url.PathEscape(n.AppKey) + "/"
+}
+
+// call performs one bridge request. The v1 API reports most failures as a
+// 200 response with a list of {"error": ...} objects.
+func (n *Hue) call(ctx context.Context, method, path string, body, result any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, n.baseURL()+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode >= httpErrorStatus {
+		return fmt.Errorf("bridge returned %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+
+	var results []struct {
+		Error *struct {
+			Description string `json:"description"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &results) == nil {
+		for _, r := range results {
+			if r.Error != nil {
+				return fmt.Errorf("bridge error: %s", r.Error.Description)
+			}
+		}
+
+		return nil
+	}
+
+	if result != nil {
+		err = json.Unmarshal(data, result)
+		if err != nil {
+			return fmt.Errorf("decoding response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// parseColor accepts "#rrggbb", "rrggbb" or one of the named colours.
+func parseColor(s string) ([3]uint8, error) {
+	if rgb, ok := namedColors[strings.ToLower(s)]; ok {
+		return rgb, nil
+	}
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) != 6 {
+		return [3]uint8{}, fmt.Errorf("invalid color %q (want #rrggbb or a color name)", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return [3]uint8{}, fmt.Errorf("invalid color %q (want #rrggbb or a color name)", s)
+	}
+
+	return [3]uint8{uint8(v >> 16), uint8(v >> 8), uint8(v)}, nil
+}
+
+// rgbToXY converts sRGB to CIE 1931 xy using the conversion Philips
+// documents for Hue lights.
+func rgbToXY(rgb [3]uint8) []float64 {
+	gamma := func(c uint8) float64 {
+		v := float64(c) / 255
+		if v > 0.04045 {
+			return math.Pow((v+0.055)/1.055, 2.4)
+		}
+
+		return v / 12.92
+	}
+	r, g, b := gamma(rgb[0]), gamma(rgb[1]), gamma(rgb[2])
+
+	x := r*0.664511 + g*0.154324 + b*0.162028
+	y := r*0.283881 + g*0.668433 + b*0.047685
+	z := r*0.000088 + g*0.072310 + b*0.986039
+	sum := x + y + z
+	if sum == 0 {
+		return []float64{0.3227, 0.329}
+	}
+
+	round := func(f float64) float64 { return math.Round(f*10000) / 10000 }
+
+	return []float64{round(x / sum), round(y / sum)}
+}
+
+// SampleConfig returns example TOML configuration.
+func (n *Hue) SampleConfig() string {
+	return `## Philips Hue light effects via the bridge's local REST API
+## Create an app key: https://developers.meethue.com/develop/get-started-2/
+[[notifiers.hue]]
+
+## Bridge IP or host name (required); a full URL is also accepted
+bridge = "192.168.1.2"
+
+## Bridge app key, a.k.a. username (required)
+app_key = ""
+
+## Light IDs and/or group (room, zone) IDs to use
+lights = ["1"]
+# groups = []
+
+## Default effect for notification types without an entry below
+## effect: "alert" (one flash), "breathe" (breathe for ~15s) or "none"
+## color: "#rrggbb" or red, green, blue, amber, orange, yellow, purple, white
+## brightness: 1-254
+## A color or brightness change is undone after duration, restoring each
+## light's previous state
+effect = "alert"
+# color = ""
+# brightness = 0
+# duration = "3s"
+
+## Per-NotificationType effects
+# [notifiers.hue.types.permission_prompt]
+# effect = "breathe"
+# color = "amber"
+#
+# [notifiers.hue.types.idle_prompt]
+# effect = "none"
+# color = "green"
+# duration = "5s"
+`
+}
+
+// Register adds hue to the given plugin registry.
+func Register(reg *notifier.Registry) {
+	err := reg.Register("hue", func() notifier.Notifier {
+		n := &Hue{}
+		ApplyDefaults(n)
+
+		return n
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,197 @@
+package hue_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/plugins/hue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type call struct {
+	method string
+	path   string
+	body   map[string]any
+}
+
+// fakeBridge serves light and group state and records every request.
+type fakeBridge struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	calls []call
+}
+
+func newFakeBridge(t *testing.T) *fakeBridge {
+	t.Helper()
+	fb := &fakeBridge{}
+	fb.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if r.Body != nil {
+			data, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(data, &body)
+		}
+		fb.mu.Lock()
+		fb.calls = append(fb.calls, call{r.Method, r.URL.Path, body})
+		fb.mu.Unlock()
+
+		if !strings.HasPrefix(r.URL.Path, "/api/key/") {
+			_, _ = io.WriteString(w, `[{"error":{"type":1,"address":"/","description":"unauthorized user"}}]`)
+
+			return
+		}
+		switch r.URL.Path {
+		case "/api/key/lights/1":
+			_, _ = io.WriteString(w, `{"state":{"on":true,"bri":200,"ct":366,"xy":[0.4,0.4],"colormode":"ct"},"name":"Desk"}`)
+		case "/api/key/lights/2":
+			_, _ = io.WriteString(w, `{"state":{"on":false,"bri":10,"xy":[0.1,0.2],"colormode":"xy"},"name":"Shelf"}`)
+		case "/api/key/groups/5":
+			_, _ = io.WriteString(w, `{"name":"Office","lights":["1","2"]}`)
+		default:
+			_, _ = io.WriteString(w, `[{"success":{}}]`)
+		}
+	}))
+	t.Cleanup(fb.Close)
+
+	return fb
+}
+
+func (fb *fakeBridge) puts() []call {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	var out []call
+	for _, c := range fb.calls {
+		if c.method == http.MethodPut {
+			out = append(out, c)
+		}
+	}
+
+	return out
+}
+
+func TestHueName(t *testing.T) {
+	p := &hue.Hue{}
+	assert.Equal(t, "hue", p.Name())
+}
+
+func TestHueDefaults(t *testing.T) {
+	p := &hue.Hue{}
+	hue.ApplyDefaults(p)
+	assert.Equal(t, "alert", p.Effect)
+	assert.Equal(t, 3*time.Second, p.Duration)
+}
+
+func TestHueImplementsNotifier(t *testing.T) {
+	var _ notifier.Notifier = &hue.Hue{}
+}
+
+func TestHueAlert(t *testing.T) {
+	fb := newFakeBridge(t)
+
+	p := &hue.Hue{}
+	hue.ApplyDefaults(p)
+	p.Bridge = fb.URL
+	p.AppKey = "key"
+	p.Lights = []string{"1"}
+	p.Groups = []string{"5"}
+
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{NotificationType: "idle_prompt"}))
+
+	puts := fb.puts()
+	require.Len(t, puts, 2)
+	assert.Equal(t, "/api/key/lights/1/state", puts[0].path)
+	assert.Equal(t, map[string]any{"alert": "select"}, puts[0].body)
+	assert.Equal(t, "/api/key/groups/5/action", puts[1].path)
+	assert.Equal(t, map[string]any{"alert": "select"}, puts[1].body)
+}
+
+func TestHueTemporaryColorIsRestored(t *testing.T) {
+	fb := newFakeBridge(t)
+
+	p := &hue.Hue{}
+	hue.ApplyDefaults(p)
+	p.Bridge = strings.TrimPrefix(fb.URL, "http://")
+	p.AppKey = "key"
+	p.Groups = []string{"5"}
+	p.Types = map[string]hue.Rule{
+		"permission_prompt": {Effect: "breathe", Color: "amber", Brightness: 300, Duration: 10 * time.Millisecond},
+	}
+
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{NotificationType: "permission_prompt"}))
+
+	puts := fb.puts()
+	require.Len(t, puts, 3)
+	assert.Equal(t, "/api/key/groups/5/action", puts[0].path)
+	assert.Equal(t, "lselect", puts[0].body["alert"])
+	assert.Equal(t, true, puts[0].body["on"])
+	assert.InDelta(t, 254, puts[0].body["bri"], 0)
+	xy := puts[0].body["xy"].([]any)
+	assert.InDelta(t, 0.5265, xy[0], 0.001)
+	assert.InDelta(t, 0.4468, xy[1], 0.001)
+
+	restored := map[string]map[string]any{}
+	for _, c := range puts[1:] {
+		restored[c.path] = c.body
+	}
+	assert.Equal(t, map[string]any{"on": true, "alert": "none", "bri": float64(200), "ct": float64(366)},
+		restored["/api/key/lights/1/state"])
+	assert.Equal(t, map[string]any{"on": false, "alert": "none", "bri": float64(10), "xy": []any{0.1, 0.2}},
+		restored["/api/key/lights/2/state"])
+}
+
+func TestHueRestoresWhenContextEnds(t *testing.T) {
+	fb := newFakeBridge(t)
+
+	p := &hue.Hue{Bridge: fb.URL, AppKey: "key", Lights: []string{"2"}, Effect: "none", Color: "#00ff00", Duration: time.Hour}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	require.NoError(t, p.Send(ctx, notifier.Notification{}))
+	assert.Less(t, time.Since(start), time.Second)
+
+	puts := fb.puts()
+	require.Len(t, puts, 2)
+	assert.NotContains(t, puts[0].body, "alert")
+	assert.Equal(t, false, puts[1].body["on"])
+}
+
+func TestHueBridgeError(t *testing.T) {
+	fb := newFakeBridge(t)
+
+	p := &hue.Hue{Bridge: fb.URL, AppKey: "wrong", Lights: []string{"1"}}
+	err := p.Send(context.Background(), notifier.Notification{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "light 1")
+	assert.Contains(t, err.Error(), "unauthorized user")
+}
+
+func TestHueValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		hue  hue.Hue
+		want string
+	}{
+		{"no bridge", hue.Hue{AppKey: "k", Lights: []string{"1"}}, "bridge is required"},
+		{"no key", hue.Hue{Bridge: "127.0.0.1:1", Lights: []string{"1"}}, "app_key is required"},
+		{"no lights", hue.Hue{Bridge: "127.0.0.1:1", AppKey: "k"}, "at least one light or group"},
+		{"bad effect", hue.Hue{Bridge: "127.0.0.1:1", AppKey: "k", Lights: []string{"1"}, Effect: "strobe"}, "unknown effect"},
+		{"bad color", hue.Hue{Bridge: "127.0.0.1:1", AppKey: "k", Lights: []string{"1"}, Color: "#zzz"}, "invalid color"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.hue.Send(context.Background(), notifier.Notification{})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
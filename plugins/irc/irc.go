@@ -0,0 +1,540 @@
+package irc
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/tmpl"
+)
+
+const (
+	dialTimeout = 30 * time.Second
+	// maxLineLen is the IRC line limit including the trailing CRLF.
+	maxLineLen = 512
+	// prefixReserve leaves room for the ":nick!user@host " prefix the server
+	// prepends when relaying our message to other clients.
+	prefixReserve  = 100
+	maxNickRetries = 3
+	saslChunkSize  = 400
+	// minMessageRoom is the least room per line a target may leave for the
+	// message itself.
+	minMessageRoom = 64
+)
+
+// IRC sends notifications as PRIVMSG or NOTICE lines to an IRC channel or nick.
+type IRC struct {
+	Server             string            `toml:"server"`
+	TLS                bool              `toml:"tls"`
+	InsecureSkipVerify bool              `toml:"insecure_skip_verify"`
+	Password           string            `toml:"password"`
+	Nick               string            `toml:"nick"`
+	User               string            `toml:"user"`
+	RealName           string            `toml:"realname"`
+	SASLUsername       string            `toml:"sasl_username"`
+	SASLPassword       string            `toml:"sasl_password"`
+	Target             string            `toml:"target"`
+	Join               bool              `toml:"join"`
+	ChannelKey         string            `toml:"channel_key"`
+	Notice             bool              `toml:"notice"`
+	Message            string            `toml:"message"`
+	QuitMessage        string            `toml:"quit_message"`
+	Vars               map[string]string `toml:"vars"`
+}
+
+// ApplyDefaults sets sane defaults on a new IRC instance.
+func ApplyDefaults(n *IRC) {
+	n.TLS = true
+	n.Nick = "claude-notifier"
+	n.User = "claude"
+	n.RealName = "claude-notifier"
+	n.Join = true
+	n.Message = "[{{.Project}}] {{.Message}}"
+	n.QuitMessage = "bye"
+}
+
+func (n *IRC) Name() string { return "irc" }
+
+func (n *IRC) Send(ctx context.Context, notif notifier.Notification) error {
+	if n.Server == "" {
+		return errors.New("server is required")
+	}
+	if n.Target == "" {
+		return errors.New("target is required")
+	}
+	if n.Nick == "" {
+		return errors.New("nick is required")
+	}
+	if n.messageRoom() < minMessageRoom {
+		return fmt.Errorf("target %q is too long to leave room for a message", n.Target)
+	}
+
+	tctx := tmpl.BuildContext(notif, n.Vars)
+
+	msgTmpl := n.Message
+	if msgTmpl == "" {
+		msgTmpl = "[{{.Project}}] {{.Message}}"
+	}
+	body, err := tmpl.Render("message", msgTmpl, tctx)
+	if err != nil {
+		return err
+	}
+
+	conn, err := n.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	// Unblock any pending read or write as soon as the context ends.
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c := &client{conn: conn, r: bufio.NewReader(conn)}
+	err = n.session(c, body)
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("irc session: %w", ctx.Err())
+	}
+
+	return err
+}
+
+func (n *IRC) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	if !n.TLS {
+		conn, err := dialer.DialContext(ctx, "tcp", n.Server)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to %s: %w", n.Server, err)
+		}
+
+		return conn, nil
+	}
+
+	host, _, err := net.SplitHostPort(n.Server)
+	if err != nil {
+		return nil, fmt.Errorf("parsing server address: %w", err)
+	}
+	tlsDialer := &tls.Dialer{
+		NetDialer: dialer,
+		Config: &tls.Config{
+			ServerName:         host,
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: n.InsecureSkipVerify, //nolint:gosec // opt-in for self-signed servers
+		},
+	}
+	conn, err := tlsDialer.DialContext(ctx, "tcp", n.Server)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", n.Server, err)
+	}
+
+	return conn, nil
+}
+
+// session runs the registration, optional join, message delivery and quit.
+func (n *IRC) session(c *client, body string) error {
+	err := n.register(c)
+	if err != nil {
+		return err
+	}
+
+	if n.Join && isChannel(n.Target) {
+		err = n.join(c)
+		if err != nil {
+			return err
+		}
+	}
+
+	command := n.command()
+	target := sanitize(n.Target)
+	for _, line := range splitMessage(body, n.messageRoom()) {
+		err = c.send(command + " " + target + " :" + line)
+		if err != nil {
+			return err
+		}
+	}
+
+	return n.quit(c)
+}
+
+func (n *IRC) command() string {
+	if n.Notice {
+		return "NOTICE"
+	}
+
+	return "PRIVMSG"
+}
+
+// messageRoom is how many bytes of message fit in one line after the
+// command, the target and the prefix the server adds when relaying it.
+func (n *IRC) messageRoom() int {
+	overhead := len(n.command()) + len(sanitize(n.Target)) + len("  :\r\n") + prefixReserve
+
+	return maxLineLen - overhead
+}
+
+func (n *IRC) register(c *client) error {
+	useSASL := n.SASLUsername != ""
+	if useSASL {
+		err := c.send("CAP REQ :sasl")
+		if err != nil {
+			return err
+		}
+	}
+	if n.Password != "" {
+		err := c.send("PASS " + sanitize(n.Password))
+		if err != nil {
+			return err
+		}
+	}
+
+	nick := sanitize(n.Nick)
+	user := n.User
+	if user == "" {
+		user = nick
+	}
+	realName := n.RealName
+	if realName == "" {
+		realName = nick
+	}
+	err := c.send("NICK " + nick)
+	if err != nil {
+		return err
+	}
+	err = c.send("USER " + sanitize(user) + " 0 * :" + sanitize(realName))
+	if err != nil {
+		return err
+	}
+
+	retries := 0
+	for {
+		msg, err := c.read()
+		if err != nil {
+			return fmt.Errorf("registering: %w", err)
+		}
+
+		switch msg.command {
+		case "001":
+			return nil
+		case "CAP":
+			err = n.handleCap(c, msg)
+		case "AUTHENTICATE":
+			err = n.authenticate(c, msg)
+		case "903":
+			err = c.send("CAP END")
+		case "902", "904", "905", "906", "908":
+			return fmt.Errorf("SASL authentication failed: %s", msg.trailing())
+		case "432", "433", "436":
+			if retries >= maxNickRetries {
+				return fmt.Errorf("nick rejected: %s", msg.trailing())
+			}
+			retries++
+			nick += "_"
+			err = c.send("NICK " + nick)
+		case "464", "465":
+			return fmt.Errorf("server rejected connection: %s", msg.trailing())
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (n *IRC) handleCap(c *client, msg message) error {
+	if len(msg.params) < 2 {
+		return nil
+	}
+	switch msg.params[1] {
+	case "ACK":
+		return c.send("AUTHENTICATE PLAIN")
+	case "NAK":
+		return errors.New("server does not support SASL")
+	}
+
+	return nil
+}
+
+func (n *IRC) authenticate(c *client, msg message) error {
+	if len(msg.params) == 0 || msg.params[0] != "+" {
+		return nil
+	}
+	payload := base64.StdEncoding.EncodeToString([]byte(n.SASLUsername + "\x00" + n.SASLUsername + "\x00" + n.SASLPassword))
+	for len(payload) >= saslChunkSize {
+		err := c.send("AUTHENTICATE " + payload[:saslChunkSize])
+		if err != nil {
+			return err
+		}
+		payload = payload[saslChunkSize:]
+	}
+	if payload == "" {
+		payload = "+"
+	}
+
+	return c.send("AUTHENTICATE " + payload)
+}
+
+func (n *IRC) join(c *client) error {
+	line := "JOIN " + sanitize(n.Target)
+	if n.ChannelKey != "" {
+		line += " " + sanitize(n.ChannelKey)
+	}
+	err := c.send(line)
+	if err != nil {
+		return err
+	}
+
+	for {
+		msg, err := c.read()
+		if err != nil {
+			return fmt.Errorf("joining %s: %w", n.Target, err)
+		}
+		switch msg.command {
+		case "366": // RPL_ENDOFNAMES
+			return nil
+		case "403", "405", "471", "473", "474", "475", "477":
+			return fmt.Errorf("joining %s: %s", n.Target, msg.trailing())
+		default:
+			// Any other error numeric about the channel, e.g. 476 (bad
+			// channel mask) or 480 (cannot join), also ends the join.
+			if isErrorFor(msg, n.Target) {
+				return fmt.Errorf("joining %s: %s", n.Target, msg.trailing())
+			}
+		}
+	}
+}
+
+// isErrorFor reports whether msg is an error numeric (400-599) naming
+// channel.
+func isErrorFor(msg message, channel string) bool {
+	code, err := strconv.Atoi(msg.command)
+	if err != nil || len(msg.command) != 3 || code < 400 || code > 599 {
+		return false
+	}
+
+	return slices.ContainsFunc(msg.params, func(p string) bool { return strings.EqualFold(p, channel) })
+}
+
+func (n *IRC) quit(c *client) error {
+	line := "QUIT"
+	if n.QuitMessage != "" {
+		line += " :" + sanitize(n.QuitMessage)
+	}
+	err := c.send(line)
+	if err != nil {
+		return err
+	}
+
+	// Drain until the server acknowledges with ERROR or closes the link.
+	for {
+		msg, err := c.read()
+		if err != nil || msg.command == "ERROR" {
+			return nil
+		}
+	}
+}
+
+// SampleConfig returns example TOML configuration.
+func (n *IRC) SampleConfig() string {
+	return `## IRC messages
+[[notifiers.irc]]
+
+## Server address as host:port (required)
+server = "irc.libera.chat:6697"
+
+## Connect over TLS
+# tls = true
+
+## Skip TLS certificate verification (self-signed servers only)
+# insecure_skip_verify = false
+
+## Channel or nick to message (required)
+target = "#my-channel"
+
+## Join the channel before sending (required by most +n channels)
+# join = true
+
+## Channel key for +k channels
+# channel_key = ""
+
+## Send NOTICE instead of PRIVMSG
+# notice = false
+
+## Nick, username and real name
+# nick = "claude-notifier"
+# user = "claude"
+# realname = "claude-notifier"
+
+## Server password (PASS)
+# password = ""
+
+## SASL PLAIN credentials
+# sasl_username = ""
+# sasl_password = ""
+
+## Go template for the message
+## Newlines are collapsed and long messages are split across several lines
+## Available variables: {{.Message}}, {{.Title}}, {{.Project}}, {{.Cwd}},
+## {{.NotificationType}}, {{.SessionID}}, {{.TranscriptPath}}, {{.Severity}}
+## Custom variables from [notifiers.irc.vars] are also available, title-cased
+# message = "[{{.Project}}] {{.Message}}"
+
+## Message sent with QUIT
+# quit_message = "bye"
+
+## User-defined template variables
+## Keys are title-cased for template access (env -> {{.Env}})
+# [notifiers.irc.vars]
+# env = "production"
+`
+}
+
+type client struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func (c *client) send(line string) error {
+	_, err := c.conn.Write([]byte(line + "\r\n"))
+	if err != nil {
+		return fmt.Errorf("writing to server: %w", err)
+	}
+
+	return nil
+}
+
+// read returns the next message from the server, answering PINGs and
+// surfacing ERROR lines as they arrive.
+func (c *client) read() (message, error) {
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return message{}, fmt.Errorf("reading from server: %w", err)
+		}
+		msg := parseMessage(strings.TrimRight(line, "\r\n"))
+		switch msg.command {
+		case "":
+			continue
+		case "PING":
+			err = c.send("PONG :" + msg.trailing())
+			if err != nil {
+				return message{}, err
+			}
+
+			continue
+		case "ERROR":
+			return msg, fmt.Errorf("server error: %s", msg.trailing())
+		}
+
+		return msg, nil
+	}
+}
+
+type message struct {
+	prefix  string
+	command string
+	params  []string
+}
+
+func (m message) trailing() string {
+	if len(m.params) == 0 {
+		return ""
+	}
+
+	return m.params[len(m.params)-1]
+}
+
+func parseMessage(line string) message {
+	var msg message
+	if strings.HasPrefix(line, "@") { // IRCv3 tags are ignored
+		_, line, _ = strings.Cut(line, " ")
+	}
+	if strings.HasPrefix(line, ":") {
+		msg.prefix, line, _ = strings.Cut(line[1:], " ")
+	}
+	for line != "" {
+		if strings.HasPrefix(line, ":") {
+			msg.params = append(msg.params, line[1:])
+
+			break
+		}
+		var param string
+		param, line, _ = strings.Cut(line, " ")
+		if param == "" {
+			continue
+		}
+		if msg.command == "" {
+			msg.command = strings.ToUpper(param)
+		} else {
+			msg.params = append(msg.params, param)
+		}
+	}
+
+	return msg
+}
+
+func isChannel(target string) bool {
+	return target != "" && strings.ContainsRune("#&+!", rune(target[0]))
+}
+
+// sanitize removes characters that would let a value break out of its line.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\r' || r == '\n' || r == 0 {
+			return -1
+		}
+
+		return r
+	}, s)
+}
+
+// splitMessage collapses newlines and splits text into chunks of at most
+// limit bytes, preferring word boundaries and never splitting a UTF-8 rune.
+func splitMessage(text string, limit int) []string {
+	// A whole rune must always fit, or the text would never shrink.
+	limit = max(limit, utf8.UTFMax)
+	text = strings.Join(strings.Fields(strings.ReplaceAll(text, "\x00", "")), " ")
+	if text == "" {
+		return nil
+	}
+
+	var lines []string
+	for len(text) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		if idx := strings.LastIndexByte(text[:cut], ' '); idx > limit/2 {
+			cut = idx
+		}
+		lines = append(lines, strings.TrimSpace(text[:cut]))
+		text = strings.TrimSpace(text[cut:])
+	}
+
+	return append(lines, text)
+}
+
+// Register adds irc to the given plugin registry.
+func Register(reg *notifier.Registry) {
+	err := reg.Register("irc", func() notifier.Notifier {
+		n := &IRC{}
+		ApplyDefaults(n)
+
+		return n
+	})
+	if err != nil {
+		panic(err)
+	}
+}
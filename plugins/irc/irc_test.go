@@ -0,0 +1,383 @@
+package irc_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/plugins/irc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer is an in-process, line-based IRC server. The reply function
+// returns the lines to send back for every line received from the client.
+type fakeServer struct {
+	addr  string
+	mu    sync.Mutex
+	lines []string
+	done  chan struct{}
+}
+
+func newFakeServer(t *testing.T, listener net.Listener, reply func(line string) []string) *fakeServer {
+	t.Helper()
+	srv := &fakeServer{addr: listener.Addr().String(), done: make(chan struct{})}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		defer close(srv.done)
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			line := strings.TrimRight(scanner.Text(), "\r")
+			srv.mu.Lock()
+			srv.lines = append(srv.lines, line)
+			srv.mu.Unlock()
+			for _, out := range reply(line) {
+				_, _ = conn.Write([]byte(out + "\r\n"))
+			}
+			if strings.HasPrefix(line, "QUIT") {
+				return
+			}
+		}
+	}()
+
+	return srv
+}
+
+func (s *fakeServer) received(t *testing.T) []string {
+	t.Helper()
+	select {
+	case <-s.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("fake server did not finish")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.lines...)
+}
+
+// standardReply welcomes the client after USER and completes JOIN and QUIT.
+func standardReply(line string) []string {
+	switch {
+	case strings.HasPrefix(line, "USER "):
+		return []string{":irc.test 001 claude-notifier :Welcome"}
+	case strings.HasPrefix(line, "JOIN "):
+		channel := strings.Fields(line)[1]
+		return []string{
+			":claude-notifier!claude@host JOIN " + channel,
+			":irc.test 353 claude-notifier = " + channel + " :claude-notifier",
+			":irc.test 366 claude-notifier " + channel + " :End of /NAMES list.",
+		}
+	case strings.HasPrefix(line, "QUIT"):
+		return []string{"ERROR :Closing Link"}
+	}
+
+	return nil
+}
+
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	return l
+}
+
+func filter(lines []string, prefix string) []string {
+	var out []string
+	for _, l := range lines {
+		if strings.HasPrefix(l, prefix) {
+			out = append(out, l)
+		}
+	}
+
+	return out
+}
+
+func TestIRCName(t *testing.T) {
+	p := &irc.IRC{}
+	assert.Equal(t, "irc", p.Name())
+}
+
+func TestIRCDefaults(t *testing.T) {
+	p := &irc.IRC{}
+	irc.ApplyDefaults(p)
+	assert.True(t, p.TLS)
+	assert.True(t, p.Join)
+	assert.Equal(t, "claude-notifier", p.Nick)
+	assert.Equal(t, "[{{.Project}}] {{.Message}}", p.Message)
+}
+
+func TestIRCImplementsNotifier(t *testing.T) {
+	var _ notifier.Notifier = &irc.IRC{}
+}
+
+func TestIRCSend(t *testing.T) {
+	srv := newFakeServer(t, listen(t), standardReply)
+
+	p := &irc.IRC{}
+	irc.ApplyDefaults(p)
+	p.Server = srv.addr
+	p.TLS = false
+	p.Target = "#ops"
+
+	err := p.Send(context.Background(), notifier.Notification{
+		Message: "Claude needs permission",
+		Cwd:     "/home/user/billing-api",
+	})
+	require.NoError(t, err)
+
+	lines := srv.received(t)
+	assert.Equal(t, "NICK claude-notifier", lines[0])
+	assert.Equal(t, "USER claude 0 * :claude-notifier", lines[1])
+	assert.Contains(t, lines, "JOIN #ops")
+	assert.Contains(t, lines, "PRIVMSG #ops :[billing-api] Claude needs permission")
+	assert.Equal(t, "QUIT :bye", lines[len(lines)-1])
+}
+
+func TestIRCNoticeWithoutJoin(t *testing.T) {
+	srv := newFakeServer(t, listen(t), standardReply)
+
+	p := &irc.IRC{
+		Server:  srv.addr,
+		Nick:    "claude-notifier",
+		Target:  "#ops",
+		Notice:  true,
+		Message: "{{.Message}}",
+	}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.NoError(t, err)
+
+	lines := srv.received(t)
+	assert.Empty(t, filter(lines, "JOIN"))
+	assert.Contains(t, lines, "NOTICE #ops :hi")
+}
+
+func TestIRCStripsNewlinesAndSplitsLongMessages(t *testing.T) {
+	srv := newFakeServer(t, listen(t), standardReply)
+
+	p := &irc.IRC{
+		Server:  srv.addr,
+		Nick:    "claude-notifier",
+		Target:  "#ops",
+		Message: "{{.Message}}",
+	}
+	long := "line one\r\nQUIT :injected\n" + strings.Repeat("word ", 200)
+	err := p.Send(context.Background(), notifier.Notification{Message: long})
+	require.NoError(t, err)
+
+	lines := srv.received(t)
+	privmsgs := filter(lines, "PRIVMSG #ops :")
+	require.Greater(t, len(privmsgs), 1)
+	assert.True(t, strings.HasPrefix(privmsgs[0], "PRIVMSG #ops :line one QUIT :injected word"))
+	for _, l := range privmsgs {
+		assert.LessOrEqual(t, len(l)+2, 512-100)
+	}
+	assert.Len(t, filter(lines, "QUIT"), 1)
+}
+
+func TestIRCSASLPlain(t *testing.T) {
+	var gotAuth string
+	srv := newFakeServer(t, listen(t), func(line string) []string {
+		switch {
+		case line == "CAP REQ :sasl":
+			return []string{":irc.test CAP * ACK :sasl"}
+		case line == "AUTHENTICATE PLAIN":
+			return []string{"AUTHENTICATE +"}
+		case strings.HasPrefix(line, "AUTHENTICATE "):
+			gotAuth = strings.TrimPrefix(line, "AUTHENTICATE ")
+			return []string{":irc.test 903 claude-notifier :SASL authentication successful"}
+		case line == "CAP END":
+			return []string{":irc.test 001 claude-notifier :Welcome"}
+		case strings.HasPrefix(line, "USER "):
+			return nil // registration is held until CAP END
+		}
+
+		return standardReply(line)
+	})
+
+	p := &irc.IRC{
+		Server:       srv.addr,
+		Nick:         "claude-notifier",
+		SASLUsername: "bot",
+		SASLPassword: "hunter2",
+		Target:       "someone",
+		Join:         true,
+		Message:      "{{.Message}}",
+	}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.NoError(t, err)
+
+	lines := srv.received(t)
+	decoded, err := base64.StdEncoding.DecodeString(gotAuth)
+	require.NoError(t, err)
+	assert.Equal(t, "bot\x00bot\x00hunter2", string(decoded))
+	assert.Contains(t, lines, "CAP END")
+	// Nicks are not channels, so no JOIN is sent.
+	assert.Empty(t, filter(lines, "JOIN"))
+	assert.Contains(t, lines, "PRIVMSG someone :hi")
+}
+
+func TestIRCSASLFailure(t *testing.T) {
+	srv := newFakeServer(t, listen(t), func(line string) []string {
+		switch line {
+		case "CAP REQ :sasl":
+			return []string{":irc.test CAP * ACK :sasl"}
+		case "AUTHENTICATE PLAIN":
+			return []string{"AUTHENTICATE +"}
+		}
+		if strings.HasPrefix(line, "AUTHENTICATE ") {
+			return []string{":irc.test 904 claude-notifier :SASL authentication failed"}
+		}
+
+		return nil
+	})
+
+	p := &irc.IRC{
+		Server:       srv.addr,
+		Nick:         "claude-notifier",
+		SASLUsername: "bot",
+		SASLPassword: "wrong",
+		Target:       "#ops",
+	}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SASL authentication failed")
+}
+
+func TestIRCNickInUse(t *testing.T) {
+	srv := newFakeServer(t, listen(t), func(line string) []string {
+		switch line {
+		case "NICK claude-notifier":
+			return []string{":irc.test 433 * claude-notifier :Nickname is already in use"}
+		case "NICK claude-notifier_":
+			return []string{":irc.test 001 claude-notifier_ :Welcome"}
+		}
+
+		return standardReply(strings.Replace(line, "USER", "IGNORED", 1))
+	})
+
+	p := &irc.IRC{Server: srv.addr, Nick: "claude-notifier", Target: "#ops", Message: "{{.Message}}"}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.NoError(t, err)
+	assert.Contains(t, srv.received(t), "PRIVMSG #ops :hi")
+}
+
+func TestIRCJoinFailure(t *testing.T) {
+	for _, reply := range []string{
+		":irc.test 474 claude-notifier #ops :Cannot join channel (+b)",
+		":irc.test 476 claude-notifier #ops :Cannot join channel (bad channel mask)",
+		":irc.test 480 claude-notifier #ops :Cannot join channel (SSL only)",
+	} {
+		t.Run(strings.Fields(reply)[1], func(t *testing.T) {
+			srv := newFakeServer(t, listen(t), func(line string) []string {
+				if strings.HasPrefix(line, "JOIN ") {
+					return []string{reply}
+				}
+
+				return standardReply(line)
+			})
+
+			p := &irc.IRC{Server: srv.addr, Nick: "claude-notifier", Target: "#ops", Join: true}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err := p.Send(ctx, notifier.Notification{Message: "hi"})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "Cannot join channel")
+			assert.NoError(t, ctx.Err(), "fails on the reply, not the deadline")
+		})
+	}
+}
+
+func TestIRCTargetTooLong(t *testing.T) {
+	p := &irc.IRC{Server: "127.0.0.1:1", Nick: "claude-notifier", Target: "#" + strings.Repeat("x", 400)}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "too long")
+}
+
+func TestIRCRespondsToPing(t *testing.T) {
+	srv := newFakeServer(t, listen(t), func(line string) []string {
+		switch {
+		case strings.HasPrefix(line, "USER "):
+			return []string{"PING :token123"}
+		case line == "PONG :token123":
+			return []string{":irc.test 001 claude-notifier :Welcome"}
+		}
+
+		return standardReply(line)
+	})
+
+	p := &irc.IRC{Server: srv.addr, Nick: "claude-notifier", Target: "#ops", Message: "{{.Message}}"}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.NoError(t, err)
+	assert.Contains(t, srv.received(t), "PONG :token123")
+}
+
+func TestIRCRespectsContextDeadline(t *testing.T) {
+	// Server accepts but never welcomes the client.
+	srv := newFakeServer(t, listen(t), func(string) []string { return nil })
+
+	p := &irc.IRC{Server: srv.addr, Nick: "claude-notifier", Target: "#ops"}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := p.Send(ctx, notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestIRCTLS(t *testing.T) {
+	// Borrow httptest's self-signed certificate for the TLS listener.
+	tlsSrv := httptest.NewTLSServer(http.NotFoundHandler())
+	tlsSrv.Close()
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: tlsSrv.TLS.Certificates,
+		MinVersion:   tls.VersionTLS12,
+	})
+	require.NoError(t, err)
+	srv := newFakeServer(t, listener, standardReply)
+
+	p := &irc.IRC{
+		Server:             srv.addr,
+		TLS:                true,
+		InsecureSkipVerify: true,
+		Nick:               "claude-notifier",
+		Target:             "#ops",
+		Message:            "{{.Message}}",
+	}
+	err = p.Send(context.Background(), notifier.Notification{Message: "secure"})
+	require.NoError(t, err)
+	assert.Contains(t, srv.received(t), "PRIVMSG #ops :secure")
+}
+
+func TestIRCRequiresConfig(t *testing.T) {
+	p := &irc.IRC{}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "server")
+}
+
+func TestIRCBadTemplate(t *testing.T) {
+	p := &irc.IRC{Server: "127.0.0.1:1", Nick: "n", Target: "#ops", Message: "{{.Invalid"}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rendering message template")
+}
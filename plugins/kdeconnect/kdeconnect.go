@@ -0,0 +1,276 @@
+package kdeconnect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/felipeelias/claude-notifier/internal/dbus"
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/tmpl"
+)
+
+const (
+	transportAuto = "auto"
+	transportDBus = "dbus"
+	transportCLI  = "cli"
+
+	actionPing  = "ping"
+	actionShare = "share"
+
+	busName        = "org.kde.kdeconnect"
+	daemonPath     = "/modules/kdeconnect"
+	defaultMessage = "Claude Code ({{.Project}}): {{.Message}}"
+)
+
+// KDEConnect sends notifications to paired phones through KDE Connect,
+// over D-Bus or with the kdeconnect-cli binary.
+type KDEConnect struct {
+	Transport string            `toml:"transport"`
+	Action    string            `toml:"action"`
+	Device    string            `toml:"device"`
+	Path      string            `toml:"path"`
+	Message   string            `toml:"message"`
+	Vars      map[string]string `toml:"vars"`
+}
+
+// ApplyDefaults sets sane defaults on a new KDEConnect instance.
+func ApplyDefaults(n *KDEConnect) {
+	n.Transport = transportAuto
+	n.Action = actionPing
+	n.Path = "kdeconnect-cli"
+	n.Message = defaultMessage
+}
+
+func (n *KDEConnect) Name() string { return "kdeconnect" }
+
+// backend is one way of talking to the KDE Connect daemon.
+type backend interface {
+	// devices returns reachable, paired devices as id -> name.
+	devices(ctx context.Context) (map[string]string, error)
+	send(ctx context.Context, id, action, text string) error
+}
+
+func (n *KDEConnect) Send(ctx context.Context, notif notifier.Notification) error {
+	action := n.Action
+	switch action {
+	case actionPing, actionShare:
+	case "":
+		action = actionPing
+	default:
+		return fmt.Errorf("unknown action %q (want ping or share)", action)
+	}
+
+	tctx := tmpl.BuildContext(notif, n.Vars)
+
+	msgTmpl := n.Message
+	if msgTmpl == "" {
+		msgTmpl = defaultMessage
+	}
+	text, err := tmpl.Render("message", msgTmpl, tctx)
+	if err != nil {
+		return err
+	}
+
+	b, closeFn, err := n.backend(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	devices, err := b.devices(ctx)
+	if err != nil {
+		return fmt.Errorf("listing devices: %w", err)
+	}
+	ids, err := selectDevices(devices, n.Device)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, id := range ids {
+		err := b.send(ctx, id, action, text)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sending to %s: %w", devices[id], err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// backend picks the transport. In auto mode D-Bus is used when the daemon
+// is on the session bus, and kdeconnect-cli otherwise.
+func (n *KDEConnect) backend(ctx context.Context) (backend, func(), error) {
+	cli := &cliBackend{path: n.Path}
+	if cli.path == "" {
+		cli.path = "kdeconnect-cli"
+	}
+
+	switch n.Transport {
+	case transportCLI:
+		return cli, func() {}, nil
+	case transportDBus, transportAuto, "":
+	default:
+		return nil, nil, fmt.Errorf("unknown transport %q (want auto, dbus or cli)", n.Transport)
+	}
+
+	conn, err := dbus.SessionBus(ctx)
+	if err == nil {
+		var running bool
+		running, err = conn.NameHasOwner(ctx, busName)
+		if err == nil && !running {
+			err = errors.New(busName + " is not running")
+		}
+		if err == nil {
+			return &dbusBackend{conn: conn}, func() { _ = conn.Close() }, nil
+		}
+		_ = conn.Close()
+	}
+
+	if n.Transport == transportDBus {
+		return nil, nil, fmt.Errorf("connecting to KDE Connect over D-Bus: %w", err)
+	}
+	slog.Debug("kdeconnect: D-Bus unavailable, using kdeconnect-cli", "error", err)
+
+	return cli, func() {}, nil
+}
+
+// selectDevices returns the IDs matching device by ID or, failing that, by
+// case-insensitive name. An empty selector picks every device.
+func selectDevices(devices map[string]string, device string) ([]string, error) {
+	if len(devices) == 0 {
+		return nil, errors.New("no reachable paired devices")
+	}
+	if device == "" {
+		ids := make([]string, 0, len(devices))
+		for id := range devices {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		return ids, nil
+	}
+	if _, ok := devices[device]; ok {
+		return []string{device}, nil
+	}
+	for id, name := range devices {
+		if strings.EqualFold(name, device) {
+			return []string{id}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("device %q is not reachable or not paired", device)
+}
+
+type dbusBackend struct {
+	conn *dbus.Conn
+}
+
+func (b *dbusBackend) devices(ctx context.Context) (map[string]string, error) {
+	body, err := b.conn.Call(ctx, busName, daemonPath, "org.kde.kdeconnect.daemon", "deviceNames", true, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) == 0 {
+		return nil, errors.New("empty deviceNames reply")
+	}
+	var devices map[string]string
+	err = dbus.Decode(body[0], &devices)
+
+	return devices, err
+}
+
+func (b *dbusBackend) send(ctx context.Context, id, action, text string) error {
+	path := dbus.ObjectPath(daemonPath + "/devices/" + id + "/" + action)
+	iface, method := "org.kde.kdeconnect.device.ping", "sendPing"
+	if action == actionShare {
+		iface, method = "org.kde.kdeconnect.device.share", "shareText"
+	}
+	_, err := b.conn.Call(ctx, busName, path, iface, method, text)
+
+	return err
+}
+
+type cliBackend struct {
+	path string
+}
+
+// devices parses "kdeconnect-cli -a --id-name-only", one "<id> <name>" per line.
+func (b *cliBackend) devices(ctx context.Context) (map[string]string, error) {
+	output, err := exec.CommandContext(ctx, b.path, "-a", "--id-name-only").Output()
+	if err != nil {
+		return nil, fmt.Errorf("running %s: %w", b.path, err)
+	}
+	devices := map[string]string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		id, name, _ := strings.Cut(strings.TrimSpace(line), " ")
+		if id != "" {
+			devices[id] = name
+		}
+	}
+
+	return devices, nil
+}
+
+func (b *cliBackend) send(ctx context.Context, id, action, text string) error {
+	flag := "--ping-msg"
+	if action == actionShare {
+		flag = "--share-text"
+	}
+	output, err := exec.CommandContext(ctx, b.path, "-d", id, flag, text).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("running %s: %s: %w", b.path, strings.TrimSpace(string(output)), err)
+	}
+
+	return nil
+}
+
+// SampleConfig returns example TOML configuration.
+func (n *KDEConnect) SampleConfig() string {
+	return `## Phone notifications through KDE Connect (no cloud service involved)
+## https://kdeconnect.kde.org
+[[notifiers.kdeconnect]]
+
+## Transport: "dbus" (talk to the daemon on the session bus), "cli" (run
+## kdeconnect-cli) or "auto" (D-Bus when available, otherwise the CLI)
+# transport = "auto"
+
+## Action: "ping" (notification on the phone) or "share" (share the text)
+# action = "ping"
+
+## Device name or ID; empty sends to every reachable paired device
+## List devices: kdeconnect-cli -a --id-name-only
+# device = ""
+
+## Path to the kdeconnect-cli binary
+# path = "kdeconnect-cli"
+
+## Go template for the message
+## Available variables: {{.Message}}, {{.Title}}, {{.Project}}, {{.Cwd}},
+## {{.NotificationType}}, {{.SessionID}}, {{.TranscriptPath}}, {{.Severity}}
+## Custom variables from [notifiers.kdeconnect.vars] are also available, title-cased
+# message = "Claude Code ({{.Project}}): {{.Message}}"
+
+## User-defined template variables
+## Keys are title-cased for template access (env -> {{.Env}})
+# [notifiers.kdeconnect.vars]
+# env = "production"
+`
+}
+
+// Register adds kdeconnect to the given plugin registry.
+func Register(reg *notifier.Registry) {
+	err := reg.Register("kdeconnect", func() notifier.Notifier {
+		n := &KDEConnect{}
+		ApplyDefaults(n)
+
+		return n
+	})
+	if err != nil {
+		panic(err)
+	}
+}
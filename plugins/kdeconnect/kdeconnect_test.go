@@ -0,0 +1,201 @@
+package kdeconnect_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/felipeelias/claude-notifier/internal/dbus"
+	"github.com/felipeelias/claude-notifier/internal/dbus/dbustest"
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/plugins/kdeconnect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var notif = notifier.Notification{
+	Message: "Claude needs permission",
+	Cwd:     "/home/user/billing-api",
+}
+
+// fakeDaemon serves the KDE Connect daemon with two paired devices.
+func fakeDaemon(t *testing.T) *dbustest.Server {
+	t.Helper()
+	srv := dbustest.NewServer(t, func(call *dbus.Message) ([]any, *dbus.Error) {
+		switch call.Member {
+		case "NameHasOwner":
+			return []any{call.Body[0] == "org.kde.kdeconnect"}, nil
+		case "deviceNames":
+			return []any{map[string]string{"a1b2": "Pixel 8", "c3d4": "Tablet"}}, nil
+		case "sendPing", "shareText":
+			return nil, nil
+		}
+
+		return nil, &dbus.Error{Name: "org.freedesktop.DBus.Error.UnknownMethod"}
+	})
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", srv.Address)
+
+	return srv
+}
+
+// fakeCLI creates a kdeconnect-cli stand-in that lists two devices and logs
+// the args of every send to a file.
+func fakeCLI(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "args.log")
+	script := filepath.Join(dir, "kdeconnect-cli")
+	content := fmt.Sprintf(`#!/bin/sh
+if [ "$1" = "-a" ]; then
+  printf 'a1b2 Pixel 8\nc3d4 Tablet\n'
+  exit 0
+fi
+printf '%%s\n' "$@" >> %s
+`, logFile)
+	require.NoError(t, os.WriteFile(script, []byte(content), 0755))
+
+	return script, logFile
+}
+
+func methodCalls(srv *dbustest.Server) []*dbus.Message {
+	var out []*dbus.Message
+	for _, c := range srv.Calls() {
+		if c.Member == "sendPing" || c.Member == "shareText" {
+			out = append(out, c)
+		}
+	}
+
+	return out
+}
+
+func TestKDEConnectName(t *testing.T) {
+	p := &kdeconnect.KDEConnect{}
+	assert.Equal(t, "kdeconnect", p.Name())
+}
+
+func TestKDEConnectDefaults(t *testing.T) {
+	p := &kdeconnect.KDEConnect{}
+	kdeconnect.ApplyDefaults(p)
+	assert.Equal(t, "auto", p.Transport)
+	assert.Equal(t, "ping", p.Action)
+	assert.Equal(t, "kdeconnect-cli", p.Path)
+	assert.Equal(t, "Claude Code ({{.Project}}): {{.Message}}", p.Message)
+}
+
+func TestKDEConnectImplementsNotifier(t *testing.T) {
+	var _ notifier.Notifier = &kdeconnect.KDEConnect{}
+}
+
+func TestKDEConnectDBusPingByName(t *testing.T) {
+	srv := fakeDaemon(t)
+
+	p := &kdeconnect.KDEConnect{}
+	kdeconnect.ApplyDefaults(p)
+	p.Device = "pixel 8"
+
+	require.NoError(t, p.Send(context.Background(), notif))
+
+	calls := methodCalls(srv)
+	require.Len(t, calls, 1)
+	assert.Equal(t, dbus.ObjectPath("/modules/kdeconnect/devices/a1b2/ping"), calls[0].Path)
+	assert.Equal(t, "org.kde.kdeconnect.device.ping", calls[0].Interface)
+	assert.Equal(t, "org.kde.kdeconnect", calls[0].Destination)
+	assert.Equal(t, []any{"Claude Code (billing-api): Claude needs permission"}, calls[0].Body)
+}
+
+func TestKDEConnectDBusShareToAllDevices(t *testing.T) {
+	srv := fakeDaemon(t)
+
+	p := &kdeconnect.KDEConnect{Transport: "dbus", Action: "share", Message: "{{.Message}}"}
+	require.NoError(t, p.Send(context.Background(), notif))
+
+	calls := methodCalls(srv)
+	require.Len(t, calls, 2)
+	assert.Equal(t, dbus.ObjectPath("/modules/kdeconnect/devices/a1b2/share"), calls[0].Path)
+	assert.Equal(t, dbus.ObjectPath("/modules/kdeconnect/devices/c3d4/share"), calls[1].Path)
+	assert.Equal(t, "shareText", calls[1].Member)
+	assert.Equal(t, []any{"Claude needs permission"}, calls[1].Body)
+}
+
+func TestKDEConnectDBusUnknownDevice(t *testing.T) {
+	fakeDaemon(t)
+
+	p := &kdeconnect.KDEConnect{Transport: "dbus", Device: "iPhone"}
+	err := p.Send(context.Background(), notif)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `device "iPhone" is not reachable or not paired`)
+}
+
+func TestKDEConnectDBusRequiredButMissing(t *testing.T) {
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "unix:path="+filepath.Join(t.TempDir(), "nobus"))
+
+	p := &kdeconnect.KDEConnect{Transport: "dbus"}
+	err := p.Send(context.Background(), notif)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "connecting to KDE Connect over D-Bus")
+}
+
+func TestKDEConnectAutoFallsBackToCLI(t *testing.T) {
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "unix:path="+filepath.Join(t.TempDir(), "nobus"))
+	script, logFile := fakeCLI(t)
+
+	p := &kdeconnect.KDEConnect{}
+	kdeconnect.ApplyDefaults(p)
+	p.Path = script
+	p.Device = "c3d4"
+
+	require.NoError(t, p.Send(context.Background(), notif))
+
+	data, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-d", "c3d4", "--ping-msg", "Claude Code (billing-api): Claude needs permission"},
+		strings.Split(strings.TrimSpace(string(data)), "\n"))
+}
+
+func TestKDEConnectAutoFallsBackWhenDaemonNotOnBus(t *testing.T) {
+	// The bus is up but nothing owns org.kde.kdeconnect.
+	srv := dbustest.NewServer(t, func(*dbus.Message) ([]any, *dbus.Error) { return []any{false}, nil })
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", srv.Address)
+	script, logFile := fakeCLI(t)
+
+	p := &kdeconnect.KDEConnect{Path: script, Action: "share", Device: "Tablet", Message: "{{.Message}}"}
+	require.NoError(t, p.Send(context.Background(), notif))
+
+	data, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-d", "c3d4", "--share-text", "Claude needs permission"},
+		strings.Split(strings.TrimSpace(string(data)), "\n"))
+}
+
+func TestKDEConnectCLINoDevices(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "kdeconnect-cli")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\nexit 0\n"), 0755))
+
+	p := &kdeconnect.KDEConnect{Transport: "cli", Path: script}
+	err := p.Send(context.Background(), notif)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no reachable paired devices")
+}
+
+func TestKDEConnectValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		p    kdeconnect.KDEConnect
+		want string
+	}{
+		{"bad action", kdeconnect.KDEConnect{Action: "ring"}, "unknown action"},
+		{"bad transport", kdeconnect.KDEConnect{Transport: "bluetooth"}, "unknown transport"},
+		{"bad template", kdeconnect.KDEConnect{Message: "{{.Invalid"}, "rendering message template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.p.Send(context.Background(), notif)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package loki
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/tmpl"
+)
+
+const (
+	httpTimeout     = 30 * time.Second
+	httpErrorStatus = 400
+	maxErrorBody    = 1024
+	pushPath        = "/loki/api/v1/push"
+	defaultMessage  = "{{.Message}}"
+)
+
+var (
+	httpClient = &http.Client{
+		Timeout: httpTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+)
+
+// Loki pushes notifications as log lines to a Grafana Loki push endpoint.
+type Loki struct {
+	URL      string            `toml:"url"`
+	Labels   map[string]string `toml:"labels"`
+	Message  string            `toml:"message"`
+	TenantID string            `toml:"tenant_id"`
+	Username string            `toml:"username"`
+	Password string            `toml:"password"`
+	Gzip     bool              `toml:"gzip"`
+	Vars     map[string]string `toml:"vars"`
+}
+
+// ApplyDefaults sets sane defaults on a new Loki instance.
+func ApplyDefaults(n *Loki) {
+	n.URL = "http://127.0.0.1:3100"
+	n.Message = defaultMessage
+}
+
+func (n *Loki) Name() string { return "loki" }
+
+// pushRequest is the JSON body accepted by /loki/api/v1/push.
+type pushRequest struct {
+	Streams []stream `json:"streams"`
+}
+
+type stream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func (n *Loki) Send(ctx context.Context, notif notifier.Notification) error {
+	if n.URL == "" {
+		return errors.New("url is required")
+	}
+
+	tctx := tmpl.BuildContext(notif, n.Vars)
+
+	msgTmpl := n.Message
+	if msgTmpl == "" {
+		msgTmpl = defaultMessage
+	}
+	line, err := tmpl.Render("message", msgTmpl, tctx)
+	if err != nil {
+		return err
+	}
+
+	labels, err := n.labels(notif, tctx)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(pushRequest{Streams: []stream{{
+		Stream: labels,
+		Values: [][2]string{{strconv.FormatInt(time.Now().UnixNano(), 10), line}},
+	}}})
+	if err != nil {
+		return fmt.Errorf("encoding payload: %w", err)
+	}
+
+	var body bytes.Buffer
+	if n.Gzip {
+		zw := gzip.NewWriter(&body)
+		_, _ = zw.Write(payload)
+		err = zw.Close()
+		if err != nil {
+			return fmt.Errorf("compressing payload: %w", err)
+		}
+	} else {
+		body.Write(payload)
+	}
+
+	endpoint := strings.TrimRight(n.URL, "/")
+	if !strings.HasSuffix(endpoint, pushPath) {
+		endpoint += pushPath
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &body)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if n.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", n.TenantID)
+	}
+	if n.Username != "" || n.Password != "" {
+		req.SetBasicAuth(n.Username, n.Password)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode >= httpErrorStatus {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		if text := strings.TrimSpace(string(msg)); text != "" {
+			return fmt.Errorf("server returned %s: %s", resp.Status, text)
+		}
+
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	return nil
+}
+
+// labels builds the stream label set. Loki drops labels with empty values, so
+// built-in labels that would be empty are left out.
+func (n *Loki) labels(notif notifier.Notification, tctx map[string]string) (map[string]string, error) {
+	hostname, _ := os.Hostname()
+	labels := map[string]string{}
+	for name, value := range map[string]string{
+		"host":              hostname,
+		"project":           notif.Project(),
+		"notification_type": notif.NotificationType,
+	} {
+		if value != "" {
+			labels[name] = value
+		}
+	}
+
+	for name, value := range n.Labels {
+		key := sanitizeName(name)
+		switch key {
+		case "host", "project", "notification_type":
+			return nil, fmt.Errorf("label %q conflicts with a built-in label", name)
+		}
+		rendered, err := tmpl.Render("label "+name, value, tctx)
+		if err != nil {
+			return nil, err
+		}
+		if rendered != "" {
+			labels[key] = rendered
+		}
+	}
+
+	return labels, nil
+}
+
+func sanitizeName(name string) string {
+	name = invalidLabelChars.ReplaceAllString(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+
+	return name
+}
+
+// SampleConfig returns example TOML configuration.
+func (n *Loki) SampleConfig() string {
+	return `## Grafana Loki log lines
+## Each notification is pushed to /loki/api/v1/push as one log line with the
+## stream labels host, project and notification_type
+[[notifiers.loki]]
+
+## Loki base URL (required); /loki/api/v1/push is appended
+url = "http://127.0.0.1:3100"
+
+## Go template for the log line
+## Available variables: {{.Message}}, {{.Title}}, {{.Project}}, {{.Cwd}},
+## {{.NotificationType}}, {{.SessionID}}, {{.TranscriptPath}}, {{.Severity}}
+## Custom variables from [notifiers.loki.vars] are also available, title-cased
+# message = "{{.Message}}"
+
+## Tenant ID sent as X-Scope-OrgID (multi-tenant Loki)
+# tenant_id = ""
+
+## Basic authentication (e.g. Grafana Cloud user ID and API token)
+# username = ""
+# password = ""
+
+## Gzip the JSON request body
+# gzip = false
+
+## Extra stream labels; values are Go templates
+## Keep cardinality low: avoid per-session or per-message values
+# [notifiers.loki.labels]
+# job = "claude-notifier"
+
+## User-defined template variables
+## Keys are title-cased for template access (env -> {{.Env}})
+# [notifiers.loki.vars]
+# env = "production"
+`
+}
+
+// Register adds loki to the given plugin registry.
+func Register(reg *notifier.Registry) {
+	err := reg.Register("loki", func() notifier.Notifier {
+		n := &Loki{}
+		ApplyDefaults(n)
+
+		return n
+	})
+	if err != nil {
+		panic(err)
+	}
+}
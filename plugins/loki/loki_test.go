@@ -0,0 +1,180 @@
+package loki_test
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/plugins/loki"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pushBody struct {
+	Streams []struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	} `json:"streams"`
+}
+
+func TestLokiName(t *testing.T) {
+	p := &loki.Loki{}
+	assert.Equal(t, "loki", p.Name())
+}
+
+func TestLokiDefaults(t *testing.T) {
+	p := &loki.Loki{}
+	loki.ApplyDefaults(p)
+	assert.Equal(t, "http://127.0.0.1:3100", p.URL)
+	assert.Equal(t, "{{.Message}}", p.Message)
+	assert.False(t, p.Gzip)
+}
+
+func TestLokiImplementsNotifier(t *testing.T) {
+	var _ notifier.Notifier = &loki.Loki{}
+}
+
+func TestLokiPush(t *testing.T) {
+	var got *http.Request
+	var body pushBody
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	p := &loki.Loki{}
+	loki.ApplyDefaults(p)
+	p.URL = srv.URL + "/"
+	p.Message = "[{{.NotificationType}}] {{.Message}} ({{.Env}})"
+	p.TenantID = "team-a"
+	p.Username = "1234"
+	p.Password = "token"
+	p.Labels = map[string]string{"job": "claude-notifier", "env": "{{.Env}}", "empty": ""}
+	p.Vars = map[string]string{"env": "prod"}
+
+	before := time.Now()
+	err := p.Send(context.Background(), notifier.Notification{
+		Message:          "Claude needs permission",
+		Cwd:              "/home/user/billing-api",
+		NotificationType: "permission_prompt",
+	})
+	require.NoError(t, err)
+
+	require.NotNil(t, got)
+	assert.Equal(t, "/loki/api/v1/push", got.URL.Path)
+	assert.Equal(t, "application/json", got.Header.Get("Content-Type"))
+	assert.Equal(t, "team-a", got.Header.Get("X-Scope-OrgID"))
+	user, pass, ok := got.BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "1234", user)
+	assert.Equal(t, "token", pass)
+
+	require.Len(t, body.Streams, 1)
+	hostname, _ := os.Hostname()
+	assert.Equal(t, map[string]string{
+		"host":              hostname,
+		"project":           "billing-api",
+		"notification_type": "permission_prompt",
+		"job":               "claude-notifier",
+		"env":               "prod",
+	}, body.Streams[0].Stream)
+
+	require.Len(t, body.Streams[0].Values, 1)
+	ts, err := strconv.ParseInt(body.Streams[0].Values[0][0], 10, 64)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, ts, before.UnixNano())
+	assert.Equal(t, "[permission_prompt] Claude needs permission (prod)", body.Streams[0].Values[0][1])
+}
+
+func TestLokiFullPushURL(t *testing.T) {
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	p := &loki.Loki{URL: srv.URL + "/loki/api/v1/push"}
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{Message: "hi"}))
+	assert.Equal(t, "/loki/api/v1/push", path)
+}
+
+func TestLokiGzip(t *testing.T) {
+	var encoding string
+	var body pushBody
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Content-Encoding")
+		zr, err := gzip.NewReader(r.Body)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NoError(t, json.NewDecoder(zr).Decode(&body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	p := &loki.Loki{URL: srv.URL, Gzip: true}
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{Message: "compressed"}))
+	assert.Equal(t, "gzip", encoding)
+	require.Len(t, body.Streams, 1)
+	assert.Equal(t, "compressed", body.Streams[0].Values[0][1])
+}
+
+func TestLokiNoAuthHeadersByDefault(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	p := &loki.Loki{URL: srv.URL}
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{Message: "hi"}))
+	assert.Empty(t, got.Header.Get("X-Scope-OrgID"))
+	assert.Empty(t, got.Header.Get("Authorization"))
+}
+
+func TestLokiErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "entry too far behind\n")
+	}))
+	defer srv.Close()
+
+	p := &loki.Loki{URL: srv.URL}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400")
+	assert.Contains(t, err.Error(), "entry too far behind")
+}
+
+func TestLokiMissingURL(t *testing.T) {
+	p := &loki.Loki{}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "url is required")
+}
+
+func TestLokiLabelConflict(t *testing.T) {
+	p := &loki.Loki{URL: "http://127.0.0.1:1", Labels: map[string]string{"host": "x"}}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "conflicts with a built-in label")
+}
+
+func TestLokiBadTemplate(t *testing.T) {
+	p := &loki.Loki{URL: "http://127.0.0.1:1", Message: "{{.Invalid"}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rendering message template")
+}
@@ -0,0 +1,182 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/tmpl"
+)
+
+const (
+	backendPushgateway = "pushgateway"
+	backendStatsD      = "statsd"
+)
+
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// Metrics emits notification counters to a Prometheus Pushgateway or a
+// StatsD/DogStatsD daemon instead of a human-readable message.
+type Metrics struct {
+	Backend   string            `toml:"backend"`
+	Prefix    string            `toml:"prefix"`
+	Labels    map[string]string `toml:"labels"`
+	URL       string            `toml:"url"`
+	Job       string            `toml:"job"`
+	Grouping  []string          `toml:"grouping"`
+	Username  string            `toml:"username"`
+	Password  string            `toml:"password"`
+	Address   string            `toml:"address"`
+	TagFormat string            `toml:"tag_format"`
+	Vars      map[string]string `toml:"vars"`
+}
+
+// ApplyDefaults sets sane defaults on a new Metrics instance.
+func ApplyDefaults(n *Metrics) {
+	n.Backend = backendPushgateway
+	n.Prefix = "claude_notifier"
+	n.URL = "http://127.0.0.1:9091"
+	n.Job = "claude_notifier"
+	n.Grouping = []string{"host", "project", "notification_type"}
+	n.Address = "127.0.0.1:8125"
+	n.TagFormat = tagsDogStatsD
+}
+
+func (n *Metrics) Name() string { return "metrics" }
+
+// label is a single name/value pair in a stable order.
+type label struct{ name, value string }
+
+func (n *Metrics) Send(ctx context.Context, notif notifier.Notification) error {
+	labels, err := n.labels(notif)
+	if err != nil {
+		return err
+	}
+
+	prefix := n.Prefix
+	if prefix == "" {
+		prefix = "claude_notifier"
+	}
+
+	switch n.Backend {
+	case backendPushgateway, "":
+		return n.push(ctx, sanitizeName(prefix), labels, time.Now())
+	case backendStatsD:
+		return n.sendStatsD(ctx, prefix, labels)
+	}
+
+	return fmt.Errorf("unknown backend %q (want pushgateway or statsd)", n.Backend)
+}
+
+// labels returns the built-in labels followed by the rendered static labels,
+// sorted by name.
+func (n *Metrics) labels(notif notifier.Notification) ([]label, error) {
+	hostname, _ := os.Hostname()
+	set := map[string]string{
+		"host":              hostname,
+		"project":           notif.Project(),
+		"notification_type": notif.NotificationType,
+	}
+
+	tctx := tmpl.BuildContext(notif, n.Vars)
+	for name, value := range n.Labels {
+		key := sanitizeName(name)
+		if _, builtin := set[key]; builtin {
+			return nil, fmt.Errorf("label %q conflicts with a built-in label", name)
+		}
+		rendered, err := tmpl.Render("label "+name, value, tctx)
+		if err != nil {
+			return nil, err
+		}
+		set[key] = rendered
+	}
+
+	out := make([]label, 0, len(set))
+	for name, value := range set {
+		out = append(out, label{name, value})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+
+	return out, nil
+}
+
+func sanitizeName(name string) string {
+	name = invalidLabelChars.ReplaceAllString(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+
+	return name
+}
+
+// SampleConfig returns example TOML configuration.
+func (n *Metrics) SampleConfig() string {
+	return `## Notification counters for Prometheus Pushgateway or StatsD/DogStatsD
+## Every notification increments <prefix>_notifications_total, labelled by
+## host, project and notification_type
+[[notifiers.metrics]]
+
+## Backend: "pushgateway" or "statsd"
+backend = "pushgateway"
+
+## Metric name prefix
+# prefix = "claude_notifier"
+
+## Extra labels added to every metric; values are Go templates
+## Available variables: {{.Message}}, {{.Title}}, {{.Project}}, {{.Cwd}},
+## {{.NotificationType}}, {{.SessionID}}, {{.TranscriptPath}}, {{.Severity}}
+## Custom variables from [notifiers.metrics.vars] are also available, title-cased
+# [notifiers.metrics.labels]
+# team = "platform"
+
+## --- Pushgateway ---
+
+## Pushgateway base URL
+## The counter is read back from the Pushgateway and pushed incremented, so
+## hooks firing at the same moment can lose an increment: treat it as
+## approximate. If the read fails, nothing is pushed.
+url = "http://127.0.0.1:9091"
+
+## Job name in the grouping key
+# job = "claude_notifier"
+
+## Labels that form the grouping key (besides job)
+## Each group holds one series; labels left out of the grouping key are
+## attached to the samples, and a later push to the same group replaces them
+# grouping = ["host", "project", "notification_type"]
+
+## Basic authentication
+# username = ""
+# password = ""
+
+## --- StatsD ---
+
+## StatsD address as host:port (UDP)
+# address = "127.0.0.1:8125"
+
+## Tag format: "dogstatsd" (|#k:v), "influx" (metric,k=v) or "none"
+# tag_format = "dogstatsd"
+
+## User-defined template variables
+## Keys are title-cased for template access (env -> {{.Env}})
+# [notifiers.metrics.vars]
+# env = "production"
+`
+}
+
+// Register adds metrics to the given plugin registry.
+func Register(reg *notifier.Registry) {
+	err := reg.Register("metrics", func() notifier.Notifier {
+		n := &Metrics{}
+		ApplyDefaults(n)
+
+		return n
+	})
+	if err != nil {
+		panic(err)
+	}
+}
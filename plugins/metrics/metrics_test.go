@@ -0,0 +1,293 @@
+package metrics_test
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/plugins/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pushed struct {
+	method string
+	path   string
+	body   string
+	user   string
+	pass   string
+}
+
+// fakePushgateway serves apiBody on /api/v1/metrics and records pushes.
+func fakePushgateway(t *testing.T, apiBody string) (*httptest.Server, func() []pushed) {
+	t.Helper()
+	var mu sync.Mutex
+	var pushes []pushed
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/metrics" {
+			_, _ = io.WriteString(w, apiBody)
+
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		user, pass, _ := r.BasicAuth()
+		mu.Lock()
+		pushes = append(pushes, pushed{r.Method, r.URL.EscapedPath(), string(body), user, pass})
+		mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []pushed {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]pushed(nil), pushes...)
+	}
+}
+
+func TestMetricsName(t *testing.T) {
+	p := &metrics.Metrics{}
+	assert.Equal(t, "metrics", p.Name())
+}
+
+func TestMetricsDefaults(t *testing.T) {
+	p := &metrics.Metrics{}
+	metrics.ApplyDefaults(p)
+	assert.Equal(t, "pushgateway", p.Backend)
+	assert.Equal(t, "claude_notifier", p.Prefix)
+	assert.Equal(t, "http://127.0.0.1:9091", p.URL)
+	assert.Equal(t, "claude_notifier", p.Job)
+	assert.Equal(t, []string{"host", "project", "notification_type"}, p.Grouping)
+	assert.Equal(t, "127.0.0.1:8125", p.Address)
+	assert.Equal(t, "dogstatsd", p.TagFormat)
+}
+
+func TestMetricsImplementsNotifier(t *testing.T) {
+	var _ notifier.Notifier = &metrics.Metrics{}
+}
+
+func TestMetricsPushgatewayFirstPush(t *testing.T) {
+	srv, pushes := fakePushgateway(t, `{"status":"success","data":[]}`)
+
+	p := &metrics.Metrics{}
+	metrics.ApplyDefaults(p)
+	p.URL = srv.URL
+	p.Username = "prom"
+	p.Password = "pw"
+
+	err := p.Send(context.Background(), notifier.Notification{
+		Cwd:              "/home/user/billing-api",
+		NotificationType: "permission_prompt",
+	})
+	require.NoError(t, err)
+
+	got := pushes()
+	require.Len(t, got, 1)
+	hostname, _ := os.Hostname()
+	assert.Equal(t, http.MethodPost, got[0].method)
+	assert.Equal(t, "/metrics/job/claude_notifier/host/"+hostname+
+		"/notification_type/permission_prompt/project/billing-api", got[0].path)
+	assert.Equal(t, "prom", got[0].user)
+	assert.Equal(t, "pw", got[0].pass)
+	assert.Contains(t, got[0].body, "# TYPE claude_notifier_notifications_total counter\n")
+	assert.Contains(t, got[0].body, "\nclaude_notifier_notifications_total 1\n")
+	assert.Contains(t, got[0].body, "# TYPE claude_notifier_last_notification_timestamp_seconds gauge\n")
+}
+
+func TestMetricsPushgatewayIncrementsExistingCounter(t *testing.T) {
+	hostname, _ := os.Hostname()
+	api := `{"status":"success","data":[
+		{"labels":{"job":"claude_notifier","host":"other","project":"billing-api","notification_type":"idle_prompt"},
+		 "claude_notifier_notifications_total":{"type":"COUNTER","metrics":[{"labels":{},"value":"40"}]}},
+		{"labels":{"job":"claude_notifier","host":"` + hostname + `","project":"billing-api","notification_type":"idle_prompt"},
+		 "claude_notifier_notifications_total":{"type":"COUNTER","metrics":[{"labels":{},"value":"6"}]}}
+	]}`
+	srv, pushes := fakePushgateway(t, api)
+
+	p := &metrics.Metrics{}
+	metrics.ApplyDefaults(p)
+	p.URL = srv.URL
+
+	err := p.Send(context.Background(), notifier.Notification{
+		Cwd:              "/home/user/billing-api",
+		NotificationType: "idle_prompt",
+	})
+	require.NoError(t, err)
+
+	got := pushes()
+	require.Len(t, got, 1)
+	assert.Contains(t, got[0].body, "\nclaude_notifier_notifications_total 7\n")
+}
+
+func TestMetricsPushgatewayCustomGroupingAndLabels(t *testing.T) {
+	srv, pushes := fakePushgateway(t, `{"status":"success","data":[]}`)
+
+	p := &metrics.Metrics{
+		URL:      srv.URL,
+		Job:      "claude",
+		Grouping: []string{"project"},
+		Labels:   map[string]string{"team": "{{.Team}}", "session-id": "{{.SessionID}}"},
+		Vars:     map[string]string{"team": "plat\"form"},
+	}
+
+	err := p.Send(context.Background(), notifier.Notification{
+		Cwd:              "/",
+		NotificationType: "idle_prompt",
+		SessionID:        "s1",
+	})
+	require.NoError(t, err)
+
+	got := pushes()
+	require.Len(t, got, 1)
+	// Project of "/" contains a slash, so it uses the base64 form.
+	assert.Equal(t, "/metrics/job/claude/project@base64/Lw", got[0].path)
+	hostname, _ := os.Hostname()
+	assert.Contains(t, got[0].body, `claude_notifier_notifications_total{host="`+hostname+
+		`",notification_type="idle_prompt",session_id="s1",team="plat\"form"} 1`)
+}
+
+func TestMetricsPushgatewayEmptyGroupValue(t *testing.T) {
+	srv, pushes := fakePushgateway(t, `{"status":"success","data":[]}`)
+
+	p := &metrics.Metrics{
+		URL:      srv.URL,
+		Grouping: []string{"notification_type", "team"},
+		Labels:   map[string]string{"team": "a/b"},
+	}
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{}))
+
+	got := pushes()
+	require.Len(t, got, 1)
+	want := "/metrics/job/claude_notifier/notification_type@base64/=/team@base64/" +
+		base64.RawURLEncoding.EncodeToString([]byte("a/b"))
+	assert.Equal(t, want, got[0].path)
+}
+
+func TestMetricsPushgatewayAPIFailureDoesNotReset(t *testing.T) {
+	srv, pushes := fakePushgateway(t, `not json`)
+
+	p := &metrics.Metrics{}
+	metrics.ApplyDefaults(p)
+	p.URL = srv.URL
+	err := p.Send(context.Background(), notifier.Notification{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "reading current counter")
+	assert.Empty(t, pushes(), "pushing 1 would reset the counter")
+}
+
+func TestMetricsPushgatewayErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			http.Error(w, "pushed metrics are invalid", http.StatusBadRequest)
+
+			return
+		}
+		_, _ = io.WriteString(w, `{"data":[]}`)
+	}))
+	defer srv.Close()
+
+	p := &metrics.Metrics{}
+	metrics.ApplyDefaults(p)
+	p.URL = srv.URL
+	err := p.Send(context.Background(), notifier.Notification{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400")
+	assert.Contains(t, err.Error(), "pushed metrics are invalid")
+}
+
+func TestMetricsLabelConflict(t *testing.T) {
+	p := &metrics.Metrics{Labels: map[string]string{"project": "x"}}
+	err := p.Send(context.Background(), notifier.Notification{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "conflicts with a built-in label")
+}
+
+func TestMetricsBadTemplate(t *testing.T) {
+	p := &metrics.Metrics{Labels: map[string]string{"team": "{{.Invalid"}}
+	err := p.Send(context.Background(), notifier.Notification{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rendering label team template")
+}
+
+func listenUDP(t *testing.T) (net.PacketConn, func() string) {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pc.Close() })
+
+	return pc, func() string {
+		buf := make([]byte, 1500)
+		_ = pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		require.NoError(t, err)
+
+		return string(buf[:n])
+	}
+}
+
+func TestMetricsStatsDTagFormats(t *testing.T) {
+	hostname, _ := os.Hostname()
+	host := strings.NewReplacer(":", "_", " ", "_").Replace(hostname)
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"dogstatsd", "claude_notifier.notifications:1|c|#host:" + host +
+			",notification_type:idle_prompt,project:my_project"},
+		{"influx", "claude_notifier.notifications,host=" + host +
+			",notification_type=idle_prompt,project=my_project:1|c"},
+		{"none", "claude_notifier.notifications:1|c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			pc, read := listenUDP(t)
+
+			p := &metrics.Metrics{}
+			metrics.ApplyDefaults(p)
+			p.Backend = "statsd"
+			p.Address = pc.LocalAddr().String()
+			p.TagFormat = tt.format
+
+			err := p.Send(context.Background(), notifier.Notification{
+				Cwd:              "/home/user/my project",
+				NotificationType: "idle_prompt",
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, read())
+		})
+	}
+}
+
+func TestMetricsStatsDSanitizesValues(t *testing.T) {
+	pc, read := listenUDP(t)
+
+	p := &metrics.Metrics{Backend: "statsd", Address: pc.LocalAddr().String(), Prefix: "cn"}
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{Cwd: "/tmp/a|b"}))
+	packet := read()
+	assert.True(t, strings.HasPrefix(packet, "cn.notifications:1|c|#"), packet)
+	assert.Contains(t, packet, "notification_type:none,project:a_b")
+}
+
+func TestMetricsStatsDUnknownTagFormat(t *testing.T) {
+	p := &metrics.Metrics{Backend: "statsd", Address: "127.0.0.1:1", TagFormat: "graphite"}
+	err := p.Send(context.Background(), notifier.Notification{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown tag_format")
+}
+
+func TestMetricsUnknownBackend(t *testing.T) {
+	p := &metrics.Metrics{Backend: "influxdb"}
+	err := p.Send(context.Background(), notifier.Notification{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown backend")
+}
@@ -0,0 +1,207 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	httpTimeout     = 30 * time.Second
+	httpErrorStatus = 400
+	maxAPIBody      = 8 << 20
+)
+
+var httpClient = &http.Client{
+	Timeout: httpTimeout,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// push reads the current counter for the group from the Pushgateway API and
+// pushes it incremented by one, so the counter keeps increasing across
+// invocations without any local state. Concurrent pushes to the same group
+// can lose an increment, so the counter is approximate. When the read fails
+// nothing is pushed, since pushing 1 would reset the series.
+func (n *Metrics) push(ctx context.Context, prefix string, labels []label, now time.Time) error {
+	job := n.Job
+	if job == "" {
+		job = "claude_notifier"
+	}
+
+	var group, sample []label
+	for _, l := range labels {
+		if slices.Contains(n.Grouping, l.name) {
+			group = append(group, l)
+		} else {
+			sample = append(sample, l)
+		}
+	}
+
+	counterName := prefix + "_notifications_total"
+	current, err := n.currentValue(ctx, job, group, counterName)
+	if err != nil {
+		return fmt.Errorf("reading current counter: %w", err)
+	}
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "# HELP %s Notifications emitted by claude-notifier.\n", counterName)
+	fmt.Fprintf(&body, "# TYPE %s counter\n", counterName)
+	fmt.Fprintf(&body, "%s%s %s\n", counterName, formatLabels(sample), strconv.FormatFloat(current+1, 'f', -1, 64))
+	gaugeName := prefix + "_last_notification_timestamp_seconds"
+	fmt.Fprintf(&body, "# HELP %s Unix time of the last notification.\n", gaugeName)
+	fmt.Fprintf(&body, "# TYPE %s gauge\n", gaugeName)
+	fmt.Fprintf(&body, "%s%s %d\n", gaugeName, formatLabels(sample), now.Unix())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.groupURL(job, group), &body)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	n.setAuth(req)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode >= httpErrorStatus {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+		return fmt.Errorf("server returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+func (n *Metrics) setAuth(req *http.Request) {
+	if n.Username != "" || n.Password != "" {
+		req.SetBasicAuth(n.Username, n.Password)
+	}
+}
+
+// groupURL builds /metrics/job/<job>/<label>/<value>..., using the base64
+// form for values that are empty or contain a slash.
+func (n *Metrics) groupURL(job string, group []label) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(n.URL, "/"))
+	b.WriteString("/metrics")
+	for _, l := range append([]label{{"job", job}}, group...) {
+		if l.value == "" || strings.Contains(l.value, "/") {
+			b.WriteString("/" + l.name + "@base64/")
+			if l.value == "" {
+				b.WriteString("=")
+			} else {
+				b.WriteString(base64.RawURLEncoding.EncodeToString([]byte(l.value)))
+			}
+
+			continue
+		}
+		b.WriteString("/" + l.name + "/" + url.PathEscape(l.value))
+	}
+
+	return b.String()
+}
+
+// apiGroup mirrors one entry of the Pushgateway /api/v1/metrics response.
+type apiGroup map[string]json.RawMessage
+
+type apiFamily struct {
+	Metrics []struct {
+		Labels map[string]string `json:"labels"`
+		Value  string            `json:"value"`
+	} `json:"metrics"`
+}
+
+func (n *Metrics) currentValue(ctx context.Context, job string, group []label, name string) (float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(n.URL, "/")+"/api/v1/metrics", nil)
+	if err != nil {
+		return 0, fmt.Errorf("creating request: %w", err)
+	}
+	n.setAuth(req)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("sending request: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode >= httpErrorStatus {
+		return 0, fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	var payload struct {
+		Data []apiGroup `json:"data"`
+	}
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxAPIBody)).Decode(&payload)
+	if err != nil {
+		return 0, fmt.Errorf("decoding response: %w", err)
+	}
+
+	want := map[string]string{"job": job}
+	for _, l := range group {
+		want[l.name] = l.value
+	}
+
+	for _, g := range payload.Data {
+		var groupLabels map[string]string
+		if json.Unmarshal(g["labels"], &groupLabels) != nil || !sameLabels(groupLabels, want) {
+			continue
+		}
+		raw, ok := g[name]
+		if !ok {
+			return 0, nil
+		}
+		var family apiFamily
+		err = json.Unmarshal(raw, &family)
+		if err != nil || len(family.Metrics) == 0 {
+			return 0, nil
+		}
+
+		return strconv.ParseFloat(family.Metrics[0].Value, 64)
+	}
+
+	return 0, nil
+}
+
+func sameLabels(got, want map[string]string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for k, v := range want {
+		if got[k] != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+func formatLabels(labels []label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(labels))
+	for _, l := range labels {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(l.value)
+		parts = append(parts, l.name+`="`+value+`"`)
+	}
+
+	return "{" + strings.Join(parts, ",") + "}"
+}
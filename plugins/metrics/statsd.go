@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+const (
+	tagsDogStatsD = "dogstatsd"
+	tagsInflux    = "influx"
+	tagsNone      = "none"
+)
+
+// sendStatsD writes a single counter increment as one UDP datagram.
+func (n *Metrics) sendStatsD(ctx context.Context, prefix string, labels []label) error {
+	packet, err := n.statsDPacket(prefix, labels)
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", n.Address)
+	if err != nil {
+		return fmt.Errorf("connecting to %s: %w", n.Address, err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	_, err = conn.Write([]byte(packet))
+	if err != nil {
+		return fmt.Errorf("writing to %s: %w", n.Address, err)
+	}
+
+	return nil
+}
+
+func (n *Metrics) statsDPacket(prefix string, labels []label) (string, error) {
+	name := statsDToken(prefix) + ".notifications"
+
+	switch n.TagFormat {
+	case tagsDogStatsD, "":
+		tags := make([]string, 0, len(labels))
+		for _, l := range labels {
+			tags = append(tags, l.name+":"+statsDToken(l.value))
+		}
+
+		return name + ":1|c|#" + strings.Join(tags, ","), nil
+	case tagsInflux:
+		var b strings.Builder
+		b.WriteString(name)
+		for _, l := range labels {
+			b.WriteString("," + l.name + "=" + statsDToken(l.value))
+		}
+
+		return b.String() + ":1|c", nil
+	case tagsNone:
+		return name + ":1|c", nil
+	}
+
+	return "", fmt.Errorf("unknown tag_format %q (want dogstatsd, influx or none)", n.TagFormat)
+}
+
+// statsDToken replaces characters that are structural in the StatsD line
+// formats.
+func statsDToken(s string) string {
+	if s == "" {
+		return "none"
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', ',', '#', '@', '=', ' ', '\n', '\r', '\t':
+			return '_'
+		}
+
+		return r
+	}, s)
+}
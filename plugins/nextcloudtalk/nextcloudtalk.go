@@ -0,0 +1,299 @@
+package nextcloudtalk
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/state"
+	"github.com/felipeelias/claude-notifier/internal/tmpl"
+)
+
+const (
+	httpTimeout     = 30 * time.Second
+	httpErrorStatus = 400
+	maxResponseBody = 64 << 10
+	maxMessageLen   = 32000
+
+	modeChat = "chat"
+	modeBot  = "bot"
+
+	defaultMessage = "**{{.Project}}**: {{.Message}}"
+)
+
+var httpClient = &http.Client{
+	Timeout: httpTimeout,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// NextcloudTalk posts notifications to a Nextcloud Talk conversation.
+type NextcloudTalk struct {
+	URL         string            `toml:"url"`
+	Token       string            `toml:"token"`
+	Mode        string            `toml:"mode"`
+	Username    string            `toml:"username"`
+	AppPassword string            `toml:"app_password"`
+	Secret      string            `toml:"secret"`
+	Message     string            `toml:"message"`
+	Silent      bool              `toml:"silent"`
+	Thread      bool              `toml:"thread"`
+	ThreadsFile string            `toml:"threads_file"`
+	Vars        map[string]string `toml:"vars"`
+}
+
+// ApplyDefaults sets sane defaults on a new NextcloudTalk instance.
+func ApplyDefaults(n *NextcloudTalk) {
+	n.Mode = modeChat
+	n.Message = defaultMessage
+}
+
+func (n *NextcloudTalk) Name() string { return "nextcloudtalk" }
+
+type chatRequest struct {
+	Message     string `json:"message"`
+	ReplyTo     int    `json:"replyTo,omitempty"`
+	ReferenceID string `json:"referenceId,omitempty"`
+	Silent      bool   `json:"silent,omitempty"`
+}
+
+func (n *NextcloudTalk) Send(ctx context.Context, notif notifier.Notification) error {
+	if n.URL == "" {
+		return errors.New("url is required")
+	}
+	if n.Token == "" {
+		return errors.New("token is required")
+	}
+
+	tctx := tmpl.BuildContext(notif, n.Vars)
+
+	msgTmpl := n.Message
+	if msgTmpl == "" {
+		msgTmpl = defaultMessage
+	}
+	message, err := tmpl.Render("message", msgTmpl, tctx)
+	if err != nil {
+		return err
+	}
+	if runes := []rune(message); len(runes) > maxMessageLen {
+		message = string(runes[:maxMessageLen])
+	}
+
+	body := chatRequest{Message: message, ReferenceID: referenceID(notif, message), Silent: n.Silent}
+
+	switch n.Mode {
+	case modeChat, "":
+		return n.sendChat(ctx, notif.SessionID, body)
+	case modeBot:
+		return n.sendBot(ctx, body)
+	}
+
+	return fmt.Errorf("unknown mode %q (want chat or bot)", n.Mode)
+}
+
+// referenceID lets clients deduplicate a message: the same notification
+// always gets the same ID. Talk expects a 64 char hex string.
+func referenceID(notif notifier.Notification, message string) string {
+	sum := sha256.Sum256([]byte(notif.SessionID + "\x00" + notif.NotificationType + "\x00" + message))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func (n *NextcloudTalk) sendChat(ctx context.Context, sessionID string, body chatRequest) error {
+	if n.Username == "" || n.AppPassword == "" {
+		return errors.New("username and app_password are required in chat mode")
+	}
+
+	threads := n.threadStore()
+	threaded := n.Thread && sessionID != ""
+	key := n.Token + "/" + sessionID
+	if threaded {
+		body.ReplyTo = threads.get(key)
+	}
+
+	endpoint := n.endpoint("/ocs/v2.php/apps/spreed/api/v1/chat/" + url.PathEscape(n.Token))
+	var result struct {
+		OCS struct {
+			Data struct {
+				ID int `json:"id"`
+			} `json:"data"`
+		} `json:"ocs"`
+	}
+	err := n.post(ctx, endpoint, body, func(req *http.Request) {
+		req.SetBasicAuth(n.Username, n.AppPassword)
+	}, &result)
+	if err != nil {
+		return err
+	}
+
+	// The first message of a session becomes the parent of later ones.
+	if threaded && body.ReplyTo == 0 && result.OCS.Data.ID != 0 {
+		err = threads.set(key, result.OCS.Data.ID)
+		if err != nil {
+			slog.Warn("saving nextcloudtalk thread", "error", err)
+		}
+	}
+
+	return nil
+}
+
+// sendBot posts as a Talk bot. Bots sign "<random><message>" with the
+// shared secret; the API does not return the new message ID, so threading
+// is not available.
+func (n *NextcloudTalk) sendBot(ctx context.Context, body chatRequest) error {
+	if n.Secret == "" {
+		return errors.New("secret is required in bot mode")
+	}
+
+	nonce := make([]byte, 32)
+	_, _ = rand.Read(nonce)
+	random := hex.EncodeToString(nonce)
+	mac := hmac.New(sha256.New, []byte(n.Secret))
+	mac.Write([]byte(random + body.Message))
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	body.Silent = false
+	endpoint := n.endpoint("/ocs/v2.php/apps/spreed/api/v1/bot/" + url.PathEscape(n.Token) + "/message")
+
+	return n.post(ctx, endpoint, body, func(req *http.Request) {
+		req.Header.Set("X-Nextcloud-Talk-Bot-Random", random)
+		req.Header.Set("X-Nextcloud-Talk-Bot-Signature", signature)
+	}, nil)
+}
+
+func (n *NextcloudTalk) endpoint(path string) string {
+	return strings.TrimRight(n.URL, "/") + path
+}
+
+func (n *NextcloudTalk) post(ctx context.Context, endpoint string, body any, auth func(*http.Request), result any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encoding payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("OCS-APIRequest", "true")
+	auth(req)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if resp.StatusCode >= httpErrorStatus {
+		var ocsErr struct {
+			OCS struct {
+				Meta struct {
+					Message string `json:"message"`
+				} `json:"meta"`
+			} `json:"ocs"`
+		}
+		if json.Unmarshal(respBody, &ocsErr) == nil && ocsErr.OCS.Meta.Message != "" {
+			return fmt.Errorf("server returned %s: %s", resp.Status, ocsErr.OCS.Meta.Message)
+		}
+
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	if result != nil && len(respBody) > 0 {
+		err = json.Unmarshal(respBody, result)
+		if err != nil {
+			return fmt.Errorf("decoding response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (n *NextcloudTalk) threadStore() threadStore {
+	path := n.ThreadsFile
+	if path == "" {
+		path = state.Dir() + "/nextcloudtalk-threads.json"
+	}
+
+	return threadStore{path: path}
+}
+
+// SampleConfig returns example TOML configuration.
+func (n *NextcloudTalk) SampleConfig() string {
+	return `## Nextcloud Talk conversation messages
+## https://nextcloud-talk.readthedocs.io/en/latest/chat/
+[[notifiers.nextcloudtalk]]
+
+## Nextcloud base URL (required)
+url = "https://cloud.example.com"
+
+## Conversation token, the last part of the conversation URL (required)
+token = ""
+
+## Mode: "chat" posts as a user through the OCS chat API; "bot" posts as a
+## Talk bot installed with occ talk:bot:install (HMAC-signed requests)
+# mode = "chat"
+
+## User and app password for chat mode
+## Create an app password under Settings > Security
+username = ""
+app_password = ""
+
+## Bot shared secret for bot mode
+# secret = ""
+
+## Go template for the message (Talk renders Markdown)
+## Available variables: {{.Message}}, {{.Title}}, {{.Project}}, {{.Cwd}},
+## {{.NotificationType}}, {{.SessionID}}, {{.TranscriptPath}}, {{.Severity}}
+## Custom variables from [notifiers.nextcloudtalk.vars] are also available, title-cased
+# message = "**{{.Project}}**: {{.Message}}"
+
+## Post without triggering chat notifications (chat mode)
+# silent = false
+
+## Reply to the first message of the same Claude session (chat mode)
+# thread = false
+
+## Where thread parents are remembered between runs
+## Defaults to $XDG_STATE_HOME/claude-notifier/nextcloudtalk-threads.json
+# threads_file = ""
+
+## User-defined template variables
+## Keys are title-cased for template access (env -> {{.Env}})
+# [notifiers.nextcloudtalk.vars]
+# env = "production"
+`
+}
+
+// Register adds nextcloudtalk to the given plugin registry.
+func Register(reg *notifier.Registry) {
+	err := reg.Register("nextcloudtalk", func() notifier.Notifier {
+		n := &NextcloudTalk{}
+		ApplyDefaults(n)
+
+		return n
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,234 @@
+package nextcloudtalk_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/plugins/nextcloudtalk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var notif = notifier.Notification{
+	Message:   "Claude needs permission",
+	Cwd:       "/home/user/billing-api",
+	SessionID: "sess-1",
+}
+
+type request struct {
+	path    string
+	header  http.Header
+	user    string
+	pass    string
+	payload map[string]any
+}
+
+// fakeTalk records requests and answers chat posts with increasing IDs.
+func fakeTalk(t *testing.T, status int) (*httptest.Server, func() []request) {
+	t.Helper()
+	var (
+		mu   sync.Mutex
+		reqs []request
+		next = 100
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		user, pass, _ := r.BasicAuth()
+
+		mu.Lock()
+		reqs = append(reqs, request{path: r.URL.Path, header: r.Header.Clone(), user: user, pass: pass, payload: payload})
+		next++
+		id := next
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if status >= 400 {
+			_, _ = w.Write([]byte(`{"ocs":{"meta":{"status":"failure","message":"Conversation not found"},"data":[]}}`))
+
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"ocs": map[string]any{"data": map[string]any{"id": id}}})
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []request {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]request(nil), reqs...)
+	}
+}
+
+func TestNextcloudTalkName(t *testing.T) {
+	p := &nextcloudtalk.NextcloudTalk{}
+	assert.Equal(t, "nextcloudtalk", p.Name())
+}
+
+func TestNextcloudTalkDefaults(t *testing.T) {
+	p := &nextcloudtalk.NextcloudTalk{}
+	nextcloudtalk.ApplyDefaults(p)
+	assert.Equal(t, "chat", p.Mode)
+	assert.Equal(t, "**{{.Project}}**: {{.Message}}", p.Message)
+	assert.False(t, p.Thread)
+}
+
+func TestNextcloudTalkImplementsNotifier(t *testing.T) {
+	var _ notifier.Notifier = &nextcloudtalk.NextcloudTalk{}
+}
+
+func TestNextcloudTalkChat(t *testing.T) {
+	srv, reqs := fakeTalk(t, http.StatusCreated)
+
+	p := &nextcloudtalk.NextcloudTalk{URL: srv.URL + "/", Token: "abc123", Username: "bot", AppPassword: "app-pass"}
+	nextcloudtalk.ApplyDefaults(p)
+	p.Silent = true
+
+	require.NoError(t, p.Send(context.Background(), notif))
+
+	got := reqs()
+	require.Len(t, got, 1)
+	assert.Equal(t, "/ocs/v2.php/apps/spreed/api/v1/chat/abc123", got[0].path)
+	assert.Equal(t, "true", got[0].header.Get("OCS-APIRequest"))
+	assert.Equal(t, "bot", got[0].user)
+	assert.Equal(t, "app-pass", got[0].pass)
+	assert.Equal(t, "**billing-api**: Claude needs permission", got[0].payload["message"])
+	assert.Equal(t, true, got[0].payload["silent"])
+	assert.Len(t, got[0].payload["referenceId"], 64)
+	assert.NotContains(t, got[0].payload, "replyTo")
+}
+
+func TestNextcloudTalkReferenceIDIsStable(t *testing.T) {
+	srv, reqs := fakeTalk(t, http.StatusCreated)
+
+	p := &nextcloudtalk.NextcloudTalk{URL: srv.URL, Token: "abc123", Username: "bot", AppPassword: "app-pass"}
+	nextcloudtalk.ApplyDefaults(p)
+	other := notif
+	other.Message = "Claude is waiting for your input"
+
+	require.NoError(t, p.Send(context.Background(), notif))
+	require.NoError(t, p.Send(context.Background(), notif))
+	require.NoError(t, p.Send(context.Background(), other))
+
+	got := reqs()
+	require.Len(t, got, 3)
+	assert.Equal(t, got[0].payload["referenceId"], got[1].payload["referenceId"])
+	assert.NotEqual(t, got[0].payload["referenceId"], got[2].payload["referenceId"])
+}
+
+func TestNextcloudTalkTruncatesOnRuneBoundary(t *testing.T) {
+	srv, reqs := fakeTalk(t, http.StatusCreated)
+
+	p := &nextcloudtalk.NextcloudTalk{URL: srv.URL, Token: "abc123", Username: "bot", AppPassword: "app-pass"}
+	nextcloudtalk.ApplyDefaults(p)
+	p.Message = "{{.Message}}"
+	long := notif
+	long.Message = strings.Repeat("é", 40000)
+
+	require.NoError(t, p.Send(context.Background(), long))
+
+	got := reqs()
+	require.Len(t, got, 1)
+	message, _ := got[0].payload["message"].(string)
+	assert.True(t, utf8.ValidString(message))
+	assert.Equal(t, 32000, utf8.RuneCountInString(message))
+}
+
+func TestNextcloudTalkChatThreading(t *testing.T) {
+	srv, reqs := fakeTalk(t, http.StatusCreated)
+	threads := filepath.Join(t.TempDir(), "state", "threads.json")
+
+	p := &nextcloudtalk.NextcloudTalk{
+		URL: srv.URL, Token: "abc123", Username: "bot", AppPassword: "app-pass",
+		Thread: true, ThreadsFile: threads,
+	}
+	require.NoError(t, p.Send(context.Background(), notif))
+	require.NoError(t, p.Send(context.Background(), notif))
+
+	other := notif
+	other.SessionID = "sess-2"
+	require.NoError(t, p.Send(context.Background(), other))
+
+	got := reqs()
+	require.Len(t, got, 3)
+	assert.NotContains(t, got[0].payload, "replyTo")
+	assert.InDelta(t, 101, got[1].payload["replyTo"], 0)
+	assert.NotContains(t, got[2].payload, "replyTo", "a new session starts a new thread")
+}
+
+func TestNextcloudTalkThreadsFileDefaultsToStateDir(t *testing.T) {
+	srv, reqs := fakeTalk(t, http.StatusCreated)
+	state := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", state)
+
+	p := &nextcloudtalk.NextcloudTalk{URL: srv.URL, Token: "abc123", Username: "bot", AppPassword: "app-pass", Thread: true}
+	require.NoError(t, p.Send(context.Background(), notif))
+	require.NoError(t, p.Send(context.Background(), notif))
+
+	got := reqs()
+	require.Len(t, got, 2)
+	assert.InDelta(t, 101, got[1].payload["replyTo"], 0)
+	assert.FileExists(t, filepath.Join(state, "claude-notifier", "nextcloudtalk-threads.json"))
+}
+
+func TestNextcloudTalkBotSignature(t *testing.T) {
+	srv, reqs := fakeTalk(t, http.StatusCreated)
+
+	p := &nextcloudtalk.NextcloudTalk{URL: srv.URL, Token: "abc123", Mode: "bot", Secret: "s3cret", Message: "{{.Message}}"}
+	require.NoError(t, p.Send(context.Background(), notif))
+
+	got := reqs()
+	require.Len(t, got, 1)
+	assert.Equal(t, "/ocs/v2.php/apps/spreed/api/v1/bot/abc123/message", got[0].path)
+	assert.Empty(t, got[0].user)
+
+	random := got[0].header.Get("X-Nextcloud-Talk-Bot-Random")
+	require.NotEmpty(t, random)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(random + "Claude needs permission"))
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), got[0].header.Get("X-Nextcloud-Talk-Bot-Signature"))
+}
+
+func TestNextcloudTalkServerError(t *testing.T) {
+	srv, _ := fakeTalk(t, http.StatusNotFound)
+
+	p := &nextcloudtalk.NextcloudTalk{URL: srv.URL, Token: "nope", Username: "bot", AppPassword: "app-pass"}
+	err := p.Send(context.Background(), notif)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+	assert.Contains(t, err.Error(), "Conversation not found")
+}
+
+func TestNextcloudTalkValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		p    nextcloudtalk.NextcloudTalk
+		want string
+	}{
+		{"no url", nextcloudtalk.NextcloudTalk{Token: "t"}, "url is required"},
+		{"no token", nextcloudtalk.NextcloudTalk{URL: "http://x"}, "token is required"},
+		{"no password", nextcloudtalk.NextcloudTalk{URL: "http://x", Token: "t", Username: "u"}, "app_password are required"},
+		{"no secret", nextcloudtalk.NextcloudTalk{URL: "http://x", Token: "t", Mode: "bot"}, "secret is required"},
+		{"bad mode", nextcloudtalk.NextcloudTalk{URL: "http://x", Token: "t", Mode: "webhook"}, "unknown mode"},
+		{"bad template", nextcloudtalk.NextcloudTalk{URL: "http://x", Token: "t", Message: "{{.Invalid"}, "rendering message template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.p.Send(context.Background(), notif)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package nextcloudtalk
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/state"
+)
+
+const (
+	threadTTL  = 7 * 24 * time.Hour
+	maxThreads = 500
+)
+
+// thread records the parent message of a session's thread.
+type thread struct {
+	MessageID int       `json:"message_id"`
+	Updated   time.Time `json:"updated"`
+}
+
+// threadStore keeps session -> parent message IDs in a JSON file so
+// threading works across invocations. Entries expire after a week.
+type threadStore struct {
+	path string
+}
+
+func (s threadStore) load() map[string]thread {
+	threads := map[string]thread{}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return threads
+	}
+	_ = json.Unmarshal(data, &threads)
+
+	return threads
+}
+
+func (s threadStore) get(key string) int {
+	t, ok := s.load()[key]
+	if !ok || time.Since(t.Updated) > threadTTL {
+		return 0
+	}
+
+	return t.MessageID
+}
+
+// set records id for key. The file is locked while it is rewritten so
+// concurrent hooks don't drop each other's threads.
+func (s threadStore) set(key string, id int) error {
+	threads := map[string]thread{}
+
+	return state.UpdateJSON(s.path, &threads, func() error {
+		now := time.Now().UTC()
+		threads[key] = thread{MessageID: id, Updated: now}
+
+		for k, t := range threads {
+			if now.Sub(t.Updated) > threadTTL {
+				delete(threads, k)
+			}
+		}
+		if len(threads) > maxThreads {
+			keys := make([]string, 0, len(threads))
+			for k := range threads {
+				keys = append(keys, k)
+			}
+			sort.Slice(keys, func(i, j int) bool { return threads[keys[i]].Updated.Before(threads[keys[j]].Updated) })
+			for _, k := range keys[:len(keys)-maxThreads] {
+				delete(threads, k)
+			}
+		}
+
+		return nil
+	})
+}
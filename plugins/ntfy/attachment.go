@@ -0,0 +1,108 @@
+package ntfy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/felipeelias/claude-notifier/internal/transcript"
+)
+
+const defaultTranscriptMaxBytes = 64 * 1024
+
+// excerpt renders the transcript attachment, or returns "" when there is
+// nothing to attach. An unreadable transcript is logged rather than
+// failing the notification, which is sent without the attachment.
+func (n *Ntfy) excerpt(transcriptPath string) (string, error) {
+	if n.TranscriptTurns <= 0 {
+		return "", nil
+	}
+	if n.Attach != "" {
+		return "", errors.New("use either attach or transcript_turns, not both")
+	}
+	if n.JSON {
+		// The excerpt is the body of a PUT, which can't carry a JSON message.
+		return "", errors.New("use either json or transcript_turns, not both")
+	}
+	if transcriptPath == "" {
+		return "", nil
+	}
+
+	maxBytes := n.TranscriptMaxBytes
+	if maxBytes == 0 {
+		maxBytes = defaultTranscriptMaxBytes
+	}
+	text, err := transcript.Excerpt(transcriptPath, transcript.Options{
+		Turns:    n.TranscriptTurns,
+		Format:   n.TranscriptFormat,
+		MaxBytes: maxBytes,
+		Redact:   n.Redact,
+	})
+	if err != nil {
+		slog.Warn("ntfy: skipping transcript attachment", "error", err)
+
+		return "", nil
+	}
+
+	return text, nil
+}
+
+// publishAttachment PUTs the excerpt as the request body, which ntfy stores
+// as an attachment. The message moves to the X-Message header.
+func (n *Ntfy) publishAttachment(ctx context.Context, tctx map[string]string, title, body, priority, excerpt string, actions []action) error {
+	endpoint, err := n.endpoint(tctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, strings.NewReader(excerpt))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	n.setHeaders(req, encodeHeader(title), priority)
+	req.Header.Set("X-Message", encodeHeader(body))
+	req.Header.Set("X-Filename", n.transcriptFilename(tctx["Project"]))
+	if len(actions) > 0 {
+		data, err := json.Marshal(actions)
+		if err != nil {
+			return fmt.Errorf("encoding actions: %w", err)
+		}
+		req.Header.Set("X-Actions", string(data))
+	}
+
+	return do(req)
+}
+
+func (n *Ntfy) transcriptFilename(project string) string {
+	if n.Filename != "" {
+		return n.Filename
+	}
+	ext := ".md"
+	if n.TranscriptFormat == transcript.FormatText {
+		ext = ".txt"
+	}
+	project = strings.Trim(invalidTopicChars.ReplaceAllString(project, "-"), "-")
+	if project == "" {
+		return "transcript" + ext
+	}
+
+	return "transcript-" + project + ext
+}
+
+// encodeHeader RFC 2047-encodes values that are not printable ASCII, which
+// ntfy decodes; raw newlines would be rejected by the HTTP client.
+func encodeHeader(s string) string {
+	for i := range len(s) {
+		if s[i] < ' ' || s[i] > '~' {
+			return mime.BEncoding.Encode("UTF-8", s)
+		}
+	}
+
+	return s
+}
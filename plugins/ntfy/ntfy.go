@@ -3,6 +3,7 @@ package ntfy
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/felipeelias/claude-notifier/internal/notifier"
 	"github.com/felipeelias/claude-notifier/internal/tmpl"
+	"github.com/felipeelias/claude-notifier/internal/transcript"
 )
 
 const (
@@ -40,10 +42,22 @@ type Ntfy struct {
 	Email    string            `toml:"email"`
 	Delay    string            `toml:"delay"`
 	Actions  string            `toml:"actions"`
+	Action   []Action          `toml:"action"`
 	Markdown bool              `toml:"markdown"`
 	Message  string            `toml:"message"`
 	Title    string            `toml:"title"`
+	Topic    string            `toml:"topic"`
+	JSON     bool              `toml:"json"`
 	Vars     map[string]string `toml:"vars"`
+
+	TranscriptTurns    int      `toml:"transcript_turns"`
+	TranscriptFormat   string   `toml:"transcript_format"`
+	TranscriptMaxBytes int      `toml:"transcript_max_bytes"`
+	Redact             []string `toml:"redact"`
+
+	// SeverityPriority maps severity levels to priorities, overriding
+	// Priority for the levels it lists.
+	SeverityPriority map[string]string `toml:"severity_priority"`
 }
 
 // ApplyDefaults sets sane defaults on a new Ntfy instance.
@@ -51,6 +65,8 @@ func ApplyDefaults(n *Ntfy) {
 	n.Markdown = true
 	n.Message = "{{.Message}}"
 	n.Title = "Claude Code ({{.Project}})"
+	n.TranscriptFormat = transcript.FormatMarkdown
+	n.TranscriptMaxBytes = defaultTranscriptMaxBytes
 }
 
 func (n *Ntfy) Name() string { return "ntfy" }
@@ -76,13 +92,49 @@ func (n *Ntfy) Send(ctx context.Context, notif notifier.Notification) error {
 		return err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, strings.NewReader(body))
+	actions, err := n.renderActions(tctx)
+	if err != nil {
+		return err
+	}
+
+	priority := notifier.ForSeverity(n.SeverityPriority, notif.Severity, n.Priority)
+
+	excerpt, err := n.excerpt(notif.TranscriptPath)
+	if err != nil {
+		return err
+	}
+	if excerpt != "" {
+		return n.publishAttachment(ctx, tctx, title, body, priority, excerpt, actions)
+	}
+
+	if n.JSON {
+		return n.publishJSON(ctx, tctx, title, body, priority, actions)
+	}
+
+	endpoint, err := n.endpoint(tctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("creating request: %w", err)
 	}
 
-	n.setHeaders(req, title)
+	n.setHeaders(req, title, priority)
+	if len(actions) > 0 {
+		// ntfy also accepts the JSON action format in the header.
+		data, err := json.Marshal(actions)
+		if err != nil {
+			return fmt.Errorf("encoding actions: %w", err)
+		}
+		req.Header.Set("X-Actions", string(data))
+	}
+
+	return do(req)
+}
 
+func do(req *http.Request) error {
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("sending request: %w", err)
@@ -106,14 +158,23 @@ func (n *Ntfy) SampleConfig() string {
 [[notifiers.ntfy]]
 
 ## ntfy server URL including topic (required)
+## When topic is set, this is the server URL without a topic
 url = "https://ntfy.sh/my-topic"
 
+## Go template for the topic, e.g. one topic per project
+## Characters other than letters, digits, "-" and "_" become "-"
+# topic = "claude-{{.Project}}"
+
+## Publish as JSON to the server root instead of with HTTP headers; safer
+## for non-ASCII titles
+# json = false
+
 ## Enable markdown formatting (web app only)
 # markdown = true
 
 ## Go template for the message body
 ## Available variables: {{.Message}}, {{.Title}}, {{.Project}}, {{.Cwd}},
-## {{.NotificationType}}, {{.SessionID}}, {{.TranscriptPath}}
+## {{.NotificationType}}, {{.SessionID}}, {{.TranscriptPath}}, {{.Severity}}
 ## Custom variables from [notifiers.ntfy.vars] are also available, title-cased
 # message = "{{.Message}}"
 
@@ -123,6 +184,10 @@ url = "https://ntfy.sh/my-topic"
 ## Message priority: min, low, default, high, urgent
 # priority = ""
 
+## Priority per severity level (low, normal, high, critical), overriding
+## priority for the levels listed; severity comes from [global.severity]
+# severity_priority = { low = "low", normal = "default", high = "high", critical = "urgent" }
+
 ## Comma-separated emoji tags (e.g. "robot,warning")
 # tags = ""
 
@@ -138,6 +203,20 @@ url = "https://ntfy.sh/my-topic"
 ## Override attachment filename
 # filename = ""
 
+## Attach an excerpt of the last N turns of the session transcript, uploaded
+## to the ntfy server (cannot be combined with attach or json); 0 disables
+## Secrets such as tokens, API keys and private keys are redacted
+# transcript_turns = 0
+
+## Excerpt format: "markdown" or "text"
+# transcript_format = "markdown"
+
+## Maximum excerpt size in bytes; the oldest turns are dropped first
+# transcript_max_bytes = 65536
+
+## Extra regular expressions to redact from the excerpt
+# redact = ["internal\\.example\\.com"]
+
 ## Email address for notification forwarding
 # email = ""
 
@@ -148,6 +227,24 @@ url = "https://ntfy.sh/my-topic"
 ## https://docs.ntfy.sh/publish/#action-buttons
 # actions = ""
 
+## Action buttons as tables (at most 3; use instead of actions)
+## type is "view" (open url), "http" (send a request) or "broadcast"
+## (Android intent); url, body and header values are Go templates
+# [[notifiers.ntfy.action]]
+# type = "view"
+# label = "Open project"
+# url = "vscode://file{{.Cwd}}"
+#
+# [[notifiers.ntfy.action]]
+# type = "http"
+# label = "Approve"
+# url = "https://example.com/approve"
+# method = "POST"
+# body = '{"session": "{{.SessionID}}"}'
+# clear = true
+# [notifiers.ntfy.action.headers]
+# X-Claude-Session = "{{.SessionID}}"
+
 ## Access token for authentication (Bearer)
 # token = ""
 
@@ -162,10 +259,10 @@ url = "https://ntfy.sh/my-topic"
 `
 }
 
-func (n *Ntfy) setHeaders(req *http.Request, title string) {
+func (n *Ntfy) setHeaders(req *http.Request, title, priority string) {
 	headers := []struct{ key, value string }{
 		{"Title", title},
-		{"Priority", n.Priority},
+		{"Priority", priority},
 		{"Tags", n.Tags},
 		{"X-Icon", n.Icon},
 		{"X-Click", n.Click},
@@ -185,7 +282,11 @@ func (n *Ntfy) setHeaders(req *http.Request, title string) {
 		req.Header.Set("X-Markdown", "yes")
 	}
 
-	// Auth: token takes precedence over username/password.
+	n.setAuth(req)
+}
+
+func (n *Ntfy) setAuth(req *http.Request) {
+	// Token takes precedence over username/password.
 	if n.Token != "" {
 		req.Header.Set("Authorization", "Bearer "+n.Token)
 	} else if n.Username != "" && n.Password != "" {
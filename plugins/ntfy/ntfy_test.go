@@ -2,9 +2,13 @@ package ntfy_test
 
 import (
 	"context"
+	"encoding/json"
 	"io"
+	"mime"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/felipeelias/claude-notifier/internal/notifier"
@@ -90,6 +94,8 @@ func TestNtfyDefaults(t *testing.T) {
 	assert.True(t, p.Markdown)
 	assert.Equal(t, "{{.Message}}", p.Message)
 	assert.Equal(t, "Claude Code ({{.Project}})", p.Title)
+	assert.Equal(t, "markdown", p.TranscriptFormat)
+	assert.Equal(t, 64*1024, p.TranscriptMaxBytes)
 }
 
 func TestNtfyImplementsNotifier(t *testing.T) {
@@ -293,3 +299,225 @@ func TestNtfyVarCollision(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "original", gotBody)
 }
+
+type published struct {
+	method  string
+	path    string
+	headers http.Header
+	body    []byte
+}
+
+func recordingServer(t *testing.T) (*httptest.Server, *published) {
+	t.Helper()
+	got := &published{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.method = r.Method
+		got.path = r.URL.Path
+		got.headers = r.Header
+		got.body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, got
+}
+
+func TestNtfyJSONPublish(t *testing.T) {
+	srv, got := recordingServer(t)
+
+	p := &ntfy.Ntfy{}
+	ntfy.ApplyDefaults(p)
+	p.URL = srv.URL + "/claude-alerts"
+	p.JSON = true
+	p.Priority = "high"
+	p.Tags = "robot, warning"
+	p.Click = "https://example.com"
+	p.Title = "Claude Code — {{.Project}}"
+	p.Action = []ntfy.Action{
+		{Type: "view", Label: "Open", URL: "vscode://file{{.Cwd}}"},
+		{
+			Type: "http", Label: "Approve", URL: "https://example.com/approve", Method: "POST",
+			Body: `{"session":"{{.SessionID}}"}`, Headers: map[string]string{"X-Project": "{{.Project}}"}, Clear: true,
+		},
+		{Type: "broadcast", Label: "Tasker", Intent: "io.example.ACTION", Extras: map[string]string{"cmd": "ack"}},
+	}
+
+	err := p.Send(context.Background(), notifier.Notification{
+		Message: "Task complete", Cwd: "/home/user/myproject", SessionID: "s1",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "/", got.path)
+	assert.Equal(t, "application/json", got.headers.Get("Content-Type"))
+	assert.Empty(t, got.headers.Get("Title"))
+	assert.JSONEq(t, `{
+		"topic": "claude-alerts",
+		"message": "Task complete",
+		"title": "Claude Code — myproject",
+		"tags": ["robot", "warning"],
+		"priority": 4,
+		"click": "https://example.com",
+		"markdown": true,
+		"actions": [
+			{"action": "view", "label": "Open", "url": "vscode://file/home/user/myproject"},
+			{"action": "http", "label": "Approve", "url": "https://example.com/approve", "method": "POST",
+			 "body": "{\"session\":\"s1\"}", "headers": {"X-Project": "myproject"}, "clear": true},
+			{"action": "broadcast", "label": "Tasker", "intent": "io.example.ACTION", "extras": {"cmd": "ack"}}
+		]
+	}`, string(got.body))
+}
+
+func TestNtfyTemplatedTopic(t *testing.T) {
+	srv, got := recordingServer(t)
+	notif := notifier.Notification{Message: "hi", Cwd: "/home/user/My Project!"}
+
+	p := &ntfy.Ntfy{URL: srv.URL + "/", Topic: "claude-{{.Project}}", JSON: true, Token: "tk"}
+	require.NoError(t, p.Send(context.Background(), notif))
+	assert.Equal(t, "/", got.path)
+	assert.Contains(t, string(got.body), `"topic":"claude-My-Project"`)
+	assert.Equal(t, "Bearer tk", got.headers.Get("Authorization"))
+
+	// Header mode appends the topic to the URL.
+	p.JSON = false
+	require.NoError(t, p.Send(context.Background(), notif))
+	assert.Equal(t, "/claude-My-Project", got.path)
+	assert.Equal(t, "hi", string(got.body))
+}
+
+func TestNtfyStructuredActionsInHeaderMode(t *testing.T) {
+	srv, got := recordingServer(t)
+
+	p := &ntfy.Ntfy{URL: srv.URL + "/topic", Action: []ntfy.Action{{Type: "view", Label: "Open", URL: "https://x/{{.Project}}"}}}
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{Message: "hi", Cwd: "/p/app"}))
+	assert.JSONEq(t, `[{"action":"view","label":"Open","url":"https://x/app"}]`, got.headers.Get("X-Actions"))
+}
+
+func TestNtfyJSONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		p    ntfy.Ntfy
+		want string
+	}{
+		{"no topic", ntfy.Ntfy{URL: "http://localhost", JSON: true}, "topic is required"},
+		{"empty topic", ntfy.Ntfy{URL: "http://localhost", Topic: "{{.Title}}", JSON: true}, "empty topic name"},
+		{"bad priority", ntfy.Ntfy{URL: "http://localhost/t", JSON: true, Priority: "loud"}, "unknown priority"},
+		{"both actions", ntfy.Ntfy{Actions: "view, Open, https://x", Action: []ntfy.Action{{Type: "view", Label: "a", URL: "u"}}}, "not both"},
+		{"bad action type", ntfy.Ntfy{Action: []ntfy.Action{{Type: "dial", Label: "a"}}}, `unknown type "dial"`},
+		{"action without url", ntfy.Ntfy{Action: []ntfy.Action{{Type: "view", Label: "a"}}}, "url is required"},
+		{"action without label", ntfy.Ntfy{Action: []ntfy.Action{{Type: "view", URL: "u"}}}, "label is required"},
+		{"too many actions", ntfy.Ntfy{Action: make([]ntfy.Action, 4)}, "too many actions"},
+		{"bad action template", ntfy.Ntfy{Action: []ntfy.Action{{Type: "view", Label: "a", URL: "{{.Nope"}}}, "rendering action 1 url template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.p.Send(context.Background(), notifier.Notification{Message: "hi"})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func writeTranscript(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	lines := `{"type":"user","message":{"role":"user","content":"deploy with token=hunter2"}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","name":"Bash","input":{"command":"make deploy"}}]}}
+`
+	require.NoError(t, os.WriteFile(path, []byte(lines), 0o600))
+
+	return path
+}
+
+func TestNtfyTranscriptAttachment(t *testing.T) {
+	srv, got := recordingServer(t)
+
+	p := &ntfy.Ntfy{}
+	ntfy.ApplyDefaults(p)
+	p.URL = srv.URL + "/alerts"
+	p.TranscriptTurns = 2
+	p.Title = "Claude — {{.Project}}"
+	p.Message = "{{.Message}}\nReview before approving"
+
+	err := p.Send(context.Background(), notifier.Notification{
+		Message: "Needs permission", Cwd: "/p/my app", TranscriptPath: writeTranscript(t),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, http.MethodPut, got.method)
+	assert.Equal(t, "transcript-my-app.md", got.headers.Get("X-Filename"))
+	assert.Contains(t, string(got.body), "### User\n\ndeploy with token=[REDACTED]")
+	assert.Contains(t, string(got.body), "**Bash** `make deploy`")
+
+	dec := new(mime.WordDecoder)
+	title, err := dec.DecodeHeader(got.headers.Get("Title"))
+	require.NoError(t, err)
+	assert.Equal(t, "Claude — my app", title)
+	message, err := dec.DecodeHeader(got.headers.Get("X-Message"))
+	require.NoError(t, err)
+	assert.Equal(t, "Needs permission\nReview before approving", message)
+}
+
+func TestNtfyTranscriptTextFormat(t *testing.T) {
+	srv, got := recordingServer(t)
+
+	p := &ntfy.Ntfy{URL: srv.URL + "/alerts", TranscriptTurns: 1, TranscriptFormat: "text", Filename: "log.txt"}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi", TranscriptPath: writeTranscript(t)})
+	require.NoError(t, err)
+
+	assert.Equal(t, "log.txt", got.headers.Get("X-Filename"))
+	assert.Equal(t, "hi", got.headers.Get("X-Message"))
+	assert.Equal(t, "Claude:\n[Bash] make deploy\n", string(got.body))
+}
+
+func TestNtfyTranscriptMissingFallsBack(t *testing.T) {
+	srv, got := recordingServer(t)
+
+	p := &ntfy.Ntfy{URL: srv.URL + "/alerts", TranscriptTurns: 2}
+	err := p.Send(context.Background(), notifier.Notification{
+		Message: "hi", TranscriptPath: filepath.Join(t.TempDir(), "missing.jsonl"),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "hi", string(got.body))
+	assert.Empty(t, got.headers.Get("X-Filename"))
+}
+
+func TestNtfyTranscriptWithAttach(t *testing.T) {
+	p := &ntfy.Ntfy{URL: "http://localhost/t", TranscriptTurns: 2, Attach: "https://example.com/a.png"}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not both")
+}
+
+func TestNtfyTranscriptWithJSON(t *testing.T) {
+	srv, got := recordingServer(t)
+
+	p := &ntfy.Ntfy{URL: srv.URL + "/t", TranscriptTurns: 2, JSON: true}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi", TranscriptPath: writeTranscript(t)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not both")
+	assert.Empty(t, got.method, "nothing is published")
+}
+
+func TestNtfySeverityPriority(t *testing.T) {
+	srv, got := recordingServer(t)
+
+	p := &ntfy.Ntfy{
+		URL:              srv.URL + "/alerts",
+		Priority:         "default",
+		SeverityPriority: map[string]string{"high": "high", "critical": "urgent"},
+		Title:            "[{{.Severity}}] {{.Project}}",
+	}
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{Message: "hi", Cwd: "/p/app", Severity: "critical"}))
+	assert.Equal(t, "urgent", got.headers.Get("Priority"))
+	assert.Equal(t, "[critical] app", got.headers.Get("Title"))
+
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{Message: "hi", Severity: "low"}))
+	assert.Equal(t, "default", got.headers.Get("Priority"))
+
+	p.JSON = true
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{Message: "hi", Severity: "high"}))
+	var msg map[string]any
+	require.NoError(t, json.Unmarshal(got.body, &msg))
+	assert.InDelta(t, 4, msg["priority"], 0)
+}
@@ -0,0 +1,248 @@
+package ntfy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/felipeelias/claude-notifier/internal/tmpl"
+)
+
+const (
+	maxActions  = 3
+	maxTopicLen = 64
+)
+
+var invalidTopicChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// Action is an action button declared as a [[notifiers.ntfy.action]] table.
+// URL, body and header values are Go templates.
+type Action struct {
+	Type    string            `toml:"type"`
+	Label   string            `toml:"label"`
+	URL     string            `toml:"url"`
+	Method  string            `toml:"method"`
+	Headers map[string]string `toml:"headers"`
+	Body    string            `toml:"body"`
+	Intent  string            `toml:"intent"`
+	Extras  map[string]string `toml:"extras"`
+	Clear   bool              `toml:"clear"`
+}
+
+// action is the JSON form of an action button, accepted in the JSON body
+// and in the X-Actions header.
+type action struct {
+	Action  string            `json:"action"`
+	Label   string            `json:"label"`
+	URL     string            `json:"url,omitempty"`
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	Intent  string            `json:"intent,omitempty"`
+	Extras  map[string]string `json:"extras,omitempty"`
+	Clear   bool              `json:"clear,omitempty"`
+}
+
+// message is the body of a JSON publish request.
+type message struct {
+	Topic    string   `json:"topic"`
+	Message  string   `json:"message"`
+	Title    string   `json:"title,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Priority int      `json:"priority,omitempty"`
+	Actions  []action `json:"actions,omitempty"`
+	Click    string   `json:"click,omitempty"`
+	Attach   string   `json:"attach,omitempty"`
+	Filename string   `json:"filename,omitempty"`
+	Icon     string   `json:"icon,omitempty"`
+	Email    string   `json:"email,omitempty"`
+	Delay    string   `json:"delay,omitempty"`
+	Markdown bool     `json:"markdown,omitempty"`
+}
+
+func (n *Ntfy) renderActions(tctx map[string]string) ([]action, error) {
+	if len(n.Action) == 0 {
+		return nil, nil
+	}
+	if n.Actions != "" {
+		return nil, errors.New("use either actions or [[action]] tables, not both")
+	}
+	if len(n.Action) > maxActions {
+		return nil, fmt.Errorf("too many actions (%d > %d)", len(n.Action), maxActions)
+	}
+
+	actions := make([]action, 0, len(n.Action))
+	for i, a := range n.Action {
+		name := "action " + strconv.Itoa(i+1)
+		if a.Label == "" {
+			return nil, fmt.Errorf("%s: label is required", name)
+		}
+		out := action{Action: a.Type, Label: a.Label, Clear: a.Clear}
+
+		var err error
+		switch a.Type {
+		case "view", "http":
+			if a.URL == "" {
+				return nil, fmt.Errorf("%s: url is required", name)
+			}
+			out.URL, err = tmpl.Render(name+" url", a.URL, tctx)
+			if err != nil {
+				return nil, err
+			}
+		case "broadcast":
+			out.Intent = a.Intent
+			out.Extras = a.Extras
+		default:
+			return nil, fmt.Errorf("%s: unknown type %q (want view, http or broadcast)", name, a.Type)
+		}
+
+		if a.Type == "http" {
+			out.Method = a.Method
+			if a.Body != "" {
+				out.Body, err = tmpl.Render(name+" body", a.Body, tctx)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if len(a.Headers) > 0 {
+				out.Headers = make(map[string]string, len(a.Headers))
+				for key, value := range a.Headers {
+					out.Headers[key], err = tmpl.Render(name+" header "+key, value, tctx)
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+
+		actions = append(actions, out)
+	}
+
+	return actions, nil
+}
+
+// topic renders the topic template and replaces characters ntfy does not
+// allow in topic names.
+func (n *Ntfy) topic(tctx map[string]string) (string, error) {
+	topic, err := tmpl.Render("topic", n.Topic, tctx)
+	if err != nil {
+		return "", err
+	}
+	topic = strings.Trim(invalidTopicChars.ReplaceAllString(topic, "-"), "-")
+	if len(topic) > maxTopicLen {
+		topic = topic[:maxTopicLen]
+	}
+	if topic == "" {
+		return "", fmt.Errorf("topic %q renders to an empty topic name", n.Topic)
+	}
+
+	return topic, nil
+}
+
+// endpoint is the URL to publish to in header mode: the URL itself, or the
+// URL with the rendered topic appended when topic is set.
+func (n *Ntfy) endpoint(tctx map[string]string) (string, error) {
+	if n.Topic == "" {
+		return n.URL, nil
+	}
+	topic, err := n.topic(tctx)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(n.URL, "/") + "/" + topic, nil
+}
+
+// publishJSON POSTs the message as JSON to the server root. Without a topic
+// template, the topic is the last path segment of the URL.
+func (n *Ntfy) publishJSON(ctx context.Context, tctx map[string]string, title, body, priority string, actions []action) error {
+	root := strings.TrimRight(n.URL, "/")
+	var topic string
+	if n.Topic != "" {
+		var err error
+		topic, err = n.topic(tctx)
+		if err != nil {
+			return err
+		}
+	} else {
+		u, err := url.Parse(root)
+		if err != nil {
+			return fmt.Errorf("parsing url: %w", err)
+		}
+		idx := strings.LastIndex(u.Path, "/")
+		if idx < 0 || u.Path[idx+1:] == "" {
+			return errors.New("topic is required when url has no topic")
+		}
+		topic = u.Path[idx+1:]
+		u.Path = u.Path[:idx]
+		root = u.String()
+	}
+
+	number, err := priorityNumber(priority)
+	if err != nil {
+		return err
+	}
+
+	var tags []string
+	for _, tag := range strings.Split(n.Tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	data, err := json.Marshal(message{
+		Topic:    topic,
+		Message:  body,
+		Title:    title,
+		Tags:     tags,
+		Priority: number,
+		Actions:  actions,
+		Click:    n.Click,
+		Attach:   n.Attach,
+		Filename: n.Filename,
+		Icon:     n.Icon,
+		Email:    n.Email,
+		Delay:    n.Delay,
+		Markdown: n.Markdown,
+	})
+	if err != nil {
+		return fmt.Errorf("encoding message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, root+"/", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	n.setAuth(req)
+
+	return do(req)
+}
+
+// priorityNumber maps ntfy priority names and numbers to 1-5; 0 leaves the
+// server default.
+func priorityNumber(priority string) (int, error) {
+	switch strings.ToLower(priority) {
+	case "":
+		return 0, nil
+	case "min", "1":
+		return 1, nil
+	case "low", "2":
+		return 2, nil
+	case "default", "3":
+		return 3, nil
+	case "high", "4":
+		return 4, nil
+	case "urgent", "max", "5":
+		return 5, nil
+	}
+
+	return 0, fmt.Errorf("unknown priority %q (want min, low, default, high, urgent or 1-5)", priority)
+}
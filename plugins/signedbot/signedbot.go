@@ -0,0 +1,361 @@
+package signedbot
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/tmpl"
+)
+
+const (
+	httpTimeout     = 30 * time.Second
+	httpErrorStatus = 400
+	maxResponseBody = 4096
+
+	flavorLark     = "lark"
+	flavorDingTalk = "dingtalk"
+	mentionAll     = "all"
+	mentionDefault = "default"
+
+	defaultTitle   = "Claude Code: {{.Project}}"
+	defaultMessage = "{{.Message}}"
+)
+
+var httpClient = &http.Client{
+	Timeout: httpTimeout,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// SignedBot posts to Lark/Feishu or DingTalk custom bot webhooks, signing
+// each request with the bot's HMAC-SHA256 secret.
+type SignedBot struct {
+	Flavor   string              `toml:"flavor"`
+	Webhook  string              `toml:"webhook"`
+	Secret   string              `toml:"secret"`
+	Title    string              `toml:"title"`
+	Message  string              `toml:"message"`
+	Color    string              `toml:"color"`
+	Mentions map[string][]string `toml:"mentions"`
+	Vars     map[string]string   `toml:"vars"`
+}
+
+// ApplyDefaults sets sane defaults on a new SignedBot instance.
+func ApplyDefaults(n *SignedBot) {
+	n.Flavor = flavorLark
+	n.Title = defaultTitle
+	n.Message = defaultMessage
+	n.Color = "blue"
+}
+
+func (n *SignedBot) Name() string { return "signedbot" }
+
+func (n *SignedBot) Send(ctx context.Context, notif notifier.Notification) error {
+	if n.Webhook == "" {
+		return errors.New("webhook is required")
+	}
+
+	tctx := tmpl.BuildContext(notif, n.Vars)
+
+	titleTmpl := n.Title
+	if titleTmpl == "" {
+		titleTmpl = defaultTitle
+	}
+	title, err := tmpl.Render("title", titleTmpl, tctx)
+	if err != nil {
+		return err
+	}
+
+	msgTmpl := n.Message
+	if msgTmpl == "" {
+		msgTmpl = defaultMessage
+	}
+	message, err := tmpl.Render("message", msgTmpl, tctx)
+	if err != nil {
+		return err
+	}
+
+	mentions := n.Mentions[notif.NotificationType]
+	if _, ok := n.Mentions[notif.NotificationType]; !ok {
+		mentions = n.Mentions[mentionDefault]
+	}
+
+	now := time.Now()
+	switch n.Flavor {
+	case flavorLark, "":
+		return n.sendLark(ctx, now, title, message, mentions)
+	case flavorDingTalk:
+		return n.sendDingTalk(ctx, now, title, message, mentions)
+	}
+
+	return fmt.Errorf("unknown flavor %q (want lark or dingtalk)", n.Flavor)
+}
+
+// larkSign computes the Lark/Feishu signature: the HMAC key is
+// "<timestamp>\n<secret>" and the message is empty.
+func larkSign(timestamp int64, secret string) string {
+	mac := hmac.New(sha256.New, []byte(strconv.FormatInt(timestamp, 10)+"\n"+secret))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// dingTalkSign computes the DingTalk signature: the HMAC key is the secret
+// and the message is "<timestamp_ms>\n<secret>".
+func dingTalkSign(timestampMS int64, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestampMS, 10) + "\n" + secret))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+type larkText struct {
+	Tag     string `json:"tag"`
+	Content string `json:"content"`
+}
+
+type larkCard struct {
+	Config struct {
+		WideScreenMode bool `json:"wide_screen_mode"`
+	} `json:"config"`
+	Header struct {
+		Title    larkText `json:"title"`
+		Template string   `json:"template,omitempty"`
+	} `json:"header"`
+	Elements []map[string]any `json:"elements"`
+}
+
+type larkPayload struct {
+	Timestamp string   `json:"timestamp,omitempty"`
+	Sign      string   `json:"sign,omitempty"`
+	MsgType   string   `json:"msg_type"`
+	Card      larkCard `json:"card"`
+}
+
+func (n *SignedBot) sendLark(ctx context.Context, now time.Time, title, message string, mentions []string) error {
+	var card larkCard
+	card.Config.WideScreenMode = true
+	card.Header.Title = larkText{Tag: "plain_text", Content: title}
+	card.Header.Template = n.Color
+
+	content := message
+	if len(mentions) > 0 {
+		ats := make([]string, 0, len(mentions))
+		for _, id := range mentions {
+			ats = append(ats, "<at id="+id+"></at>")
+		}
+		content += "\n" + strings.Join(ats, " ")
+	}
+	card.Elements = []map[string]any{{"tag": "div", "text": larkText{Tag: "lark_md", Content: content}}}
+
+	payload := larkPayload{MsgType: "interactive", Card: card}
+	if n.Secret != "" {
+		ts := now.Unix()
+		payload.Timestamp = strconv.FormatInt(ts, 10)
+		payload.Sign = larkSign(ts, n.Secret)
+	}
+
+	var result struct {
+		Code *int   `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	err := n.post(ctx, n.Webhook, payload, &result)
+	if err != nil {
+		return err
+	}
+	if result.Code != nil && *result.Code != 0 {
+		return fmt.Errorf("lark error %d: %s", *result.Code, result.Msg)
+	}
+
+	return nil
+}
+
+type dingTalkAt struct {
+	AtMobiles []string `json:"atMobiles,omitempty"`
+	AtUserIDs []string `json:"atUserIds,omitempty"`
+	IsAtAll   bool     `json:"isAtAll,omitempty"`
+}
+
+type dingTalkPayload struct {
+	MsgType  string `json:"msgtype"`
+	Markdown struct {
+		Title string `json:"title"`
+		Text  string `json:"text"`
+	} `json:"markdown"`
+	At dingTalkAt `json:"at"`
+}
+
+func (n *SignedBot) sendDingTalk(ctx context.Context, now time.Time, title, message string, mentions []string) error {
+	var payload dingTalkPayload
+	payload.MsgType = "markdown"
+	payload.Markdown.Title = title
+
+	// DingTalk only notifies mentioned users whose @ appears in the text.
+	text := "#### " + title + "\n\n" + message
+	var ats []string
+	for _, m := range mentions {
+		switch {
+		case m == mentionAll:
+			payload.At.IsAtAll = true
+			ats = append(ats, "@all")
+		case isPhone(m):
+			payload.At.AtMobiles = append(payload.At.AtMobiles, m)
+			ats = append(ats, "@"+m)
+		default:
+			payload.At.AtUserIDs = append(payload.At.AtUserIDs, m)
+			ats = append(ats, "@"+m)
+		}
+	}
+	if len(ats) > 0 {
+		text += "\n\n" + strings.Join(ats, " ")
+	}
+	payload.Markdown.Text = text
+
+	endpoint := n.Webhook
+	if n.Secret != "" {
+		u, err := url.Parse(n.Webhook)
+		if err != nil {
+			return fmt.Errorf("parsing webhook: %w", err)
+		}
+		ts := now.UnixMilli()
+		q := u.Query()
+		q.Set("timestamp", strconv.FormatInt(ts, 10))
+		q.Set("sign", dingTalkSign(ts, n.Secret))
+		u.RawQuery = q.Encode()
+		endpoint = u.String()
+	}
+
+	var result struct {
+		ErrCode *int   `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	err := n.post(ctx, endpoint, payload, &result)
+	if err != nil {
+		return err
+	}
+	if result.ErrCode != nil && *result.ErrCode != 0 {
+		return fmt.Errorf("dingtalk error %d: %s", *result.ErrCode, result.ErrMsg)
+	}
+
+	return nil
+}
+
+// isPhone reports whether a mention looks like a mobile number rather than
+// a user ID.
+func isPhone(s string) bool {
+	s = strings.TrimPrefix(s, "+")
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// post sends payload as JSON and decodes the bot API's JSON result. Both
+// APIs report most errors with HTTP 200 and a non-zero code in the body.
+func (n *SignedBot) post(ctx context.Context, endpoint string, payload, result any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encoding payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode >= httpErrorStatus {
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	// A body that is not JSON is not an error; only an explicit code is.
+	_ = json.NewDecoder(io.LimitReader(resp.Body, maxResponseBody)).Decode(result)
+
+	return nil
+}
+
+// SampleConfig returns example TOML configuration.
+func (n *SignedBot) SampleConfig() string {
+	return `## Lark/Feishu and DingTalk custom bot webhooks
+## Lark: https://open.larksuite.com/document/client-docs/bot-v3/add-custom-bot
+## DingTalk: https://open.dingtalk.com/document/robots/custom-robot-access
+[[notifiers.signedbot]]
+
+## Flavor: "lark" (interactive card; also Feishu) or "dingtalk" (markdown)
+flavor = "lark"
+
+## Bot webhook URL (required)
+## Lark: https://open.larksuite.com/open-apis/bot/v2/hook/<token>
+## Feishu: https://open.feishu.cn/open-apis/bot/v2/hook/<token>
+## DingTalk: https://oapi.dingtalk.com/robot/send?access_token=<token>
+webhook = "https://open.larksuite.com/open-apis/bot/v2/hook/xxxxxxxx"
+
+## Signing secret from the bot's security settings
+## Requests are signed with HMAC-SHA256 over the current timestamp
+# secret = ""
+
+## Go templates for the card header / markdown title and the body
+## Available variables: {{.Message}}, {{.Title}}, {{.Project}}, {{.Cwd}},
+## {{.NotificationType}}, {{.SessionID}}, {{.TranscriptPath}}, {{.Severity}}
+## Custom variables from [notifiers.signedbot.vars] are also available, title-cased
+# title = "Claude Code: {{.Project}}"
+# message = "{{.Message}}"
+
+## Lark card header color (blue, wathet, turquoise, green, yellow, orange,
+## red, carmine, violet, purple, indigo, grey)
+# color = "blue"
+
+## Users to @-mention, keyed by NotificationType; "default" applies to
+## types that are not listed. "all" mentions everyone.
+## Lark: open_id values (ou_...). DingTalk: mobile numbers or user IDs.
+# [notifiers.signedbot.mentions]
+# permission_prompt = ["all"]
+# default = []
+
+## User-defined template variables
+## Keys are title-cased for template access (env -> {{.Env}})
+# [notifiers.signedbot.vars]
+# env = "production"
+`
+}
+
+// Register adds signedbot to the given plugin registry.
+func Register(reg *notifier.Registry) {
+	err := reg.Register("signedbot", func() notifier.Notifier {
+		n := &SignedBot{}
+		ApplyDefaults(n)
+
+		return n
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,215 @@
+package signedbot_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/plugins/signedbot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type captured struct {
+	query url.Values
+	body  map[string]any
+}
+
+func fakeBot(t *testing.T, response string) (*httptest.Server, *captured) {
+	t.Helper()
+	got := &captured{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.query = r.URL.Query()
+		got.body = nil
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got.body))
+		_, _ = io.WriteString(w, response)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, got
+}
+
+func hmacBase64(key, msg string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(msg))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestSignedBotName(t *testing.T) {
+	p := &signedbot.SignedBot{}
+	assert.Equal(t, "signedbot", p.Name())
+}
+
+func TestSignedBotDefaults(t *testing.T) {
+	p := &signedbot.SignedBot{}
+	signedbot.ApplyDefaults(p)
+	assert.Equal(t, "lark", p.Flavor)
+	assert.Equal(t, "Claude Code: {{.Project}}", p.Title)
+	assert.Equal(t, "{{.Message}}", p.Message)
+	assert.Equal(t, "blue", p.Color)
+}
+
+func TestSignedBotImplementsNotifier(t *testing.T) {
+	var _ notifier.Notifier = &signedbot.SignedBot{}
+}
+
+func TestSignedBotLarkCard(t *testing.T) {
+	srv, got := fakeBot(t, `{"code":0,"msg":"success"}`)
+
+	p := &signedbot.SignedBot{}
+	signedbot.ApplyDefaults(p)
+	p.Webhook = srv.URL
+	p.Secret = "s3cret"
+	p.Mentions = map[string][]string{"permission_prompt": {"ou_abc", "all"}}
+
+	before := time.Now().Unix()
+	err := p.Send(context.Background(), notifier.Notification{
+		Message:          "Claude needs permission",
+		Cwd:              "/home/user/billing-api",
+		NotificationType: "permission_prompt",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "interactive", got.body["msg_type"])
+	ts, err := strconv.ParseInt(got.body["timestamp"].(string), 10, 64)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, ts, before)
+	assert.Equal(t, hmacBase64(got.body["timestamp"].(string)+"\ns3cret", ""), got.body["sign"])
+
+	card := got.body["card"].(map[string]any)
+	header := card["header"].(map[string]any)
+	assert.Equal(t, "blue", header["template"])
+	assert.Equal(t, "Claude Code: billing-api", header["title"].(map[string]any)["content"])
+	text := card["elements"].([]any)[0].(map[string]any)["text"].(map[string]any)
+	assert.Equal(t, "lark_md", text["tag"])
+	assert.Equal(t, "Claude needs permission\n<at id=ou_abc></at> <at id=all></at>", text["content"])
+}
+
+func TestSignedBotLarkUnsigned(t *testing.T) {
+	srv, got := fakeBot(t, `{"code":0}`)
+
+	p := &signedbot.SignedBot{Webhook: srv.URL, Message: "{{.Message}}"}
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{Message: "hi"}))
+	assert.NotContains(t, got.body, "sign")
+	assert.NotContains(t, got.body, "timestamp")
+}
+
+func TestSignedBotLarkAPIError(t *testing.T) {
+	srv, _ := fakeBot(t, `{"code":19021,"msg":"sign match fail or timestamp is not within one hour from current time"}`)
+
+	p := &signedbot.SignedBot{Webhook: srv.URL, Secret: "wrong"}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "19021")
+	assert.Contains(t, err.Error(), "sign match fail")
+}
+
+func TestSignedBotDingTalkMarkdown(t *testing.T) {
+	srv, got := fakeBot(t, `{"errcode":0,"errmsg":"ok"}`)
+
+	p := &signedbot.SignedBot{}
+	signedbot.ApplyDefaults(p)
+	p.Flavor = "dingtalk"
+	p.Webhook = srv.URL + "/robot/send?access_token=tok"
+	p.Secret = "SEC123"
+	p.Message = "**{{.NotificationType}}**: {{.Message}}"
+	p.Mentions = map[string][]string{
+		"idle_prompt": {"+86-13800000000", "user123"},
+		"default":     {"all"},
+	}
+
+	before := time.Now().UnixMilli()
+	err := p.Send(context.Background(), notifier.Notification{
+		Message:          "Waiting for input",
+		Cwd:              "/home/user/billing-api",
+		NotificationType: "idle_prompt",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "tok", got.query.Get("access_token"))
+	ts, err := strconv.ParseInt(got.query.Get("timestamp"), 10, 64)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, ts, before)
+	assert.Equal(t, hmacBase64("SEC123", got.query.Get("timestamp")+"\nSEC123"), got.query.Get("sign"))
+
+	assert.Equal(t, "markdown", got.body["msgtype"])
+	md := got.body["markdown"].(map[string]any)
+	assert.Equal(t, "Claude Code: billing-api", md["title"])
+	assert.Equal(t, "#### Claude Code: billing-api\n\n**idle_prompt**: Waiting for input\n\n@+86-13800000000 @user123", md["text"])
+	at := got.body["at"].(map[string]any)
+	assert.Equal(t, []any{"+86-13800000000"}, at["atMobiles"])
+	assert.Equal(t, []any{"user123"}, at["atUserIds"])
+	assert.NotContains(t, at, "isAtAll")
+}
+
+func TestSignedBotDingTalkDefaultMentions(t *testing.T) {
+	srv, got := fakeBot(t, `{"errcode":0,"errmsg":"ok"}`)
+
+	p := &signedbot.SignedBot{
+		Flavor:   "dingtalk",
+		Webhook:  srv.URL,
+		Mentions: map[string][]string{"default": {"all"}, "auth_success": {}},
+	}
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{Message: "hi", NotificationType: "idle_prompt"}))
+	assert.Equal(t, true, got.body["at"].(map[string]any)["isAtAll"])
+	assert.Contains(t, got.body["markdown"].(map[string]any)["text"], "@all")
+
+	// An explicit empty list for a type disables the default.
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{Message: "hi", NotificationType: "auth_success"}))
+	assert.Empty(t, got.body["at"])
+	assert.Empty(t, got.query.Get("sign"))
+}
+
+func TestSignedBotDingTalkAPIError(t *testing.T) {
+	srv, _ := fakeBot(t, `{"errcode":310000,"errmsg":"sign not match"}`)
+
+	p := &signedbot.SignedBot{Flavor: "dingtalk", Webhook: srv.URL, Secret: "x"}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "310000")
+	assert.Contains(t, err.Error(), "sign not match")
+}
+
+func TestSignedBotHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	p := &signedbot.SignedBot{Webhook: srv.URL}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "502")
+}
+
+func TestSignedBotMissingWebhook(t *testing.T) {
+	p := &signedbot.SignedBot{}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "webhook is required")
+}
+
+func TestSignedBotUnknownFlavor(t *testing.T) {
+	p := &signedbot.SignedBot{Flavor: "wecom", Webhook: "http://127.0.0.1:1"}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown flavor")
+}
+
+func TestSignedBotBadTemplate(t *testing.T) {
+	p := &signedbot.SignedBot{Webhook: "http://127.0.0.1:1", Title: "{{.Invalid"}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rendering title template")
+}
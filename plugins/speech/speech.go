@@ -0,0 +1,288 @@
+package speech
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/tmpl"
+)
+
+const (
+	backendEspeak = "espeak-ng"
+	backendPiper  = "piper"
+	backendSay    = "say"
+	backendSpd    = "spd-say"
+
+	defaultMessage = "{{.Message}} in project {{.Project}}"
+
+	// normalRate is the usual default speaking rate in words per minute.
+	normalRate = 175
+)
+
+// Speech reads notifications aloud through a local text-to-speech engine.
+type Speech struct {
+	Backend  string            `toml:"backend"`
+	Path     string            `toml:"path"`
+	Voice    string            `toml:"voice"`
+	Rate     int               `toml:"rate"`
+	Volume   int               `toml:"volume"`
+	Player   []string          `toml:"player"`
+	Message  string            `toml:"message"`
+	Messages map[string]string `toml:"messages"`
+	Vars     map[string]string `toml:"vars"`
+}
+
+// ApplyDefaults sets sane defaults on a new Speech instance.
+func ApplyDefaults(n *Speech) {
+	n.Backend = backendEspeak
+	if runtime.GOOS == "darwin" {
+		n.Backend = backendSay
+	}
+	n.Player = []string{"aplay", "-q", "-r", "22050", "-f", "S16_LE", "-t", "raw", "-"}
+	n.Message = defaultMessage
+}
+
+func (n *Speech) Name() string { return "speech" }
+
+func (n *Speech) Send(ctx context.Context, notif notifier.Notification) error {
+	tctx := tmpl.BuildContext(notif, n.Vars)
+
+	msgTmpl, ok := n.Messages[notif.NotificationType]
+	if !ok {
+		msgTmpl = n.Message
+		if msgTmpl == "" {
+			msgTmpl = defaultMessage
+		}
+	}
+	text, err := tmpl.Render("message", msgTmpl, tctx)
+	if err != nil {
+		return err
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return nil
+	}
+
+	if n.Volume < 0 || n.Volume > 100 {
+		return fmt.Errorf("volume must be between 0 and 100, got %d", n.Volume)
+	}
+
+	path := n.Path
+	if path == "" {
+		path = n.Backend
+	}
+
+	switch n.Backend {
+	case backendEspeak:
+		return run(exec.CommandContext(ctx, path, n.espeakArgs()...), text)
+	case backendSay:
+		return run(exec.CommandContext(ctx, path, n.sayArgs()...), n.sayText(text))
+	case backendSpd:
+		return run(exec.CommandContext(ctx, path, n.spdArgs(text)...), "")
+	case backendPiper:
+		return n.runPiper(ctx, path, text)
+	}
+
+	return fmt.Errorf("unknown backend %q (want espeak-ng, piper, say or spd-say)", n.Backend)
+}
+
+// run executes cmd with stdin as its input.
+func run(cmd *exec.Cmd, stdin string) error {
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("running %s: %s: %w", cmd.Path, strings.TrimSpace(string(output)), err)
+	}
+
+	return nil
+}
+
+// espeakArgs maps volume 0-100 onto espeak's amplitude, where 100 is normal.
+func (n *Speech) espeakArgs() []string {
+	var args []string
+	if n.Voice != "" {
+		args = append(args, "-v", n.Voice)
+	}
+	if n.Rate > 0 {
+		args = append(args, "-s", strconv.Itoa(n.Rate))
+	}
+	if n.Volume > 0 {
+		args = append(args, "-a", strconv.Itoa(n.Volume))
+	}
+
+	return append(args, "--stdin")
+}
+
+func (n *Speech) sayArgs() []string {
+	var args []string
+	if n.Voice != "" {
+		args = append(args, "-v", n.Voice)
+	}
+	if n.Rate > 0 {
+		args = append(args, "-r", strconv.Itoa(n.Rate))
+	}
+
+	return args
+}
+
+// sayText prefixes the text with say's embedded volume command, which is the
+// only way to set its volume.
+func (n *Speech) sayText(text string) string {
+	if n.Volume > 0 {
+		return fmt.Sprintf("[[volm %.2f]] %s", float64(n.Volume)/100, text)
+	}
+
+	return text
+}
+
+// spdArgs maps rate and volume onto spd-say's -100..100 scales. The text is
+// passed after "--" so it is never parsed as an option.
+func (n *Speech) spdArgs(text string) []string {
+	args := []string{"-w"}
+	if n.Voice != "" {
+		args = append(args, "-y", n.Voice)
+	}
+	if n.Rate > 0 {
+		args = append(args, "-r", strconv.Itoa(clamp((n.Rate-normalRate)*2/5, -100, 100)))
+	}
+	if n.Volume > 0 {
+		args = append(args, "-i", strconv.Itoa(n.Volume*2-100))
+	}
+
+	return append(args, "--", text)
+}
+
+// runPiper pipes piper's raw audio into the player command.
+func (n *Speech) runPiper(ctx context.Context, path, text string) error {
+	if n.Voice == "" {
+		return errors.New("voice (path to a piper .onnx model) is required for piper")
+	}
+	if len(n.Player) == 0 {
+		return errors.New("player is required for piper")
+	}
+
+	args := []string{"--model", n.Voice, "--output-raw"}
+	if n.Rate > 0 {
+		args = append(args, "--length_scale", strconv.FormatFloat(float64(normalRate)/float64(n.Rate), 'f', 2, 64))
+	}
+	if n.Volume > 0 {
+		args = append(args, "--volume", strconv.FormatFloat(float64(n.Volume)/100, 'f', 2, 64))
+	}
+
+	synth := exec.CommandContext(ctx, path, args...)
+	synth.Stdin = strings.NewReader(text)
+	var synthErr bytes.Buffer
+	synth.Stderr = &synthErr
+
+	play := exec.CommandContext(ctx, n.Player[0], n.Player[1:]...)
+	audio, err := synth.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("creating pipe: %w", err)
+	}
+	play.Stdin = audio
+	var playOut bytes.Buffer
+	play.Stdout = &playOut
+	play.Stderr = &playOut
+
+	// Start the player first, so piper never writes into a pipe nobody reads.
+	err = play.Start()
+	if err != nil {
+		_ = audio.Close()
+
+		return fmt.Errorf("running %s: %w", n.Player[0], err)
+	}
+	// The player holds its own copy of the read end; closing ours means piper
+	// gets a broken pipe instead of blocking if the player exits early.
+	_ = audio.Close()
+
+	err = synth.Start()
+	if err != nil {
+		_ = play.Process.Kill()
+		_ = play.Wait()
+
+		return fmt.Errorf("running %s: %w", path, err)
+	}
+	playErr := play.Wait()
+	if playErr != nil {
+		_ = synth.Process.Kill()
+	}
+	err = synth.Wait()
+	if playErr != nil {
+		return fmt.Errorf("running %s: %s: %w", n.Player[0], strings.TrimSpace(playOut.String()), playErr)
+	}
+	if err != nil {
+		return fmt.Errorf("running %s: %s: %w", path, strings.TrimSpace(synthErr.String()), err)
+	}
+
+	return nil
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
+
+// SampleConfig returns example TOML configuration.
+func (n *Speech) SampleConfig() string {
+	return `## Read notifications aloud with a local text-to-speech engine
+[[notifiers.speech]]
+
+## Backend: "espeak-ng", "piper", "say" (macOS) or "spd-say" (speech-dispatcher)
+## Defaults to "say" on macOS and "espeak-ng" elsewhere
+# backend = "espeak-ng"
+
+## Path to the backend binary (defaults to the backend name)
+# path = ""
+
+## Voice name (espeak-ng -v, say -v, spd-say -y)
+## For piper, the path to the .onnx voice model (required)
+# voice = ""
+
+## Speaking rate in words per minute (0 = backend default, ~175)
+# rate = 0
+
+## Volume in percent, 1-100 (0 = backend default)
+# volume = 0
+
+## Command that plays piper's raw audio from stdin (piper only)
+# player = ["aplay", "-q", "-r", "22050", "-f", "S16_LE", "-t", "raw", "-"]
+
+## Go template for the spoken text
+## Available variables: {{.Message}}, {{.Title}}, {{.Project}}, {{.Cwd}},
+## {{.NotificationType}}, {{.SessionID}}, {{.TranscriptPath}}, {{.Severity}}
+## Custom variables from [notifiers.speech.vars] are also available, title-cased
+# message = "{{.Message}} in project {{.Project}}"
+
+## Per-NotificationType templates; types not listed use message
+## An empty template keeps that type silent
+# [notifiers.speech.messages]
+# permission_prompt = "Claude needs permission in project {{.Project}}"
+# idle_prompt = "Claude is waiting in {{.Project}}"
+
+## User-defined template variables
+## Keys are title-cased for template access (env -> {{.Env}})
+# [notifiers.speech.vars]
+# env = "production"
+`
+}
+
+// Register adds speech to the given plugin registry.
+func Register(reg *notifier.Registry) {
+	err := reg.Register("speech", func() notifier.Notifier {
+		n := &Speech{}
+		ApplyDefaults(n)
+
+		return n
+	})
+	if err != nil {
+		panic(err)
+	}
+}
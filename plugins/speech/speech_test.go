@@ -0,0 +1,221 @@
+package speech_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/plugins/speech"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBackend creates a shell script that logs its args and stdin.
+// Returns the script path and the directory holding args.log and stdin.log.
+func fakeBackend(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	script := filepath.Join(dir, "tts")
+	content := fmt.Sprintf("#!/bin/sh\nprintf '%%s\\n' \"$@\" > %[1]s/args.log\ncat > %[1]s/stdin.log\n", dir)
+	require.NoError(t, os.WriteFile(script, []byte(content), 0755))
+
+	return script, dir
+}
+
+func readLog(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	require.NoError(t, err)
+
+	return string(data)
+}
+
+func readArgs(t *testing.T, dir string) []string {
+	t.Helper()
+
+	return strings.Split(strings.TrimSuffix(readLog(t, dir, "args.log"), "\n"), "\n")
+}
+
+var notif = notifier.Notification{
+	Message:          "Claude needs permission",
+	Cwd:              "/home/user/billing-api",
+	NotificationType: "permission_prompt",
+}
+
+func TestSpeechName(t *testing.T) {
+	p := &speech.Speech{}
+	assert.Equal(t, "speech", p.Name())
+}
+
+func TestSpeechDefaults(t *testing.T) {
+	p := &speech.Speech{}
+	speech.ApplyDefaults(p)
+	if runtime.GOOS == "darwin" {
+		assert.Equal(t, "say", p.Backend)
+	} else {
+		assert.Equal(t, "espeak-ng", p.Backend)
+	}
+	assert.Equal(t, "{{.Message}} in project {{.Project}}", p.Message)
+	assert.Equal(t, "aplay", p.Player[0])
+}
+
+func TestSpeechImplementsNotifier(t *testing.T) {
+	var _ notifier.Notifier = &speech.Speech{}
+}
+
+func TestSpeechEspeak(t *testing.T) {
+	script, dir := fakeBackend(t)
+
+	p := &speech.Speech{}
+	speech.ApplyDefaults(p)
+	p.Backend = "espeak-ng"
+	p.Path = script
+	p.Voice = "en-us"
+	p.Rate = 160
+	p.Volume = 80
+
+	require.NoError(t, p.Send(context.Background(), notif))
+	assert.Equal(t, []string{"-v", "en-us", "-s", "160", "-a", "80", "--stdin"}, readArgs(t, dir))
+	assert.Equal(t, "Claude needs permission in project billing-api", readLog(t, dir, "stdin.log"))
+}
+
+func TestSpeechSay(t *testing.T) {
+	script, dir := fakeBackend(t)
+
+	p := &speech.Speech{Backend: "say", Path: script, Voice: "Samantha", Volume: 50}
+	require.NoError(t, p.Send(context.Background(), notif))
+	assert.Equal(t, []string{"-v", "Samantha"}, readArgs(t, dir))
+	assert.Equal(t, "[[volm 0.50]] Claude needs permission in project billing-api", readLog(t, dir, "stdin.log"))
+}
+
+func TestSpeechSpdSay(t *testing.T) {
+	script, dir := fakeBackend(t)
+
+	p := &speech.Speech{Backend: "spd-say", Path: script, Rate: 225, Volume: 75, Message: "-h {{.Message}}"}
+	require.NoError(t, p.Send(context.Background(), notif))
+	// Text that looks like a flag stays after "--".
+	assert.Equal(t, []string{"-w", "-r", "20", "-i", "50", "--", "-h Claude needs permission"}, readArgs(t, dir))
+}
+
+func TestSpeechPiper(t *testing.T) {
+	script, dir := fakeBackend(t)
+
+	// The fake piper echoes stdin as "audio" to the fake player.
+	piper := filepath.Join(t.TempDir(), "piper")
+	content := fmt.Sprintf("#!/bin/sh\nprintf '%%s\\n' \"$@\" > %s/piper-args.log\ncat\n", dir)
+	require.NoError(t, os.WriteFile(piper, []byte(content), 0755))
+
+	p := &speech.Speech{
+		Backend: "piper",
+		Path:    piper,
+		Voice:   "/voices/en_US-amy-medium.onnx",
+		Rate:    350,
+		Player:  []string{script, "-q"},
+	}
+	require.NoError(t, p.Send(context.Background(), notif))
+
+	piperArgs := strings.Split(strings.TrimSpace(readLog(t, dir, "piper-args.log")), "\n")
+	assert.Equal(t, []string{"--model", "/voices/en_US-amy-medium.onnx", "--output-raw", "--length_scale", "0.50"}, piperArgs)
+	assert.Equal(t, []string{"-q"}, readArgs(t, dir))
+	assert.Equal(t, "Claude needs permission in project billing-api", readLog(t, dir, "stdin.log"))
+}
+
+func TestSpeechPiperPlayerFails(t *testing.T) {
+	dir := t.TempDir()
+	// The fake piper writes more audio than a pipe buffer holds.
+	piper := filepath.Join(dir, "piper")
+	require.NoError(t, os.WriteFile(piper, []byte("#!/bin/sh\nexec head -c 1000000 /dev/zero\n"), 0755))
+	failing := filepath.Join(dir, "player")
+	require.NoError(t, os.WriteFile(failing, []byte("#!/bin/sh\necho 'no audio device' >&2\nexit 3\n"), 0755))
+
+	tests := []struct {
+		name   string
+		player string
+		want   string
+	}{
+		{"missing", filepath.Join(dir, "missing"), "missing"},
+		{"exits", failing, "no audio device"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			p := &speech.Speech{Backend: "piper", Path: piper, Voice: "/voices/amy.onnx", Player: []string{tt.player}}
+			err := p.Send(ctx, notif)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want, "the player's error is reported")
+			assert.NoError(t, ctx.Err(), "piper doesn't block until the timeout")
+		})
+	}
+}
+
+func TestSpeechPiperRequiresVoice(t *testing.T) {
+	p := &speech.Speech{Backend: "piper", Player: []string{"aplay"}}
+	err := p.Send(context.Background(), notif)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "voice")
+}
+
+func TestSpeechPerTypeMessages(t *testing.T) {
+	script, dir := fakeBackend(t)
+
+	p := &speech.Speech{
+		Backend: "espeak-ng",
+		Path:    script,
+		Messages: map[string]string{
+			"permission_prompt": "Claude needs permission in project {{.Project}}, {{.Who}}",
+			"auth_success":      "",
+		},
+		Vars: map[string]string{"who": "Felipe"},
+	}
+	require.NoError(t, p.Send(context.Background(), notif))
+	assert.Equal(t, "Claude needs permission in project billing-api, Felipe", readLog(t, dir, "stdin.log"))
+
+	// An empty per-type template keeps the type silent.
+	require.NoError(t, os.Remove(filepath.Join(dir, "stdin.log")))
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{NotificationType: "auth_success"}))
+	assert.NoFileExists(t, filepath.Join(dir, "stdin.log"))
+}
+
+func TestSpeechCollapsesWhitespace(t *testing.T) {
+	script, dir := fakeBackend(t)
+
+	p := &speech.Speech{Backend: "espeak-ng", Path: script, Message: "{{.Message}}"}
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{Message: "line one\n\n  line two"}))
+	assert.Equal(t, "line one line two", readLog(t, dir, "stdin.log"))
+}
+
+func TestSpeechBackendFailure(t *testing.T) {
+	p := &speech.Speech{Backend: "espeak-ng", Path: "/bin/false"}
+	err := p.Send(context.Background(), notif)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "running /bin/false")
+}
+
+func TestSpeechInvalidVolume(t *testing.T) {
+	p := &speech.Speech{Backend: "espeak-ng", Volume: 150}
+	err := p.Send(context.Background(), notif)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "volume must be between 0 and 100")
+}
+
+func TestSpeechUnknownBackend(t *testing.T) {
+	p := &speech.Speech{Backend: "festival"}
+	err := p.Send(context.Background(), notif)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown backend")
+}
+
+func TestSpeechBadTemplate(t *testing.T) {
+	p := &speech.Speech{Backend: "espeak-ng", Message: "{{.Invalid"}
+	err := p.Send(context.Background(), notif)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rendering message template")
+}
@@ -0,0 +1,102 @@
+package terminalnotifier
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/felipeelias/claude-notifier/internal/tmpl"
+)
+
+// openURL returns the -open value. Without open_schemes, open is used as a
+// static string. With it, open is a template whose interpolated values are
+// URL-escaped, and the result must use one of the allowed schemes.
+func (n *TerminalNotifier) openURL(tctx map[string]string) (string, error) {
+	if n.Open == "" || len(n.OpenSchemes) == 0 {
+		return n.Open, nil
+	}
+
+	escaped := make(map[string]string, len(tctx))
+	for key, value := range tctx {
+		escaped[key] = escapeURLValue(value, pathKeys[key])
+	}
+	rendered, err := tmpl.Render("open", n.Open, escaped)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(rendered)
+	if err != nil {
+		return "", fmt.Errorf("open: parsing %q: %w", rendered, err)
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if !slices.ContainsFunc(n.OpenSchemes, func(s string) bool { return strings.EqualFold(s, scheme) }) {
+		return "", fmt.Errorf("open: scheme %q is not in open_schemes", u.Scheme)
+	}
+
+	return rendered, nil
+}
+
+// pathKeys are the template variables holding file paths, which keep their
+// "/" separators when escaped.
+var pathKeys = map[string]bool{"Cwd": true, "TranscriptPath": true}
+
+// escapeURLValue percent-encodes every byte of s except unreserved
+// characters, and "/" when keepSlash is set, so an interpolated value can't
+// add query parameters, fragments, userinfo or a scheme to the URL.
+func escapeURLValue(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := range len(s) {
+		c := s[i]
+		if isUnreserved(c) || (keepSlash && c == '/') {
+			b.WriteByte(c)
+
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+
+	return b.String()
+}
+
+// isUnreserved reports whether c may appear anywhere in a URL unescaped
+// (RFC 3986, section 2.3).
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+// executeCommand returns the -execute value. terminal-notifier runs it with
+// /bin/sh -c, so execute_args elements are rendered and then single-quoted:
+// the shell sees exactly one word per element and interprets none of it.
+func (n *TerminalNotifier) executeCommand(tctx map[string]string) (string, error) {
+	if len(n.ExecuteArgs) == 0 {
+		return n.Execute, nil
+	}
+	if n.Execute != "" {
+		return "", errors.New("use either execute or execute_args, not both")
+	}
+
+	words := make([]string, 0, len(n.ExecuteArgs))
+	for i, arg := range n.ExecuteArgs {
+		rendered, err := tmpl.Render(fmt.Sprintf("execute_args[%d]", i), arg, tctx)
+		if err != nil {
+			return "", err
+		}
+		if strings.ContainsRune(rendered, 0) {
+			return "", fmt.Errorf("execute_args[%d] contains a NUL byte", i)
+		}
+		if i == 0 && rendered == "" {
+			return "", errors.New("execute_args[0] must name a program")
+		}
+		words = append(words, shellQuote(rendered))
+	}
+
+	return strings.Join(words, " "), nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
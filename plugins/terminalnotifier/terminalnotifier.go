@@ -18,13 +18,19 @@ type TerminalNotifier struct {
 	Sound        string            `toml:"sound"`
 	Group        string            `toml:"group"`
 	Open         string            `toml:"open"`
+	OpenSchemes  []string          `toml:"open_schemes"`
 	Execute      string            `toml:"execute"`
+	ExecuteArgs  []string          `toml:"execute_args"`
 	Activate     string            `toml:"activate"`
 	Sender       string            `toml:"sender"`
 	AppIcon      string            `toml:"app_icon"`
 	ContentImage string            `toml:"content_image"`
 	IgnoreDnD    bool              `toml:"ignore_dnd"`
 	Vars         map[string]string `toml:"vars"`
+
+	// SeveritySound maps severity levels to sounds, overriding Sound for the
+	// levels it lists.
+	SeveritySound map[string]string `toml:"severity_sound"`
 }
 
 // ApplyDefaults sets sane defaults on a new TerminalNotifier instance.
@@ -49,7 +55,7 @@ path = "terminal-notifier"
 
 ## Go template for the message body
 ## Available variables: {{.Message}}, {{.Title}}, {{.Project}}, {{.Cwd}},
-## {{.NotificationType}}, {{.SessionID}}, {{.TranscriptPath}}
+## {{.NotificationType}}, {{.SessionID}}, {{.TranscriptPath}}, {{.Severity}}
 ## Custom variables from [notifiers.terminal-notifier.vars] are also available, title-cased
 # message = "{{.Message}}"
 
@@ -62,18 +68,36 @@ path = "terminal-notifier"
 ## Sound to play ("default" for system default, or a sound name from /System/Library/Sounds)
 # sound = ""
 
+## Sound per severity level (low, normal, high, critical), overriding sound
+## for the levels listed; severity comes from [global.severity]
+# severity_sound = { high = "Ping", critical = "Sosumi" }
+
 ## Group ID — only one notification per group is shown, replacing previous ones
 ## Defaults to session ID so notifications from the same session replace each other
+## The group is also removed from Notification Center when the session resumes
+## (UserPromptSubmit and SessionEnd hook events)
 # group = "{{.SessionID}}"
 
 ## URL or custom scheme to open when clicking the notification
 ## Not templated — static string for security (terminal-notifier executes these)
 # open = ""
 
+## Allowed URL schemes; setting this makes open a Go template
+## Interpolated values are URL-escaped ({{.Cwd}} and {{.TranscriptPath}}
+## keep their "/"), and a URL with any other scheme is rejected, so a crafted
+## directory name or message cannot change what is opened
+# open_schemes = ["vscode", "https"]
+# open = "vscode://file{{.Cwd}}"
+
 ## Shell command to run when clicking the notification
 ## Not templated — static string for security (terminal-notifier executes these)
 # execute = ""
 
+## Command to run when clicking the notification, as a list of arguments
+## (use instead of execute); each argument is a Go template and is passed
+## as a single word, never interpreted by the shell
+# execute_args = ["/usr/bin/open", "-a", "iTerm", "{{.Cwd}}"]
+
 ## App bundle ID to activate when clicking the notification
 # activate = ""
 
@@ -113,6 +137,46 @@ func (n *TerminalNotifier) Send(ctx context.Context, notif notifier.Notification
 	return nil
 }
 
+// Clear removes the session's notification from Notification Center. It
+// needs a group, since terminal-notifier can only remove by group.
+func (n *TerminalNotifier) Clear(ctx context.Context, notif notifier.Notification) error {
+	tctx := tmpl.BuildContext(notif, n.Vars)
+
+	args, err := n.removeArgs(tctx)
+	if err != nil {
+		return err
+	}
+	if args == nil {
+		return nil
+	}
+
+	cmd := exec.CommandContext(ctx, n.Path, args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("running %s: %s: %w", n.Path, string(output), err)
+	}
+
+	return nil
+}
+
+// removeArgs returns the arguments that remove the notification group, or
+// nil when the group renders empty.
+func (n *TerminalNotifier) removeArgs(tctx map[string]string) ([]string, error) {
+	groupTmpl := n.Group
+	if groupTmpl == "" {
+		groupTmpl = "{{.SessionID}}"
+	}
+	group, err := tmpl.Render("group", groupTmpl, tctx)
+	if err != nil {
+		return nil, err
+	}
+	if group == "" {
+		return nil, nil
+	}
+
+	return []string{"-remove", group}, nil
+}
+
 func (n *TerminalNotifier) buildArgs(tctx map[string]string) ([]string, error) {
 	type tmplField struct {
 		name, value, fallback, flag string
@@ -144,10 +208,19 @@ func (n *TerminalNotifier) buildArgs(tctx map[string]string) ([]string, error) {
 		}
 	}
 
+	open, err := n.openURL(tctx)
+	if err != nil {
+		return nil, err
+	}
+	execute, err := n.executeCommand(tctx)
+	if err != nil {
+		return nil, err
+	}
+
 	staticFlags := []struct{ flag, value string }{
-		{"-sound", n.Sound},
-		{"-open", n.Open},
-		{"-execute", n.Execute},
+		{"-sound", notifier.ForSeverity(n.SeveritySound, tctx["Severity"], n.Sound)},
+		{"-open", open},
+		{"-execute", execute},
 		{"-activate", n.Activate},
 		{"-sender", n.Sender},
 		{"-appIcon", n.AppIcon},
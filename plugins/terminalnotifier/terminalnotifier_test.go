@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -283,3 +284,169 @@ func TestSendMinimalConfig(t *testing.T) {
 	assert.NotContains(t, args, "-contentImage")
 	assert.NotContains(t, args, "-ignoreDnD")
 }
+
+func TestImplementsClearer(t *testing.T) {
+	var _ notifier.Clearer = &tn.TerminalNotifier{}
+}
+
+func TestClearRemovesGroup(t *testing.T) {
+	bin, logFile := fakeBinary(t)
+
+	p := &tn.TerminalNotifier{}
+	tn.ApplyDefaults(p)
+	p.Path = bin
+	err := p.Clear(context.Background(), notifier.Notification{
+		SessionID:     "sess-42",
+		HookEventName: "UserPromptSubmit",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"-remove", "sess-42"}, readArgs(t, logFile))
+}
+
+func TestClearCustomGroup(t *testing.T) {
+	bin, logFile := fakeBinary(t)
+
+	p := &tn.TerminalNotifier{Path: bin, Group: "claude-{{.Project}}"}
+	err := p.Clear(context.Background(), notifier.Notification{Cwd: "/tmp/proj"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"-remove", "claude-proj"}, readArgs(t, logFile))
+}
+
+func TestClearWithoutGroupDoesNothing(t *testing.T) {
+	bin, logFile := fakeBinary(t)
+
+	p := &tn.TerminalNotifier{Path: bin}
+	require.NoError(t, p.Clear(context.Background(), notifier.Notification{}))
+
+	assert.NoFileExists(t, logFile)
+}
+
+// argValue returns the value following flag in args.
+func argValue(t *testing.T, args []string, flag string) string {
+	t.Helper()
+	for i, arg := range args {
+		if arg == flag && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	t.Fatalf("flag %s not found in args", flag)
+
+	return ""
+}
+
+func TestSendSafeTemplatedOpen(t *testing.T) {
+	bin, logFile := fakeBinary(t)
+
+	p := &tn.TerminalNotifier{
+		Path:        bin,
+		Open:        "vscode://file{{.Cwd}}?session={{.SessionID}}",
+		OpenSchemes: []string{"vscode"},
+	}
+	err := p.Send(context.Background(), notifier.Notification{
+		Message:   "hi",
+		Cwd:       "/tmp/my proj?x=1#frag",
+		SessionID: "a&b",
+	})
+	require.NoError(t, err)
+
+	assertArgPair(t, readArgs(t, logFile), "-open", "vscode://file/tmp/my%20proj%3Fx%3D1%23frag?session=a%26b")
+}
+
+func TestSendSafeOpenCannotInjectParameters(t *testing.T) {
+	bin, logFile := fakeBinary(t)
+
+	p := &tn.TerminalNotifier{
+		Path:        bin,
+		Open:        "https://example.com/notify?text={{.Message}}&project={{.Project}}",
+		OpenSchemes: []string{"https"},
+	}
+	err := p.Send(context.Background(), notifier.Notification{
+		Message: "done&redirect=https://evil.example/x;y=1+2@host",
+		Cwd:     "/tmp/a/b",
+	})
+	require.NoError(t, err)
+
+	assertArgPair(t, readArgs(t, logFile), "-open",
+		"https://example.com/notify?text=done%26redirect%3Dhttps%3A%2F%2Fevil.example%2Fx%3By%3D1%2B2%40host&project=b")
+}
+
+func TestSendSafeOpenRejectsSchemes(t *testing.T) {
+	tests := []struct {
+		name string
+		open string
+		cwd  string
+	}{
+		{"scheme from template", "file://{{.Cwd}}", "/tmp/proj"},
+		{"scheme from value", "{{.Cwd}}", "javascript:alert(1)"},
+		{"no scheme", "{{.Cwd}}", "/tmp/proj"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bin, logFile := fakeBinary(t)
+
+			p := &tn.TerminalNotifier{Path: bin, Open: tt.open, OpenSchemes: []string{"vscode", "https"}}
+			err := p.Send(context.Background(), notifier.Notification{Message: "hi", Cwd: tt.cwd})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "not in open_schemes")
+			assert.NoFileExists(t, logFile)
+		})
+	}
+}
+
+func TestSendExecuteArgsCannotBeInjected(t *testing.T) {
+	bin, logFile := fakeBinary(t)
+	target, targetLog := fakeBinary(t)
+	dir := t.TempDir()
+	malicious := dir + "/x'; touch " + dir + "/pwned1; echo '$(touch " + dir + "/pwned2)`touch " + dir + "/pwned3` \"a b\" *"
+
+	p := &tn.TerminalNotifier{
+		Path:        bin,
+		ExecuteArgs: []string{target, "--cwd", "{{.Cwd}}", "{{.Message}}"},
+	}
+	err := p.Send(context.Background(), notifier.Notification{Message: "it's done", Cwd: malicious})
+	require.NoError(t, err)
+
+	// Run the command the way terminal-notifier does.
+	command := argValue(t, readArgs(t, logFile), "-execute")
+	output, err := exec.Command("/bin/sh", "-c", command).CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	assert.Equal(t, []string{"--cwd", malicious, "it's done"}, readArgs(t, targetLog))
+	for _, name := range []string{"pwned1", "pwned2", "pwned3"} {
+		assert.NoFileExists(t, filepath.Join(dir, name))
+	}
+}
+
+func TestSendExecuteConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		p    tn.TerminalNotifier
+		want string
+	}{
+		{"both forms", tn.TerminalNotifier{Execute: "true", ExecuteArgs: []string{"true"}}, "not both"},
+		{"empty program", tn.TerminalNotifier{ExecuteArgs: []string{"{{.SessionID}}"}}, "must name a program"},
+		{"bad template", tn.TerminalNotifier{ExecuteArgs: []string{"open", "{{.Cwd"}}, "rendering execute_args[1] template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bin, _ := fakeBinary(t)
+			tt.p.Path = bin
+			err := tt.p.Send(context.Background(), notifier.Notification{Message: "hi"})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestSendSeveritySound(t *testing.T) {
+	bin, logFile := fakeBinary(t)
+
+	p := &tn.TerminalNotifier{Path: bin, Sound: "default", SeveritySound: map[string]string{"critical": "Sosumi"}}
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{Message: "hi", Severity: "critical"}))
+	assertArgPair(t, readArgs(t, logFile), "-sound", "Sosumi")
+
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{Message: "hi", Severity: "low"}))
+	assertArgPair(t, readArgs(t, logFile), "-sound", "default")
+}
@@ -0,0 +1,288 @@
+package twilio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/tmpl"
+)
+
+const (
+	httpTimeout     = 30 * time.Second
+	httpErrorStatus = 400
+	maxErrorBody    = 4096
+	defaultURL      = "https://api.twilio.com"
+)
+
+// SMS segment sizes. A message that fits in a single segment gets the full
+// size; concatenated messages lose a few characters per segment to the UDH.
+const (
+	gsmSingle  = 160
+	gsmMulti   = 153
+	ucsSingle  = 70
+	ucsMulti   = 67
+	ellipsis   = "..."
+	ellipsisSz = 3
+)
+
+var httpClient = &http.Client{
+	Timeout: httpTimeout,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Twilio sends SMS notifications via the Twilio Programmable Messaging API.
+type Twilio struct {
+	URL                 string            `toml:"url"`
+	AccountSID          string            `toml:"account_sid"`
+	AuthToken           string            `toml:"auth_token"`
+	APIKey              string            `toml:"api_key"`
+	APISecret           string            `toml:"api_secret"`
+	From                string            `toml:"from"`
+	MessagingServiceSID string            `toml:"messaging_service_sid"`
+	To                  []string          `toml:"to"`
+	Message             string            `toml:"message"`
+	MaxSegments         int               `toml:"max_segments"`
+	Vars                map[string]string `toml:"vars"`
+}
+
+// ApplyDefaults sets sane defaults on a new Twilio instance.
+func ApplyDefaults(n *Twilio) {
+	n.URL = defaultURL
+	n.Message = "Claude Code ({{.Project}}): {{.Message}}"
+	n.MaxSegments = 1
+}
+
+func (n *Twilio) Name() string { return "twilio" }
+
+func (n *Twilio) Send(ctx context.Context, notif notifier.Notification) error {
+	if n.AccountSID == "" {
+		return errors.New("account_sid is required")
+	}
+	if n.From == "" && n.MessagingServiceSID == "" {
+		return errors.New("from or messaging_service_sid is required")
+	}
+	if len(n.To) == 0 {
+		return errors.New("at least one recipient in to is required")
+	}
+
+	tctx := tmpl.BuildContext(notif, n.Vars)
+
+	msgTmpl := n.Message
+	if msgTmpl == "" {
+		msgTmpl = "Claude Code ({{.Project}}): {{.Message}}"
+	}
+	body, err := tmpl.Render("message", msgTmpl, tctx)
+	if err != nil {
+		return err
+	}
+	body = shorten(body, n.MaxSegments)
+
+	var errs []error
+	for _, to := range n.To {
+		err := n.sendOne(ctx, to, body)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sending to %s: %w", to, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (n *Twilio) sendOne(ctx context.Context, to, body string) error {
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("Body", body)
+	if n.MessagingServiceSID != "" {
+		form.Set("MessagingServiceSid", n.MessagingServiceSID)
+	} else {
+		form.Set("From", n.From)
+	}
+
+	base := n.URL
+	if base == "" {
+		base = defaultURL
+	}
+	endpoint := strings.TrimRight(base, "/") + "/2010-04-01/Accounts/" + url.PathEscape(n.AccountSID) + "/Messages.json"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	// Auth: API key takes precedence over the account auth token.
+	if n.APIKey != "" {
+		req.SetBasicAuth(n.APIKey, n.APISecret)
+	} else {
+		req.SetBasicAuth(n.AccountSID, n.AuthToken)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode >= httpErrorStatus {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(io.LimitReader(resp.Body, maxErrorBody)).Decode(&apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("server returned %s: %s", resp.Status, apiErr.Message)
+		}
+
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	return nil
+}
+
+// SampleConfig returns example TOML configuration.
+func (n *Twilio) SampleConfig() string {
+	return `## Twilio SMS notifications
+## https://www.twilio.com/docs/messaging/api/message-resource
+[[notifiers.twilio]]
+
+## Twilio Account SID (required)
+account_sid = "ACxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx"
+
+## Account auth token, used when no API key is set
+auth_token = ""
+
+## API key SID and secret (take precedence over auth_token)
+# api_key = ""
+# api_secret = ""
+
+## Sender phone number in E.164 format
+## Either from or messaging_service_sid is required
+from = "+15005550006"
+
+## Messaging Service SID (takes precedence over from)
+# messaging_service_sid = ""
+
+## Recipient phone numbers in E.164 format (required)
+to = ["+15551234567"]
+
+## Go template for the SMS body
+## Available variables: {{.Message}}, {{.Title}}, {{.Project}}, {{.Cwd}},
+## {{.NotificationType}}, {{.SessionID}}, {{.TranscriptPath}}, {{.Severity}}
+## Custom variables from [notifiers.twilio.vars] are also available, title-cased
+# message = "Claude Code ({{.Project}}): {{.Message}}"
+
+## Maximum number of SMS segments; longer bodies are shortened with "..."
+## Set to 0 to disable shortening
+# max_segments = 1
+
+## API base URL (override for testing)
+# url = "https://api.twilio.com"
+
+## User-defined template variables
+## Keys are title-cased for template access (env -> {{.Env}})
+# [notifiers.twilio.vars]
+# env = "production"
+`
+}
+
+// shorten truncates body so it fits in at most maxSegments SMS segments.
+// GSM-7 bodies are measured in septets (extension characters count twice);
+// anything else is sent as UCS-2 and measured in UTF-16 code units.
+func shorten(body string, maxSegments int) string {
+	if maxSegments <= 0 {
+		return body
+	}
+
+	gsm := isGSM(body)
+	single, multi := ucsSingle, ucsMulti
+	if gsm {
+		single, multi = gsmSingle, gsmMulti
+	}
+	capacity := single
+	if maxSegments > 1 {
+		capacity = multi * maxSegments
+	}
+
+	if units(body, gsm) <= capacity {
+		return body
+	}
+
+	limit := capacity - ellipsisSz
+	used := 0
+	var buf strings.Builder
+	for _, r := range body {
+		w := runeUnits(r, gsm)
+		if used+w > limit {
+			break
+		}
+		used += w
+		buf.WriteRune(r)
+	}
+
+	return strings.TrimRight(buf.String(), " \n") + ellipsis
+}
+
+func units(s string, gsm bool) int {
+	total := 0
+	for _, r := range s {
+		total += runeUnits(r, gsm)
+	}
+
+	return total
+}
+
+func runeUnits(r rune, gsm bool) int {
+	if gsm {
+		if strings.ContainsRune(gsmExtended, r) {
+			return 2
+		}
+
+		return 1
+	}
+	if utf8.RuneLen(r) == 4 { // outside the BMP, needs a surrogate pair
+		return 2
+	}
+
+	return 1
+}
+
+func isGSM(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune(gsmBasic, r) && !strings.ContainsRune(gsmExtended, r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+const (
+	gsmBasic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+		"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	gsmExtended = "^{}\\[~]|€\f"
+)
+
+// Register adds twilio to the given plugin registry.
+func Register(reg *notifier.Registry) {
+	err := reg.Register("twilio", func() notifier.Notifier {
+		n := &Twilio{}
+		ApplyDefaults(n)
+
+		return n
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,218 @@
+package twilio_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/plugins/twilio"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type capturedRequest struct {
+	path     string
+	form     url.Values
+	user     string
+	password string
+}
+
+// fakeTwilio records every request and replies with the given status.
+func fakeTwilio(t *testing.T, status int, body string) (*httptest.Server, *[]capturedRequest) {
+	t.Helper()
+	var (
+		mu   sync.Mutex
+		reqs []capturedRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		user, pass, _ := r.BasicAuth()
+		mu.Lock()
+		reqs = append(reqs, capturedRequest{path: r.URL.Path, form: r.PostForm, user: user, password: pass})
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &reqs
+}
+
+func TestTwilioName(t *testing.T) {
+	p := &twilio.Twilio{}
+	assert.Equal(t, "twilio", p.Name())
+}
+
+func TestTwilioDefaults(t *testing.T) {
+	p := &twilio.Twilio{}
+	twilio.ApplyDefaults(p)
+	assert.Equal(t, "https://api.twilio.com", p.URL)
+	assert.Equal(t, "Claude Code ({{.Project}}): {{.Message}}", p.Message)
+	assert.Equal(t, 1, p.MaxSegments)
+}
+
+func TestTwilioImplementsNotifier(t *testing.T) {
+	var _ notifier.Notifier = &twilio.Twilio{}
+}
+
+func TestTwilioSend(t *testing.T) {
+	srv, reqs := fakeTwilio(t, http.StatusCreated, `{"sid":"SM123"}`)
+
+	p := &twilio.Twilio{}
+	twilio.ApplyDefaults(p)
+	p.URL = srv.URL
+	p.AccountSID = "AC123"
+	p.AuthToken = "secret"
+	p.From = "+15005550006"
+	p.To = []string{"+15551111111", "+15552222222"}
+
+	err := p.Send(context.Background(), notifier.Notification{
+		Message: "Permission needed",
+		Cwd:     "/home/user/billing-api",
+	})
+	require.NoError(t, err)
+
+	require.Len(t, *reqs, 2)
+	got := (*reqs)[0]
+	assert.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", got.path)
+	assert.Equal(t, "AC123", got.user)
+	assert.Equal(t, "secret", got.password)
+	assert.Equal(t, "+15005550006", got.form.Get("From"))
+	assert.Equal(t, "Claude Code (billing-api): Permission needed", got.form.Get("Body"))
+
+	recipients := []string{(*reqs)[0].form.Get("To"), (*reqs)[1].form.Get("To")}
+	assert.ElementsMatch(t, []string{"+15551111111", "+15552222222"}, recipients)
+}
+
+func TestTwilioAPIKeyAndMessagingService(t *testing.T) {
+	srv, reqs := fakeTwilio(t, http.StatusCreated, `{}`)
+
+	p := &twilio.Twilio{
+		URL:                 srv.URL,
+		AccountSID:          "AC123",
+		AuthToken:           "ignored",
+		APIKey:              "SK456",
+		APISecret:           "keysecret",
+		From:                "+15005550006",
+		MessagingServiceSID: "MG789",
+		To:                  []string{"+15551111111"},
+		Message:             "{{.Message}}",
+	}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.NoError(t, err)
+
+	require.Len(t, *reqs, 1)
+	got := (*reqs)[0]
+	assert.Equal(t, "SK456", got.user)
+	assert.Equal(t, "keysecret", got.password)
+	assert.Equal(t, "MG789", got.form.Get("MessagingServiceSid"))
+	assert.Empty(t, got.form.Get("From"))
+}
+
+func TestTwilioShortensGSMToSegments(t *testing.T) {
+	srv, reqs := fakeTwilio(t, http.StatusCreated, `{}`)
+
+	p := &twilio.Twilio{
+		URL:         srv.URL,
+		AccountSID:  "AC123",
+		From:        "+15005550006",
+		To:          []string{"+15551111111"},
+		Message:     "{{.Message}}",
+		MaxSegments: 1,
+	}
+	err := p.Send(context.Background(), notifier.Notification{Message: strings.Repeat("a", 500)})
+	require.NoError(t, err)
+
+	body := (*reqs)[0].form.Get("Body")
+	assert.Len(t, body, 160)
+	assert.True(t, strings.HasSuffix(body, "..."))
+
+	p.MaxSegments = 2
+	err = p.Send(context.Background(), notifier.Notification{Message: strings.Repeat("a", 500)})
+	require.NoError(t, err)
+	assert.Len(t, (*reqs)[1].form.Get("Body"), 306)
+}
+
+func TestTwilioShortensUnicodeToSegments(t *testing.T) {
+	srv, reqs := fakeTwilio(t, http.StatusCreated, `{}`)
+
+	p := &twilio.Twilio{
+		URL:         srv.URL,
+		AccountSID:  "AC123",
+		From:        "+15005550006",
+		To:          []string{"+15551111111"},
+		Message:     "{{.Message}}",
+		MaxSegments: 1,
+	}
+	err := p.Send(context.Background(), notifier.Notification{Message: strings.Repeat("ж", 100)})
+	require.NoError(t, err)
+
+	body := (*reqs)[0].form.Get("Body")
+	assert.Equal(t, 70, utf8.RuneCountInString(body))
+}
+
+func TestTwilioNoShorteningWhenDisabled(t *testing.T) {
+	srv, reqs := fakeTwilio(t, http.StatusCreated, `{}`)
+
+	p := &twilio.Twilio{
+		URL:        srv.URL,
+		AccountSID: "AC123",
+		From:       "+15005550006",
+		To:         []string{"+15551111111"},
+		Message:    "{{.Message}}",
+	}
+	err := p.Send(context.Background(), notifier.Notification{Message: strings.Repeat("a", 500)})
+	require.NoError(t, err)
+	assert.Len(t, (*reqs)[0].form.Get("Body"), 500)
+}
+
+func TestTwilioServerError(t *testing.T) {
+	srv, _ := fakeTwilio(t, http.StatusBadRequest, `{"code":21211,"message":"The 'To' number is not valid."}`)
+
+	p := &twilio.Twilio{
+		URL:        srv.URL,
+		AccountSID: "AC123",
+		From:       "+15005550006",
+		To:         []string{"bogus"},
+	}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sending to bogus")
+	assert.Contains(t, err.Error(), "The 'To' number is not valid.")
+}
+
+func TestTwilioRequiresConfig(t *testing.T) {
+	p := &twilio.Twilio{}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "account_sid")
+
+	p.AccountSID = "AC123"
+	err = p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "from")
+
+	p.From = "+15005550006"
+	err = p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "to")
+}
+
+func TestTwilioBadTemplate(t *testing.T) {
+	p := &twilio.Twilio{
+		AccountSID: "AC123",
+		From:       "+15005550006",
+		To:         []string{"+15551111111"},
+		Message:    "{{.Invalid",
+	}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rendering message template")
+}
@@ -0,0 +1,193 @@
+package webpush
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// recordSize is the aes128gcm record size advertised in the header.
+	recordSize = 4096
+	saltSize   = 16
+	keySize    = 16
+	nonceSize  = 12
+	authSize   = 16
+	// headerSize is salt + record size + key id length + uncompressed P-256 key.
+	headerSize = saltSize + 4 + 1 + 65
+	// maxPlaintext is what fits in a single record of a 4096-byte push message.
+	maxPlaintext = recordSize - headerSize - authSize - 1
+	vapidTTL     = 12 * time.Hour
+	p256KeySize  = 32
+)
+
+// encrypt encrypts plaintext for a subscription per RFC 8291 using the
+// aes128gcm content coding from RFC 8188.
+func encrypt(plaintext []byte, sub Subscription) ([]byte, error) {
+	if len(plaintext) > maxPlaintext {
+		return nil, fmt.Errorf("payload too large (%d > %d bytes)", len(plaintext), maxPlaintext)
+	}
+
+	uaPublicBytes, err := decodeBase64(sub.Keys.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("decoding p256dh key: %w", err)
+	}
+	authSecret, err := decodeBase64(sub.Keys.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("decoding auth secret: %w", err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing p256dh key: %w", err)
+	}
+
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generating ephemeral key: %w", err)
+	}
+	asPublicBytes := asPrivate.PublicKey().Bytes()
+
+	ecdhSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, fmt.Errorf("computing shared secret: %w", err)
+	}
+
+	keyInfo := "WebPush: info\x00" + string(uaPublicBytes) + string(asPublicBytes)
+	ikm, err := hkdf.Key(sha256.New, ecdhSecret, authSecret, keyInfo, sha256.Size)
+	if err != nil {
+		return nil, fmt.Errorf("deriving input key: %w", err)
+	}
+
+	salt := make([]byte, saltSize)
+	_, err = rand.Read(salt)
+	if err != nil {
+		return nil, fmt.Errorf("generating salt: %w", err)
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, fmt.Errorf("deriving content key: %w", err)
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", keySize)
+	if err != nil {
+		return nil, fmt.Errorf("deriving content key: %w", err)
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", nonceSize)
+	if err != nil {
+		return nil, fmt.Errorf("deriving nonce: %w", err)
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, fmt.Errorf("creating cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("creating cipher: %w", err)
+	}
+
+	// A single record terminated by the 0x02 last-record delimiter.
+	record := append(append([]byte{}, plaintext...), 0x02)
+
+	out := make([]byte, 0, headerSize+len(record)+authSize)
+	out = append(out, salt...)
+	out = binary.BigEndian.AppendUint32(out, recordSize)
+	out = append(out, byte(len(asPublicBytes)))
+	out = append(out, asPublicBytes...)
+
+	return gcm.Seal(out, nonce, record, nil), nil
+}
+
+// vapidAuthorization builds the RFC 8292 Authorization header value for a
+// push endpoint.
+func vapidAuthorization(endpoint, subject, publicKey, privateKey string, now time.Time) (string, error) {
+	key, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("parsing endpoint: %w", err)
+	}
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]any{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(vapidTTL).Unix(),
+		"sub": subject,
+	})
+	if err != nil {
+		return "", fmt.Errorf("encoding claims: %w", err)
+	}
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("signing token: %w", err)
+	}
+	sig := make([]byte, 2*p256KeySize)
+	r.FillBytes(sig[:p256KeySize])
+	s.FillBytes(sig[p256KeySize:])
+
+	token := signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+
+	return "vapid t=" + token + ", k=" + strings.TrimRight(publicKey, "="), nil
+}
+
+func parsePrivateKey(privateKey string) (*ecdsa.PrivateKey, error) {
+	if privateKey == "" {
+		return nil, errors.New("vapid_private_key is required (run claude-notifier webpush keygen)")
+	}
+	raw, err := decodeBase64(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("decoding vapid_private_key: %w", err)
+	}
+	key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing vapid_private_key: %w", err)
+	}
+
+	return key, nil
+}
+
+// GenerateKeys returns a new VAPID key pair as unpadded base64url strings:
+// the uncompressed P-256 public key and the raw private scalar.
+func GenerateKeys() (string, string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("generating key: %w", err)
+	}
+	priv, err := key.Bytes()
+	if err != nil {
+		return "", "", fmt.Errorf("encoding private key: %w", err)
+	}
+	pub, err := key.PublicKey.Bytes()
+	if err != nil {
+		return "", "", fmt.Errorf("encoding public key: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(pub), base64.RawURLEncoding.EncodeToString(priv), nil
+}
+
+// decodeBase64 accepts both the URL-safe and standard alphabets, with or
+// without padding, as browsers and libraries vary.
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(strings.TrimSpace(s), "=")
+	s = strings.NewReplacer("+", "-", "/", "_").Replace(s)
+
+	return base64.RawURLEncoding.DecodeString(s)
+}
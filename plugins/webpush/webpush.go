@@ -0,0 +1,406 @@
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/felipeelias/claude-notifier/internal/config"
+	"github.com/felipeelias/claude-notifier/internal/fsutil"
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/tmpl"
+	ucli "github.com/urfave/cli/v2"
+)
+
+const (
+	httpTimeout     = 30 * time.Second
+	httpErrorStatus = 400
+	subsFilePerms   = 0600
+)
+
+var httpClient = &http.Client{
+	Timeout: httpTimeout,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Subscription is a browser PushSubscription as serialized by
+// PushSubscription.toJSON().
+type Subscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// WebPush sends notifications to browsers via the Push API.
+type WebPush struct {
+	Subscriptions   string            `toml:"subscriptions"`
+	VAPIDPublicKey  string            `toml:"vapid_public_key"`
+	VAPIDPrivateKey string            `toml:"vapid_private_key"`
+	Subject         string            `toml:"subject"`
+	TTL             int               `toml:"ttl"`
+	Urgency         string            `toml:"urgency"`
+	Message         string            `toml:"message"`
+	Title           string            `toml:"title"`
+	Vars            map[string]string `toml:"vars"`
+
+	// SeverityUrgency maps severity levels to urgencies, overriding Urgency
+	// for the levels it lists.
+	SeverityUrgency map[string]string `toml:"severity_urgency"`
+}
+
+// ApplyDefaults sets sane defaults on a new WebPush instance.
+func ApplyDefaults(n *WebPush) {
+	n.TTL = 3600
+	n.Urgency = "high"
+	n.Message = "{{.Message}}"
+	n.Title = "Claude Code ({{.Project}})"
+}
+
+func (n *WebPush) Name() string { return "webpush" }
+
+type payload struct {
+	Title            string `json:"title"`
+	Body             string `json:"body"`
+	Tag              string `json:"tag,omitempty"`
+	Project          string `json:"project,omitempty"`
+	NotificationType string `json:"notification_type,omitempty"`
+}
+
+func (n *WebPush) Send(ctx context.Context, notif notifier.Notification) error {
+	if n.Subscriptions == "" {
+		return errors.New("subscriptions is required")
+	}
+	if n.VAPIDPublicKey == "" || n.Subject == "" {
+		return errors.New("vapid_public_key and subject are required")
+	}
+
+	tctx := tmpl.BuildContext(notif, n.Vars)
+
+	msgTmpl := n.Message
+	if msgTmpl == "" {
+		msgTmpl = "{{.Message}}"
+	}
+	body, err := tmpl.Render("message", msgTmpl, tctx)
+	if err != nil {
+		return err
+	}
+
+	titleTmpl := n.Title
+	if titleTmpl == "" {
+		titleTmpl = "Claude Code ({{.Project}})"
+	}
+	title, err := tmpl.Render("title", titleTmpl, tctx)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := buildPayload(payload{
+		Title:            title,
+		Body:             body,
+		Tag:              notif.SessionID,
+		Project:          notif.Project(),
+		NotificationType: notif.NotificationType,
+	})
+	if err != nil {
+		return err
+	}
+
+	subs, err := loadSubscriptions(n.Subscriptions)
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return fmt.Errorf("no subscriptions in %s", n.Subscriptions)
+	}
+	urgency := notifier.ForSeverity(n.SeverityUrgency, notif.Severity, n.Urgency)
+
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		errs  []error
+		stale = map[string]bool{}
+	)
+	for _, sub := range subs {
+		wg.Go(func() {
+			gone, err := n.push(ctx, sub, plaintext, urgency)
+			mu.Lock()
+			defer mu.Unlock()
+			if gone {
+				stale[sub.Endpoint] = true
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("pushing to %s: %w", endpointHost(sub.Endpoint), err))
+			}
+		})
+	}
+	wg.Wait()
+
+	if len(stale) > 0 {
+		err := pruneSubscriptions(n.Subscriptions, stale)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// push delivers one encrypted message. It reports gone=true when the push
+// service says the subscription no longer exists.
+func (n *WebPush) push(ctx context.Context, sub Subscription, plaintext []byte, urgency string) (bool, error) {
+	auth, err := vapidAuthorization(sub.Endpoint, n.Subject, n.VAPIDPublicKey, n.VAPIDPrivateKey, time.Now())
+	if err != nil {
+		return false, err
+	}
+	ciphertext, err := encrypt(plaintext, sub)
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(ciphertext))
+	if err != nil {
+		return false, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", auth)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(max(n.TTL, 0)))
+	if urgency != "" {
+		req.Header.Set("Urgency", urgency)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("sending request: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		slog.Info("pruning expired web push subscription", "endpoint", endpointHost(sub.Endpoint))
+
+		return true, nil
+	case resp.StatusCode >= httpErrorStatus:
+		return false, fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	return false, nil
+}
+
+// buildPayload marshals p, shortening the body until it fits in one record.
+func buildPayload(p payload) ([]byte, error) {
+	for {
+		data, err := json.Marshal(p)
+		if err != nil {
+			return nil, fmt.Errorf("encoding payload: %w", err)
+		}
+		if len(data) <= maxPlaintext {
+			return data, nil
+		}
+		if p.Body == "" {
+			return nil, fmt.Errorf("payload too large (%d > %d bytes)", len(data), maxPlaintext)
+		}
+		excess := len(data) - maxPlaintext
+		cut := max(len(p.Body)-excess, 0)
+		for cut > 0 && !utf8.RuneStart(p.Body[cut]) {
+			cut--
+		}
+		p.Body = p.Body[:cut]
+	}
+}
+
+func loadSubscriptions(path string) ([]Subscription, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading subscriptions: %w", err)
+	}
+	var subs []Subscription
+	err = json.Unmarshal(data, &subs)
+	if err != nil {
+		return nil, fmt.Errorf("parsing subscriptions: %w", err)
+	}
+
+	return subs, nil
+}
+
+// pruneSubscriptions rewrites the subscriptions file without the stale
+// endpoints. The file is re-read so concurrent additions are preserved, and
+// entries are kept verbatim so fields like expirationTime survive.
+func pruneSubscriptions(path string, stale map[string]bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading subscriptions: %w", err)
+	}
+	var raw []json.RawMessage
+	err = json.Unmarshal(data, &raw)
+	if err != nil {
+		return fmt.Errorf("parsing subscriptions: %w", err)
+	}
+
+	kept := make([]json.RawMessage, 0, len(raw))
+	for _, entry := range raw {
+		var sub Subscription
+		if json.Unmarshal(entry, &sub) == nil && stale[sub.Endpoint] {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+
+	data, err = json.MarshalIndent(kept, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding subscriptions: %w", err)
+	}
+
+	err = fsutil.WriteFile(path, append(data, '\n'), subsFilePerms)
+	if err != nil {
+		return fmt.Errorf("pruning subscriptions: %w", err)
+	}
+
+	return nil
+}
+
+// endpointHost keeps the capability URL path out of logs and errors.
+func endpointHost(endpoint string) string {
+	rest, ok := strings.CutPrefix(endpoint, "https://")
+	if !ok {
+		rest, _ = strings.CutPrefix(endpoint, "http://")
+	}
+	host, _, _ := strings.Cut(rest, "/")
+
+	return host
+}
+
+// Command returns the "webpush" subcommand with VAPID key management.
+func (n *WebPush) Command() *ucli.Command {
+	return &ucli.Command{
+		Name:  "webpush",
+		Usage: "Web Push helpers",
+		Subcommands: []*ucli.Command{
+			{
+				Name:  "keygen",
+				Usage: "Generate a VAPID key pair and store it in the config file",
+				Flags: []ucli.Flag{
+					&ucli.BoolFlag{
+						Name:  "force",
+						Usage: "Overwrite existing keys",
+					},
+				},
+				Action: keygenAction,
+			},
+		},
+	}
+}
+
+func keygenAction(cmd *ucli.Context) error {
+	configPath := cmd.String("config")
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	if !cmd.Bool("force") {
+		for _, prim := range cfg.Notifiers["webpush"] {
+			var existing WebPush
+			err = cfg.Decode(prim, &existing)
+			if err != nil {
+				return fmt.Errorf("decoding config for webpush: %w", err)
+			}
+			if existing.VAPIDPrivateKey != "" {
+				return errors.New("VAPID keys already configured (use --force to replace them)")
+			}
+		}
+	}
+
+	pub, priv, err := GenerateKeys()
+	if err != nil {
+		return err
+	}
+
+	err = config.SetNotifierValues(configPath, "webpush", map[string]string{
+		"vapid_public_key":  pub,
+		"vapid_private_key": priv,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(cmd.App.Writer, "VAPID keys written to %s\n", configPath)
+	_, _ = fmt.Fprintf(cmd.App.Writer, "Public key (applicationServerKey): %s\n", pub)
+
+	return nil
+}
+
+// SampleConfig returns example TOML configuration.
+func (n *WebPush) SampleConfig() string {
+	return `## Browser notifications via the Web Push API (VAPID)
+## https://developer.mozilla.org/en-US/docs/Web/API/Push_API
+[[notifiers.webpush]]
+
+## JSON file holding an array of PushSubscription objects (required)
+## Subscriptions that the push service reports as gone are removed from it
+subscriptions = "/home/me/.config/claude-notifier/webpush-subscriptions.json"
+
+## VAPID key pair, generated with: claude-notifier webpush keygen
+## Use the public key as applicationServerKey when subscribing in the browser
+vapid_public_key = ""
+vapid_private_key = ""
+
+## Contact URI sent to push services (mailto: or https:) (required)
+subject = "mailto:me@example.com"
+
+## Seconds the push service keeps an undelivered message
+# ttl = 3600
+
+## Delivery urgency: very-low, low, normal, high
+# urgency = "high"
+
+## Urgency per severity level (low, normal, high, critical), overriding
+## urgency for the levels listed; severity comes from [global.severity]
+# severity_urgency = { low = "low", normal = "normal", high = "high", critical = "high" }
+
+## Go template for the notification body
+## Available variables: {{.Message}}, {{.Title}}, {{.Project}}, {{.Cwd}},
+## {{.NotificationType}}, {{.SessionID}}, {{.TranscriptPath}}, {{.Severity}}
+## Custom variables from [notifiers.webpush.vars] are also available, title-cased
+# message = "{{.Message}}"
+
+## Go template for the notification title
+# title = "Claude Code ({{.Project}})"
+
+## User-defined template variables
+## Keys are title-cased for template access (env -> {{.Env}})
+# [notifiers.webpush.vars]
+# env = "production"
+`
+}
+
+// Register adds webpush to the given plugin registry.
+func Register(reg *notifier.Registry) {
+	err := reg.Register("webpush", func() notifier.Notifier {
+		n := &WebPush{}
+		ApplyDefaults(n)
+
+		return n
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,397 @@
+package webpush_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	appcli "github.com/felipeelias/claude-notifier/internal/cli"
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/plugins/webpush"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// browser holds the client side of a push subscription.
+type browser struct {
+	key  *ecdh.PrivateKey
+	auth []byte
+}
+
+func newBrowser(t *testing.T) *browser {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	auth := make([]byte, 16)
+	_, err = rand.Read(auth)
+	require.NoError(t, err)
+
+	return &browser{key: key, auth: auth}
+}
+
+func (b *browser) subscription(endpoint string) map[string]any {
+	return map[string]any{
+		"endpoint":       endpoint,
+		"expirationTime": nil,
+		"keys": map[string]string{
+			"p256dh": base64.RawURLEncoding.EncodeToString(b.key.PublicKey().Bytes()),
+			"auth":   base64.RawURLEncoding.EncodeToString(b.auth),
+		},
+	}
+}
+
+// decrypt reverses RFC 8291 aes128gcm encryption as a browser would.
+func (b *browser) decrypt(t *testing.T, body []byte) []byte {
+	t.Helper()
+	require.Greater(t, len(body), 86)
+	salt := body[:16]
+	assert.Equal(t, uint32(4096), binary.BigEndian.Uint32(body[16:20]))
+	idLen := int(body[20])
+	asPublicBytes := body[21 : 21+idLen]
+	ciphertext := body[21+idLen:]
+
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicBytes)
+	require.NoError(t, err)
+	secret, err := b.key.ECDH(asPublic)
+	require.NoError(t, err)
+
+	info := "WebPush: info\x00" + string(b.key.PublicKey().Bytes()) + string(asPublicBytes)
+	ikm, err := hkdf.Key(sha256.New, secret, b.auth, info, 32)
+	require.NoError(t, err)
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	require.NoError(t, err)
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	require.NoError(t, err)
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	require.NoError(t, err)
+
+	block, err := aes.NewCipher(cek)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	require.NoError(t, err)
+	require.Equal(t, byte(0x02), plain[len(plain)-1], "missing last-record delimiter")
+
+	return plain[:len(plain)-1]
+}
+
+// verifyVAPID checks the ES256 token in an Authorization header and returns
+// its claims.
+func verifyVAPID(t *testing.T, header, publicKey string) map[string]any {
+	t.Helper()
+	require.True(t, strings.HasPrefix(header, "vapid t="), header)
+	token, key, ok := strings.Cut(strings.TrimPrefix(header, "vapid t="), ", k=")
+	require.True(t, ok)
+	assert.Equal(t, publicKey, key)
+
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+	pubBytes, err := base64.RawURLEncoding.DecodeString(key)
+	require.NoError(t, err)
+	pub, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), pubBytes)
+	require.NoError(t, err)
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	require.Len(t, sig, 64)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	assert.True(t, ecdsa.Verify(pub, digest[:], r, s), "invalid VAPID signature")
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	var claims map[string]any
+	require.NoError(t, json.Unmarshal(claimsJSON, &claims))
+
+	return claims
+}
+
+type pushRecord struct {
+	path    string
+	headers http.Header
+	body    []byte
+}
+
+// fakePushService records pushes and answers with a status per path.
+func fakePushService(t *testing.T, statuses map[string]int) (*httptest.Server, func() []pushRecord) {
+	t.Helper()
+	var (
+		mu      sync.Mutex
+		records []pushRecord
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		records = append(records, pushRecord{path: r.URL.Path, headers: r.Header, body: body})
+		mu.Unlock()
+		status, ok := statuses[r.URL.Path]
+		if !ok {
+			status = http.StatusCreated
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []pushRecord {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]pushRecord(nil), records...)
+	}
+}
+
+func writeSubscriptions(t *testing.T, subs ...map[string]any) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "subscriptions.json")
+	data, err := json.Marshal(subs)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0600))
+
+	return path
+}
+
+func newPlugin(t *testing.T, subsPath string) *webpush.WebPush {
+	t.Helper()
+	pub, priv, err := webpush.GenerateKeys()
+	require.NoError(t, err)
+
+	p := &webpush.WebPush{}
+	webpush.ApplyDefaults(p)
+	p.Subscriptions = subsPath
+	p.VAPIDPublicKey = pub
+	p.VAPIDPrivateKey = priv
+	p.Subject = "mailto:me@example.com"
+
+	return p
+}
+
+func TestWebPushName(t *testing.T) {
+	p := &webpush.WebPush{}
+	assert.Equal(t, "webpush", p.Name())
+}
+
+func TestWebPushDefaults(t *testing.T) {
+	p := &webpush.WebPush{}
+	webpush.ApplyDefaults(p)
+	assert.Equal(t, 3600, p.TTL)
+	assert.Equal(t, "high", p.Urgency)
+	assert.Equal(t, "{{.Message}}", p.Message)
+	assert.Equal(t, "Claude Code ({{.Project}})", p.Title)
+}
+
+func TestWebPushImplementsNotifier(t *testing.T) {
+	var _ notifier.Notifier = &webpush.WebPush{}
+	var _ appcli.Commander = &webpush.WebPush{}
+}
+
+func TestWebPushSend(t *testing.T) {
+	srv, records := fakePushService(t, nil)
+	b := newBrowser(t)
+	p := newPlugin(t, writeSubscriptions(t, b.subscription(srv.URL+"/push/abc")))
+
+	err := p.Send(context.Background(), notifier.Notification{
+		Message:          "Claude needs permission",
+		Cwd:              "/home/user/billing-api",
+		NotificationType: "permission_prompt",
+		SessionID:        "sess-1",
+	})
+	require.NoError(t, err)
+
+	got := records()
+	require.Len(t, got, 1)
+	assert.Equal(t, "/push/abc", got[0].path)
+	assert.Equal(t, "aes128gcm", got[0].headers.Get("Content-Encoding"))
+	assert.Equal(t, "3600", got[0].headers.Get("TTL"))
+	assert.Equal(t, "high", got[0].headers.Get("Urgency"))
+
+	claims := verifyVAPID(t, got[0].headers.Get("Authorization"), p.VAPIDPublicKey)
+	assert.Equal(t, srv.URL, claims["aud"])
+	assert.Equal(t, "mailto:me@example.com", claims["sub"])
+
+	var payload map[string]string
+	require.NoError(t, json.Unmarshal(b.decrypt(t, got[0].body), &payload))
+	assert.Equal(t, "Claude Code (billing-api)", payload["title"])
+	assert.Equal(t, "Claude needs permission", payload["body"])
+	assert.Equal(t, "sess-1", payload["tag"])
+	assert.Equal(t, "permission_prompt", payload["notification_type"])
+}
+
+func TestWebPushShortensLongBodies(t *testing.T) {
+	srv, records := fakePushService(t, nil)
+	b := newBrowser(t)
+	p := newPlugin(t, writeSubscriptions(t, b.subscription(srv.URL+"/push/abc")))
+
+	err := p.Send(context.Background(), notifier.Notification{Message: strings.Repeat("é", 4000)})
+	require.NoError(t, err)
+
+	got := records()
+	require.Len(t, got, 1)
+	assert.LessOrEqual(t, len(got[0].body), 4096)
+	var payload map[string]string
+	require.NoError(t, json.Unmarshal(b.decrypt(t, got[0].body), &payload))
+	assert.True(t, strings.HasPrefix(payload["body"], "éé"))
+}
+
+func TestWebPushPrunesGoneSubscriptions(t *testing.T) {
+	srv, records := fakePushService(t, map[string]int{
+		"/push/gone":    http.StatusGone,
+		"/push/missing": http.StatusNotFound,
+	})
+	b := newBrowser(t)
+	path := writeSubscriptions(t,
+		b.subscription(srv.URL+"/push/gone"),
+		b.subscription(srv.URL+"/push/ok"),
+		b.subscription(srv.URL+"/push/missing"),
+	)
+	p := newPlugin(t, path)
+
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.NoError(t, err)
+	assert.Len(t, records(), 3)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var remaining []map[string]any
+	require.NoError(t, json.Unmarshal(data, &remaining))
+	require.Len(t, remaining, 1)
+	assert.Equal(t, srv.URL+"/push/ok", remaining[0]["endpoint"])
+	assert.Contains(t, remaining[0], "expirationTime")
+}
+
+func TestWebPushServerError(t *testing.T) {
+	srv, _ := fakePushService(t, map[string]int{"/push/bad": http.StatusBadRequest})
+	b := newBrowser(t)
+	path := writeSubscriptions(t, b.subscription(srv.URL+"/push/bad"))
+	p := newPlugin(t, path)
+
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400")
+	assert.NotContains(t, err.Error(), "/push/bad", "endpoint paths are capabilities and must not be logged")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "/push/bad", "non-gone subscriptions are kept")
+}
+
+func TestWebPushRequiresConfig(t *testing.T) {
+	p := &webpush.WebPush{}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "subscriptions")
+
+	p = &webpush.WebPush{Subscriptions: writeSubscriptions(t), VAPIDPublicKey: "x", Subject: "mailto:a@b.c"}
+	err = p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no subscriptions")
+}
+
+func TestWebPushMissingPrivateKey(t *testing.T) {
+	srv, _ := fakePushService(t, nil)
+	b := newBrowser(t)
+	p := newPlugin(t, writeSubscriptions(t, b.subscription(srv.URL+"/push/abc")))
+	p.VAPIDPrivateKey = ""
+
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "webpush keygen")
+}
+
+func TestWebPushBadTemplate(t *testing.T) {
+	p := newPlugin(t, writeSubscriptions(t))
+	p.Message = "{{.Invalid"
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rendering message template")
+}
+
+func runKeygen(t *testing.T, configPath string, extra ...string) (string, error) {
+	t.Helper()
+	reg := notifier.NewRegistry()
+	webpush.Register(reg)
+	app := appcli.New("test", reg)
+	var out bytes.Buffer
+	app.Writer = &out
+
+	args := append([]string{"claude-notifier", "--config", configPath, "webpush", "keygen"}, extra...)
+	err := app.Run(args)
+
+	return out.String(), err
+}
+
+func TestKeygenWritesConfig(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	sample := (&webpush.WebPush{}).SampleConfig()
+	require.NoError(t, os.WriteFile(configPath, []byte(sample), 0600))
+
+	out, err := runKeygen(t, configPath)
+	require.NoError(t, err)
+
+	var cfg struct {
+		Notifiers struct {
+			WebPush []webpush.WebPush `toml:"webpush"`
+		} `toml:"notifiers"`
+	}
+	_, err = toml.DecodeFile(configPath, &cfg)
+	require.NoError(t, err)
+	require.Len(t, cfg.Notifiers.WebPush, 1)
+	got := cfg.Notifiers.WebPush[0]
+	assert.NotEmpty(t, got.VAPIDPrivateKey)
+	assert.Contains(t, out, got.VAPIDPublicKey)
+
+	pub, err := base64.RawURLEncoding.DecodeString(got.VAPIDPublicKey)
+	require.NoError(t, err)
+	assert.Len(t, pub, 65)
+	priv, err := base64.RawURLEncoding.DecodeString(got.VAPIDPrivateKey)
+	require.NoError(t, err)
+	assert.Len(t, priv, 32)
+
+	// Existing keys are protected unless --force is given.
+	_, err = runKeygen(t, configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--force")
+
+	_, err = runKeygen(t, configPath, "--force")
+	require.NoError(t, err)
+}
+
+func TestKeygenMissingConfig(t *testing.T) {
+	_, err := runKeygen(t, filepath.Join(t.TempDir(), "missing.toml"))
+	assert.Error(t, err)
+}
+
+func TestWebPushSeverityUrgency(t *testing.T) {
+	srv, records := fakePushService(t, nil)
+	b := newBrowser(t)
+	p := newPlugin(t, writeSubscriptions(t, b.subscription(srv.URL+"/push/abc")))
+	p.SeverityUrgency = map[string]string{"low": "very-low"}
+
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{Message: "done", Severity: "low"}))
+	require.NoError(t, p.Send(context.Background(), notifier.Notification{Message: "done", Severity: "high"}))
+
+	got := records()
+	require.Len(t, got, 2)
+	assert.Equal(t, "very-low", got[0].headers.Get("Urgency"))
+	assert.Equal(t, "high", got[1].headers.Get("Urgency"))
+}
@@ -0,0 +1,473 @@
+package xmpp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // SCRAM-SHA-1 is mandated by RFC 6120
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	nsClient  = "jabber:client"
+	nsStream  = "http://etherx.jabber.org/streams"
+	nsTLS     = "urn:ietf:params:xml:ns:xmpp-tls"
+	nsSASL    = "urn:ietf:params:xml:ns:xmpp-sasl"
+	nsBind    = "urn:ietf:params:xml:ns:xmpp-bind"
+	nsSession = "urn:ietf:params:xml:ns:xmpp-session"
+	nsMUC     = "http://jabber.org/protocol/muc"
+	nsMUCUser = "http://jabber.org/protocol/muc#user"
+
+	nonceSize = 18
+)
+
+// element is a generic XML element tree used to inspect server stanzas.
+type element struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []element  `xml:",any"`
+	Text     string     `xml:",chardata"`
+}
+
+func (e element) attr(name string) string {
+	for _, a := range e.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+
+	return ""
+}
+
+func (e element) child(name string) (element, bool) {
+	for _, c := range e.Children {
+		if c.XMLName.Local == name {
+			return c, true
+		}
+	}
+
+	return element{}, false
+}
+
+// stream is a minimal client-side XMPP stream over a single connection.
+type stream struct {
+	conn   net.Conn
+	dec    *xml.Decoder
+	domain string
+	secure bool
+}
+
+func newStream(conn net.Conn, domain string, secure bool) *stream {
+	return &stream{conn: conn, dec: xml.NewDecoder(conn), domain: domain, secure: secure}
+}
+
+func (s *stream) write(format string, args ...any) error {
+	_, err := fmt.Fprintf(s.conn, format, args...)
+	if err != nil {
+		return fmt.Errorf("writing to server: %w", err)
+	}
+
+	return nil
+}
+
+// open sends a stream header, waits for the server's header and returns the
+// stream features that follow it.
+func (s *stream) open() (element, error) {
+	s.dec = xml.NewDecoder(s.conn)
+	err := s.write("<?xml version='1.0'?><stream:stream to='%s' xmlns='%s' xmlns:stream='%s' version='1.0'>",
+		escape(s.domain), nsClient, nsStream)
+	if err != nil {
+		return element{}, err
+	}
+
+	for {
+		tok, err := s.dec.Token()
+		if err != nil {
+			return element{}, fmt.Errorf("reading stream header: %w", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			if start.Name.Local != "stream" || start.Name.Space != nsStream {
+				return element{}, fmt.Errorf("unexpected stream header <%s>", start.Name.Local)
+			}
+
+			break
+		}
+	}
+
+	features, err := s.next()
+	if err != nil {
+		return element{}, err
+	}
+	if features.XMLName.Local != "features" {
+		return element{}, fmt.Errorf("expected stream features, got <%s>", features.XMLName.Local)
+	}
+
+	return features, nil
+}
+
+// next reads the next top-level element, turning stream errors into Go errors.
+func (s *stream) next() (element, error) {
+	for {
+		tok, err := s.dec.Token()
+		if err != nil {
+			return element{}, fmt.Errorf("reading from server: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			var el element
+			err = s.dec.DecodeElement(&el, &t)
+			if err != nil {
+				return element{}, fmt.Errorf("decoding <%s>: %w", t.Name.Local, err)
+			}
+			if el.XMLName.Local == "error" && el.XMLName.Space == nsStream {
+				return element{}, fmt.Errorf("stream error: %s", describeError(el))
+			}
+
+			return el, nil
+		case xml.EndElement:
+			return element{}, io.EOF
+		}
+	}
+}
+
+// startTLS negotiates STARTTLS and replaces the underlying connection.
+func (s *stream) startTLS(ctx context.Context, cfg *tls.Config) error {
+	err := s.write("<starttls xmlns='%s'/>", nsTLS)
+	if err != nil {
+		return err
+	}
+	resp, err := s.next()
+	if err != nil {
+		return err
+	}
+	if resp.XMLName.Local != "proceed" {
+		return errors.New("server refused STARTTLS")
+	}
+
+	tlsConn := tls.Client(s.conn, cfg)
+	err = tlsConn.HandshakeContext(ctx)
+	if err != nil {
+		return fmt.Errorf("TLS handshake: %w", err)
+	}
+	s.conn = tlsConn
+	s.secure = true
+
+	return nil
+}
+
+// authenticate runs SASL, preferring SCRAM-SHA-1 over PLAIN.
+func (s *stream) authenticate(features element, username, password string) error {
+	mechs := map[string]bool{}
+	if m, ok := features.child("mechanisms"); ok {
+		for _, c := range m.Children {
+			mechs[strings.TrimSpace(c.Text)] = true
+		}
+	}
+
+	switch {
+	case mechs["SCRAM-SHA-1"]:
+		return s.authSCRAM(username, password)
+	case mechs["PLAIN"]:
+		if !s.secure {
+			return errors.New("refusing SASL PLAIN over an unencrypted connection")
+		}
+
+		return s.authPlain(username, password)
+	}
+
+	return errors.New("no supported SASL mechanism (need SCRAM-SHA-1 or PLAIN)")
+}
+
+func (s *stream) authPlain(username, password string) error {
+	payload := base64.StdEncoding.EncodeToString([]byte("\x00" + username + "\x00" + password))
+	err := s.write("<auth xmlns='%s' mechanism='PLAIN'>%s</auth>", nsSASL, payload)
+	if err != nil {
+		return err
+	}
+
+	return s.saslResult()
+}
+
+func (s *stream) authSCRAM(username, password string) error {
+	nonceBytes := make([]byte, nonceSize)
+	_, err := rand.Read(nonceBytes)
+	if err != nil {
+		return fmt.Errorf("generating nonce: %w", err)
+	}
+	clientNonce := base64.RawStdEncoding.EncodeToString(nonceBytes)
+	clientFirstBare := "n=" + scramName(username) + ",r=" + clientNonce
+
+	err = s.write("<auth xmlns='%s' mechanism='SCRAM-SHA-1'>%s</auth>", nsSASL,
+		base64.StdEncoding.EncodeToString([]byte("n,,"+clientFirstBare)))
+	if err != nil {
+		return err
+	}
+
+	challenge, err := s.next()
+	if err != nil {
+		return err
+	}
+	if challenge.XMLName.Local == "failure" {
+		return fmt.Errorf("authentication failed: %s", describeError(challenge))
+	}
+	if challenge.XMLName.Local != "challenge" {
+		return fmt.Errorf("expected SASL challenge, got <%s>", challenge.XMLName.Local)
+	}
+	serverFirst, err := base64.StdEncoding.DecodeString(strings.TrimSpace(challenge.Text))
+	if err != nil {
+		return fmt.Errorf("decoding SASL challenge: %w", err)
+	}
+
+	attrs := parseSCRAM(string(serverFirst))
+	nonce, salt64, iterStr := attrs["r"], attrs["s"], attrs["i"]
+	if !strings.HasPrefix(nonce, clientNonce) || salt64 == "" || iterStr == "" {
+		return errors.New("invalid SCRAM server challenge")
+	}
+	salt, err := base64.StdEncoding.DecodeString(salt64)
+	if err != nil {
+		return fmt.Errorf("decoding SCRAM salt: %w", err)
+	}
+	iterations, err := strconv.Atoi(iterStr)
+	if err != nil || iterations <= 0 {
+		return errors.New("invalid SCRAM iteration count")
+	}
+
+	salted, err := pbkdf2.Key(sha1.New, password, salt, iterations, sha1.Size)
+	if err != nil {
+		return fmt.Errorf("deriving SCRAM key: %w", err)
+	}
+	clientFinalBare := "c=biws,r=" + nonce
+	authMessage := clientFirstBare + "," + string(serverFirst) + "," + clientFinalBare
+
+	clientKey := hmacSHA1(salted, "Client Key")
+	storedKey := sha1.Sum(clientKey)
+	signature := hmacSHA1(storedKey[:], authMessage)
+	proof := make([]byte, len(clientKey))
+	for i := range clientKey {
+		proof[i] = clientKey[i] ^ signature[i]
+	}
+	serverSignature := hmacSHA1(hmacSHA1(salted, "Server Key"), authMessage)
+
+	clientFinal := clientFinalBare + ",p=" + base64.StdEncoding.EncodeToString(proof)
+	err = s.write("<response xmlns='%s'>%s</response>", nsSASL,
+		base64.StdEncoding.EncodeToString([]byte(clientFinal)))
+	if err != nil {
+		return err
+	}
+
+	result, err := s.next()
+	if err != nil {
+		return err
+	}
+	// Some servers deliver the server-final message as a challenge and
+	// send an empty success afterwards.
+	if result.XMLName.Local == "challenge" {
+		err = s.write("<response xmlns='%s'/>", nsSASL)
+		if err != nil {
+			return err
+		}
+		err = s.saslResult()
+		if err != nil {
+			return err
+		}
+	} else if result.XMLName.Local != "success" {
+		return fmt.Errorf("authentication failed: %s", describeError(result))
+	}
+	serverFinal, err := base64.StdEncoding.DecodeString(strings.TrimSpace(result.Text))
+	if err != nil {
+		return fmt.Errorf("decoding SASL success: %w", err)
+	}
+	verifier, err := base64.StdEncoding.DecodeString(parseSCRAM(string(serverFinal))["v"])
+	if err != nil || !hmac.Equal(verifier, serverSignature) {
+		return errors.New("server signature mismatch")
+	}
+
+	return nil
+}
+
+func (s *stream) saslResult() error {
+	result, err := s.next()
+	if err != nil {
+		return err
+	}
+	if result.XMLName.Local != "success" {
+		return fmt.Errorf("authentication failed: %s", describeError(result))
+	}
+
+	return nil
+}
+
+// bind binds a resource and, for older servers, establishes a session.
+func (s *stream) bind(features element, resource string) error {
+	if _, ok := features.child("bind"); !ok {
+		return errors.New("server does not offer resource binding")
+	}
+
+	resourceXML := ""
+	if resource != "" {
+		resourceXML = "<resource>" + escape(resource) + "</resource>"
+	}
+	err := s.write("<iq type='set' id='bind1'><bind xmlns='%s'>%s</bind></iq>", nsBind, resourceXML)
+	if err != nil {
+		return err
+	}
+	err = s.awaitIQ("bind1")
+	if err != nil {
+		return fmt.Errorf("binding resource: %w", err)
+	}
+
+	if session, ok := features.child("session"); ok {
+		if _, optional := session.child("optional"); !optional {
+			err = s.write("<iq type='set' id='sess1'><session xmlns='%s'/></iq>", nsSession)
+			if err != nil {
+				return err
+			}
+			err = s.awaitIQ("sess1")
+			if err != nil {
+				return fmt.Errorf("establishing session: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *stream) awaitIQ(id string) error {
+	for {
+		el, err := s.next()
+		if err != nil {
+			return err
+		}
+		if el.XMLName.Local != "iq" || el.attr("id") != id {
+			continue
+		}
+		if el.attr("type") == "error" {
+			return errors.New(describeError(el))
+		}
+
+		return nil
+	}
+}
+
+// joinRoom enters a MUC room and waits for the server to reflect our own
+// presence back, which signals that the join completed.
+func (s *stream) joinRoom(room, nick, password string) error {
+	passwordXML := ""
+	if password != "" {
+		passwordXML = "<password>" + escape(password) + "</password>"
+	}
+	err := s.write("<presence to='%s/%s'><x xmlns='%s'><history maxstanzas='0'/>%s</x></presence>",
+		escape(room), escape(nick), nsMUC, passwordXML)
+	if err != nil {
+		return err
+	}
+
+	for {
+		el, err := s.next()
+		if err != nil {
+			return err
+		}
+		if el.XMLName.Local != "presence" {
+			continue
+		}
+		from, _, _ := strings.Cut(el.attr("from"), "/")
+		if !strings.EqualFold(from, room) {
+			continue
+		}
+		if el.attr("type") == "error" {
+			return fmt.Errorf("joining %s: %s", room, describeError(el))
+		}
+		if isSelfPresence(el) {
+			return nil
+		}
+	}
+}
+
+func isSelfPresence(el element) bool {
+	x, ok := el.child("x")
+	if !ok || x.XMLName.Space != nsMUCUser {
+		return false
+	}
+	for _, c := range x.Children {
+		if c.XMLName.Local == "status" && c.attr("code") == "110" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *stream) message(to, kind, body string) error {
+	return s.write("<message to='%s' type='%s'><body>%s</body></message>", escape(to), kind, escape(body))
+}
+
+func (s *stream) close() {
+	_ = s.write("</stream:stream>")
+	// Give the server a chance to close its side of the stream.
+	for {
+		_, err := s.next()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// describeError renders the condition and optional text of an error element.
+func describeError(el element) string {
+	target := el
+	if e, ok := el.child("error"); ok {
+		target = e
+	}
+	var parts []string
+	for _, c := range target.Children {
+		if c.XMLName.Local == "text" {
+			parts = append(parts, strings.TrimSpace(c.Text))
+		} else {
+			parts = append(parts, c.XMLName.Local)
+		}
+	}
+	if len(parts) == 0 {
+		return target.XMLName.Local
+	}
+
+	return strings.Join(parts, ": ")
+}
+
+func escape(s string) string {
+	var buf strings.Builder
+	_ = xml.EscapeText(&buf, []byte(s))
+
+	return buf.String()
+}
+
+func hmacSHA1(key []byte, msg string) []byte {
+	mac := hmac.New(sha1.New, key)
+	_, _ = mac.Write([]byte(msg))
+
+	return mac.Sum(nil)
+}
+
+// scramName escapes a username for the SCRAM n= attribute (RFC 5802 §5.1).
+func scramName(name string) string {
+	return strings.NewReplacer("=", "=3D", ",", "=2C").Replace(name)
+}
+
+func parseSCRAM(msg string) map[string]string {
+	attrs := map[string]string{}
+	for part := range strings.SplitSeq(msg, ",") {
+		if k, v, ok := strings.Cut(part, "="); ok {
+			attrs[k] = v
+		}
+	}
+
+	return attrs
+}
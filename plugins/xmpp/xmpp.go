@@ -0,0 +1,262 @@
+package xmpp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/internal/tmpl"
+)
+
+const (
+	dialTimeout     = 30 * time.Second
+	startTLSPort    = "5222"
+	directTLSPort   = "5223"
+	modeStartTLS    = "starttls"
+	modeDirectTLS   = "direct"
+	modeNone        = "none"
+	defaultResource = "claude-notifier"
+)
+
+// XMPP sends notifications as XMPP chat or groupchat messages.
+type XMPP struct {
+	JID                string            `toml:"jid"`
+	Password           string            `toml:"password"`
+	Server             string            `toml:"server"`
+	TLS                string            `toml:"tls"`
+	InsecureSkipVerify bool              `toml:"insecure_skip_verify"`
+	Resource           string            `toml:"resource"`
+	To                 string            `toml:"to"`
+	Room               string            `toml:"room"`
+	RoomNick           string            `toml:"room_nick"`
+	RoomPassword       string            `toml:"room_password"`
+	Message            string            `toml:"message"`
+	Vars               map[string]string `toml:"vars"`
+}
+
+// ApplyDefaults sets sane defaults on a new XMPP instance.
+func ApplyDefaults(n *XMPP) {
+	n.TLS = modeStartTLS
+	n.Resource = defaultResource
+	n.RoomNick = "claude-notifier"
+	n.Message = "[{{.Project}}] {{.Message}}"
+}
+
+func (n *XMPP) Name() string { return "xmpp" }
+
+func (n *XMPP) Send(ctx context.Context, notif notifier.Notification) error {
+	username, domain, ok := strings.Cut(n.JID, "@")
+	if !ok || username == "" || domain == "" {
+		return errors.New("jid must be of the form user@domain")
+	}
+	domain, _, _ = strings.Cut(domain, "/")
+	if n.To == "" && n.Room == "" {
+		return errors.New("to or room is required")
+	}
+
+	mode := n.TLS
+	if mode == "" {
+		mode = modeStartTLS
+	}
+	if mode != modeStartTLS && mode != modeDirectTLS && mode != modeNone {
+		return fmt.Errorf("unknown tls mode %q (want starttls, direct or none)", mode)
+	}
+
+	tctx := tmpl.BuildContext(notif, n.Vars)
+
+	msgTmpl := n.Message
+	if msgTmpl == "" {
+		msgTmpl = "[{{.Project}}] {{.Message}}"
+	}
+	body, err := tmpl.Render("message", msgTmpl, tctx)
+	if err != nil {
+		return err
+	}
+
+	conn, err := n.dial(ctx, domain, mode)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	// Unblock any pending read or write as soon as the context ends.
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	s := newStream(conn, domain, mode == modeDirectTLS)
+	err = n.session(ctx, s, username, body, mode)
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("xmpp session: %w", ctx.Err())
+	}
+
+	return err
+}
+
+func (n *XMPP) tlsConfig(domain string) *tls.Config {
+	return &tls.Config{
+		ServerName:         domain,
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: n.InsecureSkipVerify, //nolint:gosec // opt-in for self-signed servers
+	}
+}
+
+func (n *XMPP) dial(ctx context.Context, domain, mode string) (net.Conn, error) {
+	addr := n.Server
+	if addr == "" {
+		port := startTLSPort
+		if mode == modeDirectTLS {
+			port = directTLSPort
+		}
+		addr = net.JoinHostPort(domain, port)
+	}
+
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if mode == modeDirectTLS {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: n.tlsConfig(domain)}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", addr, err)
+	}
+
+	return conn, nil
+}
+
+// session negotiates the stream, authenticates, binds a resource and sends
+// the message.
+func (n *XMPP) session(ctx context.Context, s *stream, username, body, mode string) error {
+	features, err := s.open()
+	if err != nil {
+		return err
+	}
+
+	if mode == modeStartTLS {
+		if _, ok := features.child("starttls"); !ok {
+			return errors.New("server does not offer STARTTLS")
+		}
+		err = s.startTLS(ctx, n.tlsConfig(s.domain))
+		if err != nil {
+			return err
+		}
+		features, err = s.open()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.authenticate(features, username, n.Password)
+	if err != nil {
+		return err
+	}
+
+	features, err = s.open()
+	if err != nil {
+		return err
+	}
+	resource := n.Resource
+	if resource == "" {
+		resource = defaultResource
+	}
+	err = s.bind(features, resource)
+	if err != nil {
+		return err
+	}
+
+	if n.To != "" {
+		err = s.message(n.To, "chat", body)
+		if err != nil {
+			return err
+		}
+	}
+
+	if n.Room != "" {
+		nick := n.RoomNick
+		if nick == "" {
+			nick = username
+		}
+		err = s.joinRoom(n.Room, nick, n.RoomPassword)
+		if err != nil {
+			return err
+		}
+		err = s.message(n.Room, "groupchat", body)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.close()
+
+	return nil
+}
+
+// SampleConfig returns example TOML configuration.
+func (n *XMPP) SampleConfig() string {
+	return `## XMPP (Jabber) messages
+[[notifiers.xmpp]]
+
+## Account to log in with (required)
+jid = "bot@example.com"
+password = ""
+
+## Recipient JID for a direct chat message
+## Either to or room is required
+to = "me@example.com"
+
+## Multi-user chat room to post into
+# room = "ops@conference.example.com"
+# room_nick = "claude-notifier"
+# room_password = ""
+
+## Server address as host:port
+## Defaults to the JID domain on port 5222 (starttls) or 5223 (direct)
+# server = ""
+
+## Transport security: "starttls", "direct" (TLS from the first byte) or "none"
+# tls = "starttls"
+
+## Skip TLS certificate verification (self-signed servers only)
+# insecure_skip_verify = false
+
+## Resource to bind
+# resource = "claude-notifier"
+
+## Go template for the message body
+## Available variables: {{.Message}}, {{.Title}}, {{.Project}}, {{.Cwd}},
+## {{.NotificationType}}, {{.SessionID}}, {{.TranscriptPath}}, {{.Severity}}
+## Custom variables from [notifiers.xmpp.vars] are also available, title-cased
+# message = "[{{.Project}}] {{.Message}}"
+
+## User-defined template variables
+## Keys are title-cased for template access (env -> {{.Env}})
+# [notifiers.xmpp.vars]
+# env = "production"
+`
+}
+
+// Register adds xmpp to the given plugin registry.
+func Register(reg *notifier.Registry) {
+	err := reg.Register("xmpp", func() notifier.Notifier {
+		n := &XMPP{}
+		ApplyDefaults(n)
+
+		return n
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,507 @@
+package xmpp_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/felipeelias/claude-notifier/internal/notifier"
+	"github.com/felipeelias/claude-notifier/plugins/xmpp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stanza struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []stanza   `xml:",any"`
+	Text     string     `xml:",chardata"`
+}
+
+func (s stanza) attr(name string) string {
+	for _, a := range s.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+
+	return ""
+}
+
+func (s stanza) child(name string) stanza {
+	for _, c := range s.Children {
+		if c.XMLName.Local == name {
+			return c
+		}
+	}
+
+	return stanza{}
+}
+
+type serverOpts struct {
+	mechanisms []string
+	password   string
+	startTLS   *tls.Config
+	roomError  bool
+}
+
+// fakeServer is a scripted, single-connection XMPP server.
+type fakeServer struct {
+	addr     string
+	opts     serverOpts
+	mu       sync.Mutex
+	received []stanza
+	authMech string
+	err      error
+	done     chan struct{}
+}
+
+func newFakeServer(t *testing.T, opts serverOpts) *fakeServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	srv := &fakeServer{addr: listener.Addr().String(), opts: opts, done: make(chan struct{})}
+	go func() {
+		defer close(srv.done)
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+		srv.err = srv.serve(conn)
+	}()
+
+	return srv
+}
+
+func (f *fakeServer) wait(t *testing.T) []stanza {
+	t.Helper()
+	select {
+	case <-f.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("fake server did not finish")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]stanza(nil), f.received...)
+}
+
+func readHeader(dec *xml.Decoder) error {
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local == "stream" {
+			return nil
+		}
+	}
+}
+
+// readStanza returns the next element, or ok=false when the client closes
+// the stream.
+func readStanza(dec *xml.Decoder) (stanza, bool, error) {
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return stanza{}, false, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			var s stanza
+			err = dec.DecodeElement(&s, &t)
+
+			return s, true, err
+		case xml.EndElement:
+			return stanza{}, false, nil
+		}
+	}
+}
+
+const header = "<?xml version='1.0'?><stream:stream xmlns='jabber:client' " +
+	"xmlns:stream='http://etherx.jabber.org/streams' id='s1' from='example.com' version='1.0'>"
+
+func (f *fakeServer) serve(conn net.Conn) error {
+	dec := xml.NewDecoder(conn)
+	if err := readHeader(dec); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprint(conn, header)
+
+	if f.opts.startTLS != nil {
+		_, _ = fmt.Fprint(conn, "<stream:features><starttls xmlns='urn:ietf:params:xml:ns:xmpp-tls'><required/></starttls></stream:features>")
+		el, _, err := readStanza(dec)
+		if err != nil || el.XMLName.Local != "starttls" {
+			return fmt.Errorf("expected starttls: %w", err)
+		}
+		_, _ = fmt.Fprint(conn, "<proceed xmlns='urn:ietf:params:xml:ns:xmpp-tls'/>")
+		tlsConn := tls.Server(conn, f.opts.startTLS)
+		if err := tlsConn.Handshake(); err != nil {
+			return err
+		}
+		conn = tlsConn
+		dec = xml.NewDecoder(conn)
+		if err := readHeader(dec); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprint(conn, header)
+	}
+
+	var mechs strings.Builder
+	for _, m := range f.opts.mechanisms {
+		mechs.WriteString("<mechanism>" + m + "</mechanism>")
+	}
+	_, _ = fmt.Fprintf(conn, "<stream:features><mechanisms xmlns='urn:ietf:params:xml:ns:xmpp-sasl'>%s</mechanisms></stream:features>", mechs.String())
+
+	auth, _, err := readStanza(dec)
+	if err != nil {
+		return err
+	}
+	f.authMech = auth.attr("mechanism")
+	ok, err := f.authenticate(conn, dec, auth)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		_, _ = fmt.Fprint(conn, "<failure xmlns='urn:ietf:params:xml:ns:xmpp-sasl'><not-authorized/></failure>")
+
+		return nil
+	}
+
+	dec = xml.NewDecoder(conn)
+	if err := readHeader(dec); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprint(conn, header)
+	_, _ = fmt.Fprint(conn, "<stream:features><bind xmlns='urn:ietf:params:xml:ns:xmpp-bind'/>"+
+		"<session xmlns='urn:ietf:params:xml:ns:xmpp-session'><optional/></session></stream:features>")
+
+	for {
+		el, ok, err := readStanza(dec)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			_, _ = fmt.Fprint(conn, "</stream:stream>")
+
+			return nil
+		}
+		f.mu.Lock()
+		f.received = append(f.received, el)
+		f.mu.Unlock()
+
+		switch el.XMLName.Local {
+		case "iq":
+			resource := el.child("bind").child("resource").Text
+			_, _ = fmt.Fprintf(conn, "<iq type='result' id='%s'><bind xmlns='urn:ietf:params:xml:ns:xmpp-bind'>"+
+				"<jid>bot@example.com/%s</jid></bind></iq>", el.attr("id"), resource)
+		case "presence":
+			to := el.attr("to")
+			room, _, _ := strings.Cut(to, "/")
+			// Other occupants first, then our own reflected presence.
+			_, _ = fmt.Fprintf(conn, "<presence from='%s/alice'><x xmlns='http://jabber.org/protocol/muc#user'>"+
+				"<item affiliation='member' role='participant'/></x></presence>", room)
+			if f.opts.roomError {
+				_, _ = fmt.Fprintf(conn, "<presence from='%s' type='error'><error type='auth'>"+
+					"<not-authorized xmlns='urn:ietf:params:xml:ns:xmpp-stanzas'/></error></presence>", to)
+			} else {
+				_, _ = fmt.Fprintf(conn, "<presence from='%s'><x xmlns='http://jabber.org/protocol/muc#user'>"+
+					"<item affiliation='none' role='participant'/><status code='110'/></x></presence>", to)
+			}
+		}
+	}
+}
+
+func (f *fakeServer) authenticate(conn net.Conn, dec *xml.Decoder, auth stanza) (bool, error) {
+	payload, err := base64.StdEncoding.DecodeString(auth.Text)
+	if err != nil {
+		return false, err
+	}
+
+	switch auth.attr("mechanism") {
+	case "PLAIN":
+		parts := strings.Split(string(payload), "\x00")
+		if len(parts) != 3 || parts[1] != "bot" || parts[2] != f.opts.password {
+			return false, nil
+		}
+		_, _ = fmt.Fprint(conn, "<success xmlns='urn:ietf:params:xml:ns:xmpp-sasl'/>")
+
+		return true, nil
+	case "SCRAM-SHA-1":
+		clientFirstBare := strings.TrimPrefix(string(payload), "n,,")
+		clientNonce := strings.TrimPrefix(strings.Split(clientFirstBare, ",")[1], "r=")
+		salt := []byte("pepper")
+		serverFirst := "r=" + clientNonce + "srv,s=" + base64.StdEncoding.EncodeToString(salt) + ",i=4096"
+		_, _ = fmt.Fprintf(conn, "<challenge xmlns='urn:ietf:params:xml:ns:xmpp-sasl'>%s</challenge>",
+			base64.StdEncoding.EncodeToString([]byte(serverFirst)))
+
+		resp, _, err := readStanza(dec)
+		if err != nil {
+			return false, err
+		}
+		clientFinal, err := base64.StdEncoding.DecodeString(resp.Text)
+		if err != nil {
+			return false, err
+		}
+		withoutProof, proof64, _ := strings.Cut(string(clientFinal), ",p=")
+		proof, err := base64.StdEncoding.DecodeString(proof64)
+		if err != nil {
+			return false, err
+		}
+
+		salted, err := pbkdf2.Key(sha1.New, f.opts.password, salt, 4096, sha1.Size)
+		if err != nil {
+			return false, err
+		}
+		authMessage := clientFirstBare + "," + serverFirst + "," + withoutProof
+		clientKey := mac(salted, "Client Key")
+		storedKey := sha1.Sum(clientKey)
+		signature := mac(storedKey[:], authMessage)
+		recovered := make([]byte, len(proof))
+		for i := range proof {
+			recovered[i] = proof[i] ^ signature[i]
+		}
+		if sum := sha1.Sum(recovered); !hmac.Equal(sum[:], storedKey[:]) {
+			return false, nil
+		}
+		serverSig := mac(mac(salted, "Server Key"), authMessage)
+		_, _ = fmt.Fprintf(conn, "<success xmlns='urn:ietf:params:xml:ns:xmpp-sasl'>%s</success>",
+			base64.StdEncoding.EncodeToString([]byte("v="+base64.StdEncoding.EncodeToString(serverSig))))
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func mac(key []byte, msg string) []byte {
+	h := hmac.New(sha1.New, key)
+	_, _ = h.Write([]byte(msg))
+
+	return h.Sum(nil)
+}
+
+func messages(stanzas []stanza) []stanza {
+	var out []stanza
+	for _, s := range stanzas {
+		if s.XMLName.Local == "message" {
+			out = append(out, s)
+		}
+	}
+
+	return out
+}
+
+func TestXMPPName(t *testing.T) {
+	p := &xmpp.XMPP{}
+	assert.Equal(t, "xmpp", p.Name())
+}
+
+func TestXMPPDefaults(t *testing.T) {
+	p := &xmpp.XMPP{}
+	xmpp.ApplyDefaults(p)
+	assert.Equal(t, "starttls", p.TLS)
+	assert.Equal(t, "claude-notifier", p.Resource)
+	assert.Equal(t, "[{{.Project}}] {{.Message}}", p.Message)
+}
+
+func TestXMPPImplementsNotifier(t *testing.T) {
+	var _ notifier.Notifier = &xmpp.XMPP{}
+}
+
+func TestXMPPChatWithSCRAM(t *testing.T) {
+	srv := newFakeServer(t, serverOpts{mechanisms: []string{"PLAIN", "SCRAM-SHA-1"}, password: "s3cret"})
+
+	p := &xmpp.XMPP{}
+	xmpp.ApplyDefaults(p)
+	p.JID = "bot@example.com"
+	p.Password = "s3cret"
+	p.Server = srv.addr
+	p.TLS = "none"
+	p.To = "me@example.com"
+
+	err := p.Send(context.Background(), notifier.Notification{
+		Message: "Claude needs permission & <input>",
+		Cwd:     "/home/user/billing-api",
+	})
+	require.NoError(t, err)
+
+	stanzas := srv.wait(t)
+	require.NoError(t, srv.err)
+	assert.Equal(t, "SCRAM-SHA-1", srv.authMech)
+	assert.Equal(t, "claude-notifier", stanzas[0].child("bind").child("resource").Text)
+
+	msgs := messages(stanzas)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "me@example.com", msgs[0].attr("to"))
+	assert.Equal(t, "chat", msgs[0].attr("type"))
+	assert.Equal(t, "[billing-api] Claude needs permission & <input>", msgs[0].child("body").Text)
+}
+
+func TestXMPPWrongPassword(t *testing.T) {
+	srv := newFakeServer(t, serverOpts{mechanisms: []string{"SCRAM-SHA-1"}, password: "right"})
+
+	p := &xmpp.XMPP{JID: "bot@example.com", Password: "wrong", Server: srv.addr, TLS: "none", To: "me@example.com"}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not-authorized")
+}
+
+func TestXMPPRefusesPlainWithoutTLS(t *testing.T) {
+	srv := newFakeServer(t, serverOpts{mechanisms: []string{"PLAIN"}, password: "pw"})
+
+	p := &xmpp.XMPP{JID: "bot@example.com", Password: "pw", Server: srv.addr, TLS: "none", To: "me@example.com"}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "refusing SASL PLAIN")
+}
+
+func TestXMPPStartTLSWithPlain(t *testing.T) {
+	certSrv := httptest.NewTLSServer(http.NotFoundHandler())
+	certSrv.Close()
+	srv := newFakeServer(t, serverOpts{
+		mechanisms: []string{"PLAIN"},
+		password:   "pw",
+		startTLS:   &tls.Config{Certificates: certSrv.TLS.Certificates, MinVersion: tls.VersionTLS12},
+	})
+
+	p := &xmpp.XMPP{
+		JID:                "bot@example.com",
+		Password:           "pw",
+		Server:             srv.addr,
+		TLS:                "starttls",
+		InsecureSkipVerify: true,
+		To:                 "me@example.com",
+		Message:            "{{.Message}}",
+	}
+	err := p.Send(context.Background(), notifier.Notification{Message: "over tls"})
+	require.NoError(t, err)
+
+	stanzas := srv.wait(t)
+	require.NoError(t, srv.err)
+	assert.Equal(t, "PLAIN", srv.authMech)
+	msgs := messages(stanzas)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "over tls", msgs[0].child("body").Text)
+}
+
+func TestXMPPStartTLSNotOffered(t *testing.T) {
+	srv := newFakeServer(t, serverOpts{mechanisms: []string{"PLAIN"}, password: "pw"})
+
+	p := &xmpp.XMPP{JID: "bot@example.com", Password: "pw", Server: srv.addr, TLS: "starttls", To: "me@example.com"}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "STARTTLS")
+}
+
+func TestXMPPGroupchat(t *testing.T) {
+	srv := newFakeServer(t, serverOpts{mechanisms: []string{"SCRAM-SHA-1"}, password: "pw"})
+
+	p := &xmpp.XMPP{
+		JID:          "bot@example.com",
+		Password:     "pw",
+		Server:       srv.addr,
+		TLS:          "none",
+		Room:         "ops@conference.example.com",
+		RoomNick:     "claude",
+		RoomPassword: "roompw",
+		Message:      "{{.Message}}",
+	}
+	err := p.Send(context.Background(), notifier.Notification{Message: "deploy waiting"})
+	require.NoError(t, err)
+
+	stanzas := srv.wait(t)
+	require.NoError(t, srv.err)
+
+	var presence stanza
+	for _, s := range stanzas {
+		if s.XMLName.Local == "presence" {
+			presence = s
+		}
+	}
+	assert.Equal(t, "ops@conference.example.com/claude", presence.attr("to"))
+	assert.Equal(t, "roompw", presence.child("x").child("password").Text)
+
+	msgs := messages(stanzas)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "ops@conference.example.com", msgs[0].attr("to"))
+	assert.Equal(t, "groupchat", msgs[0].attr("type"))
+	assert.Equal(t, "deploy waiting", msgs[0].child("body").Text)
+}
+
+func TestXMPPGroupchatJoinError(t *testing.T) {
+	srv := newFakeServer(t, serverOpts{mechanisms: []string{"SCRAM-SHA-1"}, password: "pw", roomError: true})
+
+	p := &xmpp.XMPP{
+		JID:      "bot@example.com",
+		Password: "pw",
+		Server:   srv.addr,
+		TLS:      "none",
+		Room:     "ops@conference.example.com",
+	}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not-authorized")
+}
+
+func TestXMPPRespectsContextDeadline(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer func() { _ = conn.Close() }()
+			time.Sleep(2 * time.Second) // never answer
+		}
+	}()
+
+	p := &xmpp.XMPP{JID: "bot@example.com", Server: listener.Addr().String(), TLS: "none", To: "me@example.com"}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = p.Send(ctx, notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestXMPPValidation(t *testing.T) {
+	p := &xmpp.XMPP{JID: "not-a-jid", To: "me@example.com"}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "jid")
+
+	p = &xmpp.XMPP{JID: "bot@example.com"}
+	err = p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "to or room")
+
+	p = &xmpp.XMPP{JID: "bot@example.com", To: "me@example.com", TLS: "ssl"}
+	err = p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown tls mode")
+}
+
+func TestXMPPBadTemplate(t *testing.T) {
+	p := &xmpp.XMPP{JID: "bot@example.com", To: "me@example.com", Message: "{{.Invalid"}
+	err := p.Send(context.Background(), notifier.Notification{Message: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rendering message template")
+}